@@ -0,0 +1,140 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+// Command rxnminer-embed trains word embeddings on a corpus -- a TSV
+// or JSON lines file, possibly gzipped, or a directory of texts -- and
+// saves them in the text or binary format of word2vec.
+//
+// Documents are tokenized and their words assembled as by the rest of
+// the suite, so that the vocabulary of the embeddings matches that of
+// its other tools.  The nearest neighbours of some words can be
+// printed, to check the embeddings:
+//
+//	rxnminer-embed -dim 100 -epochs 5 -out vectors.bin -binary -show toluene,chlorobenzene patents.tsv.gz
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/RxnWeaver/RxnMiner/corpus"
+	"github.com/RxnWeaver/RxnMiner/embedding"
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+var (
+	defs = embedding.DefaultOptions()
+
+	out      = flag.String("out", "", "Output vectors file; standard output, if not given")
+	binary   = flag.Bool("binary", false, "Write vectors in the binary format, rather than text")
+	workers  = flag.Int("workers", 0, "Number of tokenizing workers; the number of CPUs, if not given")
+	dim      = flag.Int("dim", defs.Dim, "Dimension of the vectors")
+	window   = flag.Int("window", defs.Window, "Largest number of context words on either side")
+	negative = flag.Int("negative", defs.Negative, "Number of negative samples per context word")
+	minCount = flag.Int("mincount", defs.MinCount, "Drop words seen less often")
+	epochs   = flag.Int("epochs", defs.Epochs, "Number of passes over the corpus")
+	alpha    = flag.Float64("alpha", defs.Alpha, "Initial learning rate")
+	sample   = flag.Float64("sample", defs.Sample, "Threshold of the subsampling of frequent words; 0 disables it")
+	minN     = flag.Int("minn", defs.MinN, "Length of the shortest subwords; 0 disables subwords")
+	maxN     = flag.Int("maxn", defs.MaxN, "Length of the longest subwords")
+	buckets  = flag.Int("buckets", defs.Buckets, "Number of hashed subword vectors")
+	seed     = flag.Int64("seed", defs.Seed, "Seed of the random numbers")
+	show     = flag.String("show", "", "Comma-separated words whose nearest neighbours are printed")
+)
+
+func main() {
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatalf("Expected a single corpus")
+	}
+
+	o := embedding.Options{
+		Dim:      *dim,
+		Window:   *window,
+		Negative: *negative,
+		MinCount: *minCount,
+		Epochs:   *epochs,
+		Alpha:    *alpha,
+		Sample:   *sample,
+		MinN:     *minN,
+		MaxN:     *maxN,
+		Buckets:  *buckets,
+		Seed:     *seed,
+	}
+	tr, err := embedding.NewTrainer(o)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	src, err := corpus.OpenSource(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer src.Close()
+	pl, err := tkz.NewPipelineFromNames("tokenize", "sentences", "words")
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	opts := corpus.Options{Workers: *workers, Pipeline: pl}
+	opts.Sink = func(res *corpus.Result) error {
+		if res.Err != nil {
+			return nil // Recorded in the statistics
+		}
+		return tr.AddDocument(res.Doc)
+	}
+	c, err := corpus.NewCorpus(src, opts)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	stats, err := c.Run()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	for _, f := range stats.Failures {
+		log.Printf("Document %d (%s) failed : %v", f.Seq, f.ID, f.Err)
+	}
+	fmt.Fprintf(os.Stderr, "Read %d documents, %d sentences; %d distinct words\n", stats.Documents, stats.Sentences, tr.Len())
+
+	m, err := tr.Train()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Trained %d vectors of dimension %d\n", m.Len(), m.Dim())
+
+	if *show != "" {
+		for _, w := range strings.Split(*show, ",") {
+			ns, err := m.Nearest(strings.ToLower(strings.TrimSpace(w)), 10)
+			if err != nil {
+				log.Printf("%v", err)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "%s :", w)
+			for _, n := range ns {
+				fmt.Fprintf(os.Stderr, " %s (%.3f)", n.Word, n.Similarity)
+			}
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		if w, err = os.Create(*out); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	write := m.WriteText
+	if *binary {
+		write = m.WriteBinary
+	}
+	if err := write(w); err != nil {
+		log.Fatalf("%v", err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+// Command rxnminer-sentmodel trains a statistical sentence boundary
+// model from texts and the offsets of their reference sentences, in
+// the format of `tokenizer/testdata`, and saves it to a file.
+//
+// A fraction of the documents can be held out, to report the precision
+// and recall of the model:
+//
+//	rxnminer-sentmodel -text patent_7k_text.txt.gz -refs patent_7k_ref.txt -holdout 0.2 -out model.json
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+var (
+	text    = flag.String("text", "", "Input texts; gzipped, if the name ends in .gz")
+	refs    = flag.String("refs", "", "Offsets of the reference sentences")
+	out     = flag.String("out", "", "Output model file; standard output, if not given")
+	iters   = flag.Int("iters", 5, "Number of training iterations")
+	holdout = flag.Float64("holdout", 0, "Fraction of the examples to hold out for evaluation")
+	abbrevs = flag.String("abbrevs", "default", "Abbreviation set: default or chemistry")
+)
+
+// open answers a reader of the named file, decompressing it if its name
+// ends in `.gz`.
+func open(name string) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(name, ".gz") {
+		return f, nil
+	}
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gr, f}, nil
+}
+
+func main() {
+	flag.Parse()
+	if *text == "" || *refs == "" {
+		log.Fatalf("Expected both -text and -refs")
+	}
+	if *holdout < 0 || *holdout >= 1 {
+		log.Fatalf("Invalid holdout fraction : %v", *holdout)
+	}
+
+	var a *tkz.AbbrevSet
+	switch *abbrevs {
+	case "default":
+		a = tkz.DefaultAbbrevs()
+	case "chemistry":
+		a = tkz.ChemistryAbbrevs()
+	default:
+		log.Fatalf("Unknown abbreviation set : %s", *abbrevs)
+	}
+
+	tr, err := open(*text)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer tr.Close()
+	rr, err := open(*refs)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer rr.Close()
+
+	exs, err := tkz.ReadSentenceExamples(tr, rr)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	n := len(exs) - int(float64(len(exs))**holdout)
+	m := tkz.TrainSentenceModel(exs[:n], a, *iters)
+
+	if n < len(exs) {
+		tp, fp, fn := m.Evaluate(exs[n:], a)
+		fmt.Fprintf(os.Stderr, "Precision : %.4f, recall : %.4f (%d held-out examples)\n",
+			float64(tp)/float64(tp+fp), float64(tp)/float64(tp+fn), len(exs)-n)
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		if w, err = os.Create(*out); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	if err := m.Save(w); err != nil {
+		log.Fatalf("%v", err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+// Command rxnminer-server serves tokenization, sentence assembly and
+// annotation of documents over HTTP, as JSON.
+//
+// Additional abbreviation sets can be loaded from files, each holding
+// one non-terminating abbreviation per line:
+//
+//	rxnminer-server -addr :8080 -abbrevs lab=lab-abbrevs.txt
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/RxnWeaver/RxnMiner/server"
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+// abbrevFlags collects repeated `-abbrevs name=path` flags.
+type abbrevFlags map[string]string
+
+func (af abbrevFlags) String() string {
+	var ss []string
+	for k, v := range af {
+		ss = append(ss, k+"="+v)
+	}
+	return strings.Join(ss, ",")
+}
+
+func (af abbrevFlags) Set(s string) error {
+	i := strings.Index(s, "=")
+	if i <= 0 || i == len(s)-1 {
+		return fmt.Errorf("Expected name=path : %s", s)
+	}
+	af[s[:i]] = s[i+1:]
+	return nil
+}
+
+// loadAbbrevs answers the default abbreviation set, extended with the
+// abbreviations in the given file.
+func loadAbbrevs(path string) (*tkz.AbbrevSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var abbrevs []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		s := strings.TrimSpace(sc.Text())
+		if s != "" && !strings.HasPrefix(s, "#") {
+			abbrevs = append(abbrevs, s)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return tkz.DefaultAbbrevs().With(abbrevs...), nil
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "Address to listen on")
+	maxBody := flag.Int64("max-body", 1<<20, "Maximum size of a request body, in bytes")
+	maxDocs := flag.Int("max-docs", 100, "Maximum number of documents per request")
+	af := abbrevFlags{}
+	flag.Var(af, "abbrevs", "Abbreviation set to load, as name=path; repeatable")
+	flag.Parse()
+
+	cfg := server.Config{MaxBodyBytes: *maxBody, MaxDocuments: *maxDocs, AbbrevSets: map[string]*tkz.AbbrevSet{}}
+	for name, path := range af {
+		a, err := loadAbbrevs(path)
+		if err != nil {
+			log.Fatalf("Unable to load abbreviations %s : %s", name, err.Error())
+		}
+		cfg.AbbrevSets[name] = a
+	}
+
+	log.Printf("Listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server.NewServer(cfg)))
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+// Command rxnminer assembles the sentences of its input, and prints
+// them with their offsets.
+//
+// Plain text is read from the named file, or from the standard input.
+// With `-corpus`, the named file or directory is read as a corpus
+// instead (see package corpus); `-id` restricts the output to one
+// document.  With `-trace`, the boundary decisions taken while
+// assembling each sentence are printed below it:
+//
+//	rxnminer -corpus -id CA2054325C -trace patent_7k_text.txt.gz
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/RxnWeaver/RxnMiner/corpus"
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+var (
+	trace   = flag.Bool("trace", false, "Print the boundary decisions of each sentence")
+	groups  = flag.Bool("groups", false, "Do not end sentences inside open groups; print unbalanced groups")
+	quotes  = flag.Bool("quotes", false, "Do not end sentences inside open quotations; print unbalanced quotations")
+	tech    = flag.Bool("technical", false, "Treat input as technical documents")
+	abbrevs = flag.String("abbrevs", "", "Abbreviation set: default or chemistry; that of the language, if not given")
+	lang    = flag.String("lang", "", "Language pack: de, en, fr, hi, ja, te or zh")
+	segment = flag.Bool("segment", false, "Subdivide Chinese and Japanese text into words")
+	smodel  = flag.String("sentmodel", "", "Sentence model file, or default for the bundled one; rules, if not given")
+	chem    = flag.Bool("chemstarts", false, "Begin sentences with chemical names, locants and quantities too")
+	isCorp  = flag.Bool("corpus", false, "Read input as a corpus: TSV, JSON lines or a directory")
+	id      = flag.String("id", "", "Only process the corpus document with this identifier")
+)
+
+// model assembles sentences, if given.
+var model *tkz.SentenceModel
+
+func main() {
+	flag.Parse()
+	if flag.NArg() > 1 {
+		log.Fatalf("Expected at most one input")
+	}
+
+	var a *tkz.AbbrevSet
+	switch *abbrevs {
+	case "":
+	case "default":
+		a = tkz.DefaultAbbrevs()
+	case "chemistry":
+		a = tkz.ChemistryAbbrevs()
+	default:
+		log.Fatalf("Unknown abbreviation set : %s", *abbrevs)
+	}
+	var lp *tkz.LanguagePack
+	if *lang != "" {
+		if lp = tkz.Language(*lang); lp == nil {
+			log.Fatalf("Unknown language : %s", *lang)
+		}
+	}
+
+	switch *smodel {
+	case "":
+	case "default":
+		model = tkz.DefaultSentenceModel()
+	default:
+		f, err := os.Open(*smodel)
+		if err != nil {
+			log.Fatalf("Unable to read sentence model : %s", err.Error())
+		}
+		model, err = tkz.LoadSentenceModel(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Unable to read sentence model : %s", err.Error())
+		}
+	}
+
+	if !*isCorp {
+		var b []byte
+		var err error
+		name := "stdin"
+		if flag.NArg() == 1 {
+			name = flag.Arg(0)
+			b, err = ioutil.ReadFile(name)
+		} else {
+			b, err = ioutil.ReadAll(os.Stdin)
+		}
+		if err != nil {
+			log.Fatalf("Unable to read input : %s", err.Error())
+		}
+		rec := &corpus.Record{ID: name, Sections: []corpus.Section{{Name: corpus.BodySection, Text: string(b)}}}
+		if err := process(os.Stdout, rec, lp, a); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if flag.NArg() == 0 {
+		log.Fatalf("Expected a corpus file or directory")
+	}
+	src, err := corpus.OpenSource(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("Unable to open corpus : %s", err.Error())
+	}
+	defer src.Close()
+	for err = src.MoveNext(); err != io.EOF; err = src.MoveNext() {
+		if err != nil {
+			if _, ok := err.(*corpus.ParseError); !ok {
+				log.Fatal(err)
+			}
+			log.Print(err)
+			continue
+		}
+		rec := src.Item()
+		if *id != "" && rec.ID != *id {
+			continue
+		}
+		if err := process(os.Stdout, rec, lp, a); err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+// process assembles the sentences of the given record, and prints
+// them.
+func process(w io.Writer, rec *corpus.Record, lp *tkz.LanguagePack, a *tkz.AbbrevSet) error {
+	var doc *tkz.Document
+	var err error
+	if *tech {
+		doc, err = tkz.NewTechnicalDocument(rec.ID)
+	} else {
+		doc, err = tkz.NewDocument(rec.ID)
+	}
+	if err != nil {
+		return err
+	}
+	doc.SetLanguage(lp)
+	if *segment {
+		doc.SetSegmenter(tkz.NewLexiconSegmenter(tkz.DefaultLexicon()))
+	}
+	if a != nil {
+		doc.SetAbbreviations(a)
+	}
+	doc.SetSentenceModel(model)
+	if *chem {
+		var base tkz.StartClassifier
+		if lp != nil {
+			base = lp.Starts
+		}
+		doc.SetStartClassifier(tkz.NewChemicalStartClassifier(base))
+	}
+	doc.SetSentenceOptions(tkz.SentenceOptions{Trace: *trace, GroupAware: *groups, QuoteAware: *quotes})
+	for _, sec := range rec.Sections {
+		if strings.TrimSpace(sec.Text) != "" {
+			doc.SetInput(sec.Name, sec.Text)
+		}
+	}
+	doc.Tokenize()
+	doc.AssembleSentences()
+
+	for _, sec := range doc.Sections() {
+		fmt.Fprintf(w, "== %s / %s\n", rec.ID, sec)
+		for _, s := range doc.SectionSentences(sec) {
+			fmt.Fprintf(w, "%d:%d\t%s\n", s.Begin(), s.End(), s.Text())
+			for _, bd := range s.Trace() {
+				fmt.Fprintf(w, "\t\t%s\n", bd.String())
+			}
+			for _, gw := range s.Warnings() {
+				fmt.Fprintf(w, "\t\t@%d[%d] %q %s\n", gw.Begin, gw.Token, gw.Text, tkz.GwDescriptions[gw.Kind])
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+// Package corpus processes large collections of documents
+// concurrently, using a pool of workers.
+package corpus
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+// Options control the processing of a corpus.
+type Options struct {
+	Workers   int                 // Defaults to the number of CPUs
+	Buffer    int                 // Documents queued ahead of workers; defaults to `Workers`
+	Ordered   bool                // Deliver results in input order?
+	Technical bool                // Create technical documents?
+	Pipeline  *tkz.Pipeline       // Defaults to tokenization and sentence assembly
+	Sink      func(*Result) error // Receives results, from a single goroutine
+}
+
+// Result represents the outcome of processing one document.
+type Result struct {
+	Seq    int // Position in the source
+	Record *Record
+	Doc    *tkz.Document
+	Err    error
+}
+
+// Failure records a document that could not be processed.
+type Failure struct {
+	Seq int
+	ID  string
+	Err error
+}
+
+// Stats summarises a run over a corpus.
+type Stats struct {
+	Documents int // Including failed ones
+	Failed    int
+	Bytes     int64
+	Tokens    int
+	Sentences int
+	Elapsed   time.Duration
+	Failures  []Failure
+}
+
+// DocsPerSecond answers the throughput of the run in documents.
+func (s *Stats) DocsPerSecond() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Documents) / s.Elapsed.Seconds()
+}
+
+// BytesPerSecond answers the throughput of the run in bytes of input
+// text.
+func (s *Stats) BytesPerSecond() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / s.Elapsed.Seconds()
+}
+
+// Corpus processes the documents read from a source through a
+// pipeline, on a configurable number of goroutines.
+//
+// Reading is throttled by the workers through bounded queues, so that
+// only a bounded number of documents is in memory at any time, even
+// when results are delivered in input order.
+type Corpus struct {
+	src  Source
+	opts Options
+}
+
+// NewCorpus creates a corpus runner over the given source.  The
+// pipeline in the options, if given, is shared by all workers; its
+// processors must be safe for concurrent use.
+func NewCorpus(src Source, opts Options) (*Corpus, error) {
+	if src == nil {
+		return nil, fmt.Errorf("No source given")
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = opts.Workers
+	}
+	if opts.Pipeline == nil {
+		pl, err := tkz.NewPipelineFromNames("tokenize", "sentences")
+		if err != nil {
+			return nil, err
+		}
+		opts.Pipeline = pl
+	}
+
+	return &Corpus{src, opts}, nil
+}
+
+// Run reads all documents from the source, processes them, and hands
+// the results to the sink, if any.
+//
+// Failures of individual documents -- malformed records, pipeline
+// errors and panics -- are recorded in the answered statistics, and
+// do not abort the run.  Errors reading the source and errors
+// answered by the sink do.
+func (c *Corpus) Run() (*Stats, error) {
+	t0 := time.Now()
+	ordered := c.opts.Ordered
+	jobs := make(chan *Result, c.opts.Buffer)
+	results := make(chan *Result, c.opts.Buffer)
+	window := make(chan struct{}, c.opts.Workers+c.opts.Buffer)
+	done := make(chan struct{})
+	var srcErr error
+
+	go func() {
+		defer close(jobs)
+		for seq := 0; ; seq++ {
+			err := c.src.MoveNext()
+			if err == io.EOF {
+				return
+			}
+			res := &Result{Seq: seq}
+			if err != nil {
+				pe, ok := err.(*ParseError)
+				if !ok {
+					srcErr = err
+					return
+				}
+				res.Err = pe
+			} else {
+				res.Record = c.src.Item()
+			}
+
+			if ordered {
+				select {
+				case window <- struct{}{}:
+				case <-done:
+					return
+				}
+			}
+			select {
+			case jobs <- res:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for res := range jobs {
+				if res.Err == nil {
+					c.process(res)
+				}
+				select {
+				case results <- res:
+				case <-done:
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	stats := &Stats{}
+	var sinkErr error
+	emit := func(res *Result) {
+		c.account(stats, res)
+		if c.opts.Sink != nil {
+			if err := c.opts.Sink(res); err != nil {
+				sinkErr = err
+				close(done)
+				return
+			}
+		}
+		if ordered {
+			<-window
+		}
+	}
+
+	pending := make(map[int]*Result)
+	next := 0
+	for res := range results {
+		if sinkErr != nil {
+			continue
+		}
+		if !ordered {
+			emit(res)
+			continue
+		}
+
+		pending[res.Seq] = res
+		for r, ok := pending[next]; ok && sinkErr == nil; r, ok = pending[next] {
+			delete(pending, next)
+			next++
+			emit(r)
+		}
+	}
+
+	stats.Elapsed = time.Since(t0)
+	if sinkErr != nil {
+		return stats, sinkErr
+	}
+	return stats, srcErr
+}
+
+// process builds a document from the record of the given result, and
+// runs it through the pipeline.  Blank sections are omitted.
+func (c *Corpus) process(res *Result) {
+	defer func() {
+		if r := recover(); r != nil {
+			res.Doc = nil
+			res.Err = fmt.Errorf("Panic processing document : %v", r)
+		}
+	}()
+
+	rec := res.Record
+	var doc *tkz.Document
+	var err error
+	if c.opts.Technical {
+		doc, err = tkz.NewTechnicalDocument(rec.ID)
+	} else {
+		doc, err = tkz.NewDocument(rec.ID)
+	}
+	if err != nil {
+		res.Err = err
+		return
+	}
+	for _, sec := range rec.Sections {
+		if strings.TrimSpace(sec.Text) == "" {
+			continue
+		}
+		if err = doc.SetInput(sec.Name, sec.Text); err != nil {
+			res.Err = err
+			return
+		}
+	}
+
+	if _, err = c.opts.Pipeline.Run(doc); err != nil {
+		res.Err = err
+		return
+	}
+	res.Doc = doc
+}
+
+// account updates the given statistics with the given result.
+func (c *Corpus) account(stats *Stats, res *Result) {
+	stats.Documents++
+	if res.Err != nil {
+		stats.Failed++
+		f := Failure{Seq: res.Seq, Err: res.Err}
+		if res.Record != nil {
+			f.ID = res.Record.ID
+		}
+		stats.Failures = append(stats.Failures, f)
+	}
+	if res.Record == nil {
+		return
+	}
+
+	for _, sec := range res.Record.Sections {
+		stats.Bytes += int64(len(sec.Text))
+		if res.Doc != nil {
+			stats.Tokens += len(res.Doc.SectionTokens(sec.Name))
+			stats.Sentences += len(res.Doc.SectionSentences(sec.Name))
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package corpus
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+func sentenceOffsets(doc *tkz.Document, sec string) string {
+	var soffs []string
+	for _, sent := range doc.SectionSentences(sec) {
+		soffs = append(soffs, fmt.Sprintf("%d:%d", sent.Begin(), sent.End()))
+	}
+	return strings.Join(soffs, ",")
+}
+
+func TestCorpusPatent7k(t *testing.T) {
+	src, err := OpenSource("../tokenizer/testdata/patent_7k_text.txt.gz")
+	if err != nil {
+		t.Fatalf("Unable to open source : %s", err.Error())
+	}
+	defer src.Close()
+	ref, err := ioutil.ReadFile("../tokenizer/testdata/patent_7k_ref.txt")
+	if err != nil {
+		t.Fatalf("Unable to read reference : %s", err.Error())
+	}
+
+	var buf bytes.Buffer
+	opts := Options{Workers: 4, Buffer: 2, Ordered: true}
+	opts.Sink = func(res *Result) error {
+		if res.Err != nil {
+			return res.Err
+		}
+		fmt.Fprintf(&buf, "%s\t%s\t%s\n", res.Record.ID, sentenceOffsets(res.Doc, "T"), sentenceOffsets(res.Doc, "A"))
+		return nil
+	}
+	c, _ := NewCorpus(src, opts)
+	stats, err := c.Run()
+	if err != nil {
+		t.Fatalf("Failed to process corpus : %s", err.Error())
+	}
+	if buf.String() != string(ref) {
+		t.Fatalf("Output differs from the reference")
+	}
+	if stats.Failed != 0 || stats.Documents != strings.Count(string(ref), "\n") || stats.Sentences == 0 {
+		t.Errorf("Unexpected statistics : %d documents, %d failed, %d sentences", stats.Documents, stats.Failed, stats.Sentences)
+	}
+}
+
+//
+
+func TestCorpusFailures001(t *testing.T) {
+	in := "D1\tA title.\tAn abstract.\n" +
+		"malformed line\n" +
+		"\n" +
+		"D2\tPANIC\n" +
+		"D3\tAnother title.\n"
+	bad := tkz.NewProcessorFunc("bad", []string{tkz.LayerTokens}, nil, func(d *tkz.Document) error {
+		if in, _ := d.Input("T"); strings.TrimSpace(in) == "PANIC" {
+			panic("bad input")
+		}
+		return nil
+	})
+	tok, _ := tkz.NewProcessor("tokenize")
+	pl, _ := tkz.NewPipeline(tok, bad)
+
+	for _, ordered := range []bool{true, false} {
+		var ids []string
+		opts := Options{Workers: 3, Ordered: ordered, Pipeline: pl}
+		opts.Sink = func(res *Result) error {
+			if res.Err == nil {
+				ids = append(ids, res.Record.ID)
+			}
+			return nil
+		}
+		c, _ := NewCorpus(NewTSVSource(strings.NewReader(in)), opts)
+		stats, err := c.Run()
+		if err != nil {
+			t.Fatalf("Failed to process corpus : %s", err.Error())
+		}
+		if stats.Documents != 4 || stats.Failed != 2 || len(ids) != 2 {
+			t.Fatalf("Expected 4 documents, 2 failures, observed : %d, %d", stats.Documents, stats.Failed)
+		}
+		if ordered && (ids[0] != "D1" || ids[1] != "D3" || stats.Failures[0].Seq != 1 || stats.Failures[1].ID != "D2") {
+			t.Errorf("Unexpected order of results : %v, %v", ids, stats.Failures)
+		}
+	}
+}
+
+//
+
+func TestCorpusSinkError001(t *testing.T) {
+	var in strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&in, "D%d\tTitle number %d.\n", i, i)
+	}
+	n := 0
+	opts := Options{Workers: 2, Buffer: 1, Ordered: true}
+	opts.Sink = func(res *Result) error {
+		n++
+		if n == 10 {
+			return fmt.Errorf("Sink full")
+		}
+		return nil
+	}
+	c, _ := NewCorpus(NewTSVSource(strings.NewReader(in.String())), opts)
+	if _, err := c.Run(); err == nil || n != 10 {
+		t.Errorf("Expected the run to stop at the sink error, observed : %d", n)
+	}
+}
+
+//
+
+func TestSources001(t *testing.T) {
+	js := `{"id": "J1", "sections": [{"name": "T", "text": "A title."}]}` + "\n" +
+		`{"sections": []}` + "\n" +
+		`{"id": "J2", "sections": [{"name": "A", "text": "An abstract. Two sentences."}]}`
+	src := NewJSONLSource(strings.NewReader(js))
+	var ids []string
+	var errs int
+	for err := src.MoveNext(); err != io.EOF; err = src.MoveNext() {
+		if err != nil {
+			if _, ok := err.(*ParseError); !ok {
+				t.Fatalf("Unexpected error : %s", err.Error())
+			}
+			errs++
+			continue
+		}
+		ids = append(ids, src.Item().ID)
+	}
+	if strings.Join(ids, ",") != "J1,J2" || errs != 1 {
+		t.Errorf("Expected J1,J2 and one error, observed : %v, %d", ids, errs)
+	}
+
+	dir, err := ioutil.TempDir("", "corpus")
+	if err != nil {
+		t.Fatalf("Unable to create directory : %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "b.txt"), []byte("Plain text."), 0644)
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte("Gzipped text. Second sentence."))
+	gw.Close()
+	ioutil.WriteFile(filepath.Join(dir, "a.txt.gz"), gz.Bytes(), 0644)
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+
+	ds, err := OpenSource(dir)
+	if err != nil {
+		t.Fatalf("Unable to open directory : %s", err.Error())
+	}
+	var sents []int
+	c, _ := NewCorpus(ds, Options{Ordered: true, Sink: func(res *Result) error {
+		if res.Record.ID != "a" && res.Record.ID != "b" {
+			t.Errorf("Unexpected identifier : %s", res.Record.ID)
+		}
+		sents = append(sents, len(res.Doc.SectionSentences(BodySection)))
+		return nil
+	}})
+	if _, err := c.Run(); err != nil || len(sents) != 2 || sents[0] != 2 || sents[1] != 1 {
+		t.Errorf("Expected sentence counts [2 1], observed : %v", sents)
+	}
+}
@@ -0,0 +1,335 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package corpus
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BodySection is the name of the only section of documents read from
+// individual files.
+const BodySection = "body"
+
+// Section represents one named section of the text of a document.
+type Section struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+// Record represents one document read from a source, in the form of
+// its identifier and the texts of its sections.
+type Record struct {
+	ID       string    `json:"id"`
+	Sections []Section `json:"sections"`
+}
+
+// ParseError represents a malformed record in a source.  Sources can
+// continue past such errors.
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("Malformed record at line %d : %s", e.Line, e.Err.Error())
+}
+
+// Source represents a sequential provider of documents.
+//
+// `MoveNext` answers `nil` when a record is available through `Item`,
+// a `*ParseError` when the current record is malformed but more may
+// follow, and `io.EOF` when the source is exhausted.  Any other error
+// is fatal.
+type Source interface {
+	MoveNext() error
+	Item() *Record
+	Close() error
+}
+
+// lineReader reads lines of arbitrary length, with their line
+// terminators.
+type lineReader struct {
+	rd     *bufio.Reader
+	line   int
+	closer io.Closer
+}
+
+func (lr *lineReader) next() (string, error) {
+	s, err := lr.rd.ReadString('\n')
+	if err != nil && (err != io.EOF || s == "") {
+		return "", err
+	}
+	lr.line++
+	return s, nil
+}
+
+func (lr *lineReader) Close() error {
+	if lr.closer != nil {
+		return lr.closer.Close()
+	}
+	return nil
+}
+
+// TSVSource reads documents from tab-separated lines, each of the
+// form `id<TAB>text<TAB>text...`.  Blank lines are skipped.
+//
+// Fields are taken verbatim: the last one retains the line
+// terminator, so that sentence offsets agree with those computed by
+// line-oriented tools over the same files.
+type TSVSource struct {
+	lineReader
+	names []string
+	cur   *Record
+}
+
+// NewTSVSource creates a source that reads tab-separated documents
+// from the given reader.
+//
+// The fields following the identifier are named by the given section
+// names, in order.  In their absence, they are named `T` (title) and
+// `A` (abstract).  Any further fields are named by their positions.
+func NewTSVSource(r io.Reader, names ...string) *TSVSource {
+	if len(names) == 0 {
+		names = []string{"T", "A"}
+	}
+	return &TSVSource{lineReader: lineReader{rd: bufio.NewReader(r)}, names: names}
+}
+
+// MoveNext reads the next document, skipping blank lines.
+func (s *TSVSource) MoveNext() error {
+	s.cur = nil
+	for {
+		line, err := s.next()
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fs := strings.Split(line, "\t")
+		if len(fs) < 2 || strings.TrimSpace(fs[0]) == "" {
+			return &ParseError{s.line, fmt.Errorf("Expected an identifier and at least one text field")}
+		}
+		rec := &Record{ID: fs[0]}
+		for i, f := range fs[1:] {
+			name := strconv.Itoa(i + 1)
+			if i < len(s.names) {
+				name = s.names[i]
+			}
+			rec.Sections = append(rec.Sections, Section{name, f})
+		}
+		s.cur = rec
+		return nil
+	}
+}
+
+// Item answers the current document.
+func (s *TSVSource) Item() *Record {
+	return s.cur
+}
+
+// JSONLSource reads documents from lines of JSON, each of the form
+// `{"id": "...", "sections": [{"name": "...", "text": "..."}, ...]}`.
+// Blank lines are skipped.
+type JSONLSource struct {
+	lineReader
+	cur *Record
+}
+
+// NewJSONLSource creates a source that reads JSON lines from the
+// given reader.
+func NewJSONLSource(r io.Reader) *JSONLSource {
+	return &JSONLSource{lineReader: lineReader{rd: bufio.NewReader(r)}}
+}
+
+// MoveNext reads the next document, skipping blank lines.
+func (s *JSONLSource) MoveNext() error {
+	s.cur = nil
+	for {
+		line, err := s.next()
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		rec := &Record{}
+		if err := json.Unmarshal([]byte(line), rec); err != nil {
+			return &ParseError{s.line, err}
+		}
+		if rec.ID == "" {
+			return &ParseError{s.line, fmt.Errorf("Missing identifier")}
+		}
+		s.cur = rec
+		return nil
+	}
+}
+
+// Item answers the current document.
+func (s *JSONLSource) Item() *Record {
+	return s.cur
+}
+
+// DirSource reads documents from the regular files in a directory, in
+// the order of their names.  Each file is one document with a single
+// section named `BodySection`; its identifier is the name of the file
+// without extensions.  Gzipped files are decompressed.
+type DirSource struct {
+	dir   string
+	names []string
+	idx   int
+	cur   *Record
+}
+
+// NewDirSource creates a source that reads documents from the files
+// in the given directory.  Hidden files and sub-directories are
+// ignored.
+func NewDirSource(dir string) (*DirSource, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &DirSource{dir: dir}
+	for _, fi := range fis {
+		if fi.Mode().IsRegular() && !strings.HasPrefix(fi.Name(), ".") {
+			s.names = append(s.names, fi.Name())
+		}
+	}
+	return s, nil
+}
+
+// MoveNext reads the next file.  A file that cannot be read is
+// reported as a malformed record, whose line is its position in the
+// directory.
+func (s *DirSource) MoveNext() error {
+	s.cur = nil
+	if s.idx >= len(s.names) {
+		return io.EOF
+	}
+	name := s.names[s.idx]
+	s.idx++
+
+	text, err := readFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return &ParseError{s.idx, err}
+	}
+	s.cur = &Record{ID: baseName(name), Sections: []Section{{BodySection, text}}}
+	return nil
+}
+
+// Item answers the current document.
+func (s *DirSource) Item() *Record {
+	return s.cur
+}
+
+// Close is a no-op; files are closed as soon as they are read.
+func (s *DirSource) Close() error {
+	return nil
+}
+
+// OpenSource opens a source of the appropriate kind for the given
+// path.
+//
+// Directories are read using `DirSource`.  Files with extensions
+// `.jsonl` or `.json` are read as JSON lines; all other files are
+// read as tab-separated lines.  A trailing `.gz` extension causes the
+// file to be decompressed.
+func OpenSource(path string) (Source, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return NewDirSource(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	var r io.Reader = f
+	var c io.Closer = f
+	name := path
+	if strings.HasSuffix(name, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		r = gr
+		c = multiCloser{gr, f}
+		name = strings.TrimSuffix(name, ".gz")
+	}
+
+	switch filepath.Ext(name) {
+	case ".jsonl", ".json":
+		s := NewJSONLSource(r)
+		s.closer = c
+		return s, nil
+	default:
+		s := NewTSVSource(r)
+		s.closer = c
+		return s, nil
+	}
+}
+
+// multiCloser closes all its constituents, in order.
+type multiCloser []io.Closer
+
+func (mc multiCloser) Close() error {
+	var res error
+	for _, c := range mc {
+		if err := c.Close(); err != nil && res == nil {
+			res = err
+		}
+	}
+	return res
+}
+
+// readFile answers the contents of the given file, decompressing
+// them if it is gzipped.
+func readFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return "", err
+		}
+		defer gr.Close()
+		r = gr
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// baseName answers the given file name without its extensions.
+func baseName(name string) string {
+	if i := strings.Index(name, "."); i > 0 {
+		return name[:i]
+	}
+	return name
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+// Package embedding trains word embeddings on tokenized documents,
+// using skip-gram with negative sampling, optionally enriched with the
+// character n-grams of words.  Embeddings are saved and loaded in the
+// text and binary formats of word2vec, and queried for the nearest
+// neighbours of words.
+package embedding
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/RxnWeaver/RxnMiner/features"
+)
+
+// Neighbour is a word near another one, with the cosine similarity of
+// their vectors.
+type Neighbour struct {
+	Word       string
+	Similarity float64
+}
+
+// Model holds the vectors of the words of a vocabulary.
+//
+// Models trained with subwords also hold the vectors of the n-grams of
+// the characters of words, and answer vectors for words outside their
+// vocabularies: names of chemicals not seen in training, say.  Those
+// vectors are not part of the word2vec formats, and are lost when
+// models are saved.
+//
+// Models are safe for concurrent use.
+type Model struct {
+	dim   int
+	words []string
+	index map[string]int
+	vecs  []float32 // By word, `dim` apiece
+	units []float32 // Of unit length, for similarities
+
+	minn, maxn int
+	subs       []float32 // By hash bucket, `dim` apiece
+}
+
+// newModel creates and initialises a model of the given words, with the
+// given vectors of the given dimension.  Words occurring more than once
+// retain their first vectors.
+func newModel(dim int, words []string, vecs []float32) *Model {
+	m := &Model{dim: dim, words: words, vecs: vecs}
+	m.index = make(map[string]int, len(words))
+	for i := len(words) - 1; i >= 0; i-- {
+		m.index[words[i]] = i
+	}
+
+	m.units = make([]float32, len(vecs))
+	for i := range words {
+		v := vecs[i*dim : (i+1)*dim]
+		n := norm(v)
+		if n == 0 {
+			continue
+		}
+		u := m.units[i*dim : (i+1)*dim]
+		for j, x := range v {
+			u[j] = float32(float64(x) / n)
+		}
+	}
+	return m
+}
+
+// Dim answers the dimension of the vectors of the model.
+func (m *Model) Dim() int {
+	return m.dim
+}
+
+// Len answers the number of words in the vocabulary of the model.
+func (m *Model) Len() int {
+	return len(m.words)
+}
+
+// Words answers the words of the vocabulary of the model.  Trained
+// models hold them in decreasing order of their frequencies.
+func (m *Model) Words() []string {
+	res := make([]string, len(m.words))
+	copy(res, m.words)
+	return res
+}
+
+// Contains answers if the given word is part of the vocabulary of the
+// model.
+func (m *Model) Contains(w string) bool {
+	_, ok := m.index[w]
+	return ok
+}
+
+// Vector answers a copy of the vector of the given word, and if it
+// could be determined.  Words outside the vocabulary have the average
+// of the vectors of their n-grams, should the model have subwords.
+func (m *Model) Vector(w string) ([]float32, bool) {
+	res := make([]float32, m.dim)
+	if i, ok := m.index[w]; ok {
+		copy(res, m.vecs[i*m.dim:(i+1)*m.dim])
+		return res, true
+	}
+	if m.subs == nil {
+		return nil, false
+	}
+
+	grams := subwords(w, m.minn, m.maxn, len(m.subs)/m.dim)
+	if len(grams) == 0 {
+		return nil, false
+	}
+	for _, g := range grams {
+		add(res, m.subs[g*m.dim:(g+1)*m.dim], 1)
+	}
+	scale(res, 1/float32(len(grams)))
+	return res, true
+}
+
+// Similarity answers the cosine similarity of the vectors of the given
+// words.
+func (m *Model) Similarity(a, b string) (float64, error) {
+	va, ok := m.Vector(a)
+	if !ok {
+		return 0, fmt.Errorf("Unknown word : %s", a)
+	}
+	vb, ok := m.Vector(b)
+	if !ok {
+		return 0, fmt.Errorf("Unknown word : %s", b)
+	}
+	na, nb := norm(va), norm(vb)
+	if na == 0 || nb == 0 {
+		return 0, nil
+	}
+	return dot(va, vb) / (na * nb), nil
+}
+
+// Nearest answers up to the given number of words of the vocabulary
+// nearest to the given word, in decreasing order of similarity.  The
+// word itself is excluded.
+func (m *Model) Nearest(w string, k int) ([]Neighbour, error) {
+	v, ok := m.Vector(w)
+	if !ok {
+		return nil, fmt.Errorf("Unknown word : %s", w)
+	}
+	return m.NearestVector(v, k, w), nil
+}
+
+// NearestVector answers up to the given number of words of the
+// vocabulary nearest to the given vector, in decreasing order of
+// similarity, excluding the given words.
+func (m *Model) NearestVector(v []float32, k int, exclude ...string) []Neighbour {
+	if len(v) != m.dim || k <= 0 {
+		return nil
+	}
+	n := norm(v)
+	if n == 0 {
+		return nil
+	}
+	skip := make(map[int]struct{}, len(exclude))
+	for _, w := range exclude {
+		if i, ok := m.index[w]; ok {
+			skip[i] = struct{}{}
+		}
+	}
+
+	res := make([]Neighbour, 0, len(m.words))
+	for i, w := range m.words {
+		if _, ok := skip[i]; ok {
+			continue
+		}
+		s := dot(v, m.units[i*m.dim:(i+1)*m.dim]) / n
+		res = append(res, Neighbour{w, s})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Similarity != res[j].Similarity {
+			return res[i].Similarity > res[j].Similarity
+		}
+		return res[i].Word < res[j].Word
+	})
+	if len(res) > k {
+		res = res[:k]
+	}
+	return res
+}
+
+// subwords answers the hash buckets of the character n-grams of the
+// given word, of the given lengths, for the given number of buckets.
+// The word is delimited by `<` and `>`, so that prefixes and suffixes
+// are told from the rest; the delimited word itself is excluded.
+func subwords(w string, minn, maxn, buckets int) []int {
+	if minn <= 0 || buckets <= 0 {
+		return nil
+	}
+	rs := []rune("<" + w + ">")
+	var res []int
+	for n := minn; n <= maxn && n <= len(rs); n++ {
+		for i := 0; i+n <= len(rs); i++ {
+			if n == len(rs) {
+				continue
+			}
+			res = append(res, features.Hash(string(rs[i:i+n]), buckets))
+		}
+	}
+	return res
+}
+
+// dot answers the dot product of the given vectors.
+func dot(a, b []float32) float64 {
+	var res float64
+	for i, x := range a {
+		res += float64(x) * float64(b[i])
+	}
+	return res
+}
+
+// norm answers the Euclidean length of the given vector.
+func norm(a []float32) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+// add adds the given multiple of the second vector to the first.
+func add(a, b []float32, f float32) {
+	for i, x := range b {
+		a[i] += f * x
+	}
+}
+
+// scale multiplies the given vector by the given factor.
+func scale(a []float32, f float32) {
+	for i := range a {
+		a[i] *= f
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package embedding
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+var (
+	solvents = []string{"ethanol", "methanol", "toluene", "hexane", "acetone", "dioxane"}
+	arenes   = []string{"chlorobenzene", "bromobenzene", "nitrobenzene", "methylbenzene", "ethylbenzene"}
+	salts    = []string{"nacl", "kcl", "licl", "nabr", "kbr"}
+
+	templates = []string{
+		"the residue was dissolved in S and filtered",
+		"the crude product was recrystallised from hot S",
+		"S was removed under reduced pressure",
+		"the A was nitrated with fuming nitric acid",
+		"a solution of A in sulfuric acid was heated",
+		"the organic layer was washed with aqueous X solution",
+		"saturated X was added to the aqueous phase",
+	}
+)
+
+// trainer answers a trainer of the given options with sentences made
+// of the test templates, filled with random solvents, arenes and salts.
+func trainer(t *testing.T, o Options, n int) *Trainer {
+	tr, err := NewTrainer(o)
+	if err != nil {
+		t.Fatalf("Unable to create trainer : %s", err.Error())
+	}
+	rng := rand.New(rand.NewSource(42))
+	for i := 0; i < n; i++ {
+		var sent []string
+		for _, w := range strings.Fields(templates[rng.Intn(len(templates))]) {
+			switch w {
+			case "S":
+				w = solvents[rng.Intn(len(solvents))]
+			case "A":
+				w = arenes[rng.Intn(len(arenes))]
+			case "X":
+				w = salts[rng.Intn(len(salts))]
+			}
+			sent = append(sent, w)
+		}
+		tr.Add(sent)
+	}
+	return tr
+}
+
+// testOptions answers options for training small models quickly.
+func testOptions() Options {
+	o := DefaultOptions()
+	o.Dim = 24
+	o.Window = 3
+	o.MinCount = 1
+	o.Sample = 0
+	o.Buckets = 1 << 12
+	return o
+}
+
+// contains answers if the given words include the given one.
+func contains(ws []string, w string) bool {
+	for _, x := range ws {
+		if x == w {
+			return true
+		}
+	}
+	return false
+}
+
+//
+
+func TestTrainer001(t *testing.T) {
+	if _, err := NewTrainer(Options{}); err == nil {
+		t.Errorf("Expected an error for invalid options")
+	}
+
+	d, _ := tkz.NewDocument("Embedding")
+	d.SetInput("T", "Preparation of 4-methoxyphenol.")
+	d.SetInput("A", "The residue was dissolved in ethyl acetate. It was washed with brine (20 mL).")
+	d.Tokenize()
+	d.AssembleSentences()
+	d.AssembleWords()
+
+	tr, _ := NewTrainer(testOptions())
+	if err := tr.AddDocument(d); err != nil {
+		t.Fatalf("Unable to add document : %s", err.Error())
+	}
+	cases := []struct {
+		word  string
+		count int64
+	}{
+		{"4-methoxyphenol", 1},
+		{"preparation", 1},
+		{"was", 2},
+		{"20", 1},
+		{".", 0},
+		{"(", 0},
+		{"The", 0},
+	}
+	for _, c := range cases {
+		if obs := tr.Count(c.word); obs != c.count {
+			t.Errorf("Expected count of %s : %d, observed : %d", c.word, c.count, obs)
+		}
+	}
+	if len(tr.sents) != 3 {
+		t.Errorf("Expected sentence count : 3, observed : %d", len(tr.sents))
+	}
+
+	o := testOptions()
+	o.MinCount = 3
+	tr, _ = NewTrainer(o)
+	tr.AddDocument(d)
+	if _, err := tr.Train(); err == nil {
+		t.Errorf("Expected an error for an empty vocabulary")
+	}
+}
+
+//
+
+func TestTrain001(t *testing.T) {
+	m, err := trainer(t, testOptions(), 3000).Train()
+	if err != nil {
+		t.Fatalf("Unable to train : %s", err.Error())
+	}
+	if m.Dim() != 24 {
+		t.Errorf("Expected dimension : 24, observed : %d", m.Dim())
+	}
+	if m.Words()[0] != "was" {
+		t.Errorf("Expected most frequent word : was, observed : %s", m.Words()[0])
+	}
+
+	groups := [][]string{solvents, arenes, salts}
+	for _, g := range groups {
+		for _, w := range g {
+			ns, err := m.Nearest(w, 3)
+			if err != nil {
+				t.Fatalf("Unable to find neighbours : %s", err.Error())
+			}
+			for _, n := range ns {
+				if !contains(g, n.Word) {
+					t.Errorf("Expected neighbours of %s among %v, observed : %v", w, g, ns)
+					break
+				}
+			}
+		}
+	}
+
+	// Words outside the vocabulary are known by their subwords.
+	ns, err := m.Nearest("fluorobenzene", 3)
+	if err != nil {
+		t.Fatalf("Unable to find neighbours : %s", err.Error())
+	}
+	for _, n := range ns {
+		if !strings.HasSuffix(n.Word, "benzene") {
+			t.Errorf("Expected neighbours of fluorobenzene among arenes, observed : %v", ns)
+			break
+		}
+	}
+
+	s1, _ := m.Similarity("ethanol", "methanol")
+	s2, _ := m.Similarity("ethanol", "nitrobenzene")
+	if s1 <= s2 {
+		t.Errorf("Expected ethanol nearer methanol than nitrobenzene, observed : %.4f, %.4f", s1, s2)
+	}
+
+	// Training is reproducible.
+	m2, _ := trainer(t, testOptions(), 3000).Train()
+	v1, _ := m.Vector("toluene")
+	v2, _ := m2.Vector("toluene")
+	for i := range v1 {
+		if v1[i] != v2[i] {
+			t.Fatalf("Expected identical vectors, observed : %v, %v", v1, v2)
+		}
+	}
+
+	o := testOptions()
+	o.MinN = 0
+	m, _ = trainer(t, o, 500).Train()
+	if _, err := m.Nearest("fluorobenzene", 3); err == nil {
+		t.Errorf("Expected an error for an unknown word without subwords")
+	}
+}
+
+//
+
+func TestFormat001(t *testing.T) {
+	o := testOptions()
+	o.Dim = 8
+	o.Epochs = 1
+	m, _ := trainer(t, o, 200).Train()
+
+	var tb, bb bytes.Buffer
+	if err := m.WriteText(&tb); err != nil {
+		t.Fatalf("Unable to write vectors : %s", err.Error())
+	}
+	if err := m.WriteBinary(&bb); err != nil {
+		t.Fatalf("Unable to write vectors : %s", err.Error())
+	}
+	header := strings.SplitN(tb.String(), "\n", 2)[0]
+	if exp := "50 8"; header != exp {
+		t.Errorf("Expected header : %s, observed : %s", exp, header)
+	}
+
+	mt, err := ReadText(&tb)
+	if err != nil {
+		t.Fatalf("Unable to read vectors : %s", err.Error())
+	}
+	mb, err := ReadBinary(&bb)
+	if err != nil {
+		t.Fatalf("Unable to read vectors : %s", err.Error())
+	}
+	for _, r := range []*Model{mt, mb} {
+		if r.Len() != m.Len() || r.Dim() != m.Dim() {
+			t.Fatalf("Expected %d vectors of dimension %d, observed : %d of %d", m.Len(), m.Dim(), r.Len(), r.Dim())
+		}
+		for _, w := range m.Words() {
+			exp, _ := m.Vector(w)
+			obs, ok := r.Vector(w)
+			if !ok {
+				t.Fatalf("Expected a vector for : %s", w)
+			}
+			for i := range exp {
+				if exp[i] != obs[i] {
+					t.Fatalf("Expected vector of %s : %v, observed : %v", w, exp, obs)
+				}
+			}
+		}
+		if _, ok := r.Vector("fluorobenzene"); ok {
+			t.Errorf("Expected no vector for an unknown word after loading")
+		}
+	}
+
+	glove := "ethanol 1 0 0\nmethanol 0.9 0.1 0\nbrine 0 0 1\n"
+	g, err := ReadText(strings.NewReader(glove))
+	if err != nil {
+		t.Fatalf("Unable to read vectors : %s", err.Error())
+	}
+	ns, _ := g.Nearest("ethanol", 5)
+	if len(ns) != 2 || ns[0].Word != "methanol" || ns[1].Word != "brine" || ns[1].Similarity != 0 {
+		t.Errorf("Expected neighbours methanol and brine, observed : %v", ns)
+	}
+
+	bad := []string{
+		"2 3\nethanol 1 0 0\n",
+		"ethanol 1 0 0\nmethanol 1 0\n",
+		"ethanol 1 x 0\n",
+		"",
+	}
+	for _, in := range bad {
+		if _, err := ReadText(strings.NewReader(in)); err == nil {
+			t.Errorf("Expected an error for vectors : %q", in)
+		}
+	}
+	bad = []string{
+		"2 3\nethanol ",
+		"2000000000 1000\nethanol ",
+		"9223372036854775807 9223372036854775807\n",
+		"3 -1\n",
+	}
+	for _, in := range bad {
+		if _, err := ReadBinary(strings.NewReader(in)); err == nil {
+			t.Errorf("Expected an error for vectors : %q", in)
+		}
+	}
+
+	w := newModel(1, []string{"ethyl acetate"}, []float32{1})
+	if err := w.WriteText(&tb); err == nil {
+		t.Errorf("Expected an error for a word with space")
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package embedding
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxDim is the largest dimension of vectors read.
+const maxDim = 1 << 16
+
+// checkWord answers an error, should the given word not be writable in
+// the word2vec formats.
+func checkWord(w string) error {
+	if w == "" || strings.ContainsAny(w, " \t\r\n") {
+		return fmt.Errorf("Invalid word : %q", w)
+	}
+	return nil
+}
+
+// WriteText writes the vectors of the model to the given writer in the
+// text format of word2vec: a header with the number of words and the
+// dimension, followed by a line per word, with the word and the
+// components of its vector separated by space.
+func (m *Model) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d %d\n", len(m.words), m.dim)
+	for i, word := range m.words {
+		if err := checkWord(word); err != nil {
+			return err
+		}
+		bw.WriteString(word)
+		for _, x := range m.vecs[i*m.dim : (i+1)*m.dim] {
+			bw.WriteByte(' ')
+			bw.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// WriteBinary writes the vectors of the model to the given writer in
+// the binary format of word2vec: a header with the number of words and
+// the dimension, followed by each word, a space, and the components of
+// its vector as little-endian 32-bit floats.
+func (m *Model) WriteBinary(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d %d\n", len(m.words), m.dim)
+	buf := make([]byte, 4*m.dim)
+	for i, word := range m.words {
+		if err := checkWord(word); err != nil {
+			return err
+		}
+		for j, x := range m.vecs[i*m.dim : (i+1)*m.dim] {
+			binary.LittleEndian.PutUint32(buf[4*j:], math.Float32bits(x))
+		}
+		bw.WriteString(word)
+		bw.WriteByte(' ')
+		bw.Write(buf)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ReadText reads a model from the given reader, in the text format of
+// word2vec.  The header is optional, as in the format of GloVe; without
+// it, the dimension is that of the first vector.
+func ReadText(r io.Reader) (*Model, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	dim, count := 0, -1
+	var words []string
+	var vecs []float32
+	for n := 1; sc.Scan(); n++ {
+		fs := strings.Fields(sc.Text())
+		if len(fs) == 0 {
+			continue
+		}
+		if n == 1 && len(fs) == 2 {
+			c, err1 := strconv.Atoi(fs[0])
+			d, err2 := strconv.Atoi(fs[1])
+			if err1 == nil && err2 == nil {
+				if c < 0 || d <= 0 {
+					return nil, fmt.Errorf("Invalid header : %s", sc.Text())
+				}
+				count, dim = c, d
+				continue
+			}
+		}
+
+		if dim == 0 {
+			dim = len(fs) - 1
+			if dim == 0 {
+				return nil, fmt.Errorf("Expected a vector at line %d : %s", n, sc.Text())
+			}
+		}
+		if len(fs) != dim+1 {
+			return nil, fmt.Errorf("Expected %d components at line %d, observed : %d", dim, n, len(fs)-1)
+		}
+		for _, f := range fs[1:] {
+			x, err := strconv.ParseFloat(f, 32)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid component at line %d : %s", n, f)
+			}
+			vecs = append(vecs, float32(x))
+		}
+		words = append(words, fs[0])
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	if count >= 0 && count != len(words) {
+		return nil, fmt.Errorf("Expected word count : %d, observed : %d", count, len(words))
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("No vectors given")
+	}
+	return newModel(dim, words, vecs), nil
+}
+
+// ReadBinary reads a model from the given reader, in the binary format
+// of word2vec.
+func ReadBinary(r io.Reader) (*Model, error) {
+	br := bufio.NewReader(r)
+	header, err := br.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read header : %s", err.Error())
+	}
+	var count, dim int
+	if _, err := fmt.Sscanf(header, "%d %d", &count, &dim); err != nil || count <= 0 || dim <= 0 {
+		return nil, fmt.Errorf("Invalid header : %s", strings.TrimSpace(header))
+	}
+
+	if dim > maxDim {
+		return nil, fmt.Errorf("Dimension too large : %d", dim)
+	}
+
+	// The header is not trusted for the sizes of allocations; vectors
+	// are appended as they are read.
+	var words []string
+	var vecs []float32
+	buf := make([]byte, 4*dim)
+	for i := 0; i < count; i++ {
+		word, err := br.ReadString(' ')
+		if err != nil {
+			return nil, fmt.Errorf("Unexpected end of vectors after %d words", i)
+		}
+		word = strings.TrimLeft(word[:len(word)-1], "\r\n")
+		if word == "" {
+			return nil, fmt.Errorf("Empty word after %d words", i)
+		}
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, fmt.Errorf("Unexpected end of vectors after %d words", i)
+		}
+		for j := 0; j < dim; j++ {
+			vecs = append(vecs, math.Float32frombits(binary.LittleEndian.Uint32(buf[4*j:])))
+		}
+		words = append(words, word)
+	}
+	return newModel(dim, words, vecs), nil
+}
@@ -0,0 +1,395 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package embedding
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+// Options control the training of embeddings.
+type Options struct {
+	Dim      int     // Dimension of the vectors
+	Window   int     // Largest number of context words on either side
+	Negative int     // Number of negative samples per context word
+	MinCount int     // Words seen less often are dropped
+	Epochs   int     // Number of passes over the sentences
+	Alpha    float64 // Initial learning rate, decaying linearly
+	Sample   float64 // Threshold of the subsampling of frequent words; 0 disables it
+	MinN     int     // Length of the shortest subwords, in runes; 0 disables subwords
+	MaxN     int     // Length of the longest subwords, in runes
+	Buckets  int     // Number of hashed subword vectors
+	Seed     int64   // Of the random numbers, for reproducible training
+}
+
+// DefaultOptions answers the usual options of word2vec, with subwords
+// of three to six runes, which suit names of chemicals.
+func DefaultOptions() Options {
+	return Options{
+		Dim:      100,
+		Window:   5,
+		Negative: 5,
+		MinCount: 5,
+		Epochs:   5,
+		Alpha:    0.025,
+		Sample:   1e-3,
+		MinN:     3,
+		MaxN:     6,
+		Buckets:  1 << 18,
+		Seed:     1,
+	}
+}
+
+// validate answers an error describing the first invalid option, if
+// any.
+func (o Options) validate() error {
+	switch {
+	case o.Dim <= 0:
+		return fmt.Errorf("Invalid dimension : %d", o.Dim)
+	case o.Window <= 0:
+		return fmt.Errorf("Invalid window : %d", o.Window)
+	case o.Negative <= 0:
+		return fmt.Errorf("Invalid number of negative samples : %d", o.Negative)
+	case o.Epochs <= 0:
+		return fmt.Errorf("Invalid number of epochs : %d", o.Epochs)
+	case o.Alpha <= 0:
+		return fmt.Errorf("Invalid learning rate : %v", o.Alpha)
+	case o.Sample < 0:
+		return fmt.Errorf("Invalid sampling threshold : %v", o.Sample)
+	case o.MinN < 0 || (o.MinN > 0 && o.MaxN < o.MinN):
+		return fmt.Errorf("Invalid subword lengths : %d-%d", o.MinN, o.MaxN)
+	case o.MinN > 0 && o.Buckets <= 0:
+		return fmt.Errorf("Invalid number of buckets : %d", o.Buckets)
+	}
+	return nil
+}
+
+// Trainer accumulates the sentences of a corpus, and trains embeddings
+// of their words.
+//
+// Sentences are held in memory as sequences of word indices.  Training
+// is sequential, so that given the same sentences and options, it
+// answers the same vectors.
+type Trainer struct {
+	opts   Options
+	index  map[string]int32
+	words  []string
+	counts []int64
+	sents  [][]int32
+}
+
+// NewTrainer creates and initialises a trainer with the given options,
+// and no sentences.
+func NewTrainer(o Options) (*Trainer, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	return &Trainer{opts: o, index: make(map[string]int32)}, nil
+}
+
+// Add records the given sentence, as a sequence of words.  Words are
+// recorded as given.
+func (t *Trainer) Add(sent []string) {
+	if len(sent) == 0 {
+		return
+	}
+	ids := make([]int32, len(sent))
+	for i, w := range sent {
+		id, ok := t.index[w]
+		if !ok {
+			id = int32(len(t.words))
+			t.index[w] = id
+			t.words = append(t.words, w)
+			t.counts = append(t.counts, 0)
+		}
+		t.counts[id]++
+		ids[i] = id
+	}
+	t.sents = append(t.sents, ids)
+}
+
+// AddDocument records the sentences of all the sections of the given
+// tokenized document.
+//
+// Sentences are sequences of the words of the document; see
+// `Document.SentenceWords`.  Assembling the words of documents
+// beforehand -- using the `words` processor, say -- keeps names of
+// chemicals whole.
+// Words are recorded in lowercase, with any white space replaced by
+// `_`; those having neither letters nor digits are skipped.
+//
+// It can be called from the sink of a corpus runner.
+func (t *Trainer) AddDocument(d *tkz.Document) error {
+	for _, sec := range d.Sections() {
+		groups, err := d.SentenceWords(sec)
+		if err != nil {
+			return err
+		}
+		for _, ws := range groups {
+			sent := make([]string, 0, len(ws))
+			for _, w := range ws {
+				if s, ok := normalise(w.Text()); ok {
+					sent = append(sent, s)
+				}
+			}
+			t.Add(sent)
+		}
+	}
+	return nil
+}
+
+// normalise answers the given word in lowercase, with its runs of white
+// space replaced by `_`, and if it has letters or digits.
+func normalise(w string) (string, bool) {
+	ok := false
+	for _, r := range w {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return "", false
+	}
+	return strings.Join(strings.Fields(strings.ToLower(w)), "_"), true
+}
+
+// Len answers the number of distinct words recorded so far.
+func (t *Trainer) Len() int {
+	return len(t.words)
+}
+
+// Count answers the number of occurrences of the given word recorded
+// so far.
+func (t *Trainer) Count(w string) int64 {
+	if id, ok := t.index[w]; ok {
+		return t.counts[id]
+	}
+	return 0
+}
+
+// Train answers a model trained on the sentences recorded so far, with
+// the words seen at least `MinCount` times.
+func (t *Trainer) Train() (*Model, error) {
+	o := t.opts
+	vocab := make([]int32, 0, len(t.words))
+	for i, c := range t.counts {
+		if c >= int64(o.MinCount) {
+			vocab = append(vocab, int32(i))
+		}
+	}
+	if len(vocab) == 0 {
+		return nil, fmt.Errorf("No words seen at least %d times", o.MinCount)
+	}
+	sort.Slice(vocab, func(i, j int) bool {
+		a, b := vocab[i], vocab[j]
+		if t.counts[a] != t.counts[b] {
+			return t.counts[a] > t.counts[b]
+		}
+		return t.words[a] < t.words[b]
+	})
+
+	remap := make([]int32, len(t.words))
+	for i := range remap {
+		remap[i] = -1
+	}
+	words := make([]string, len(vocab))
+	counts := make([]int64, len(vocab))
+	var ntoks int64
+	for i, id := range vocab {
+		remap[id] = int32(i)
+		words[i] = t.words[id]
+		counts[i] = t.counts[id]
+		ntoks += counts[i]
+	}
+
+	sg := newSkipGram(o, words, counts, ntoks)
+	ids := make([]int32, 0, 64)
+	for e := 0; e < o.Epochs; e++ {
+		for _, s := range t.sents {
+			ids = ids[:0]
+			for _, id := range s {
+				if w := remap[id]; w >= 0 {
+					sg.seen++
+					if sg.keep(w) {
+						ids = append(ids, w)
+					}
+				}
+			}
+			sg.sentence(ids)
+		}
+	}
+	return sg.model(words), nil
+}
+
+// skipGram holds the state of the training of embeddings.
+type skipGram struct {
+	opts  Options
+	dim   int
+	nw    int       // Number of words
+	rows  [][]int   // Of the input vectors, by word
+	in    []float32 // Input vectors, of words and then subwords
+	out   []float32 // Output vectors, of words
+	cum   []float64 // Cumulative weights of words, for negative sampling
+	keeps []float64 // Probabilities of keeping words, when subsampling
+	rng   *rand.Rand
+	total int64 // Word occurrences to be seen in training
+	seen  int64 // Word occurrences seen so far
+	hid   []float32
+	grad  []float32
+}
+
+// newSkipGram creates and initialises the state of training with the
+// given options, for the given words and their counts.
+func newSkipGram(o Options, words []string, counts []int64, ntoks int64) *skipGram {
+	nw := len(words)
+	sg := &skipGram{opts: o, dim: o.Dim, nw: nw}
+	sg.rng = rand.New(rand.NewSource(o.Seed))
+	sg.total = ntoks * int64(o.Epochs)
+	sg.hid = make([]float32, o.Dim)
+	sg.grad = make([]float32, o.Dim)
+
+	nin := nw
+	if o.MinN > 0 {
+		nin += o.Buckets
+	}
+	sg.in = make([]float32, nin*o.Dim)
+	for i := range sg.in {
+		sg.in[i] = float32((sg.rng.Float64() - 0.5) / float64(o.Dim))
+	}
+	sg.out = make([]float32, nw*o.Dim)
+
+	sg.rows = make([][]int, nw)
+	for i, w := range words {
+		sg.rows[i] = []int{i}
+		for _, g := range subwords(w, o.MinN, o.MaxN, o.Buckets) {
+			sg.rows[i] = append(sg.rows[i], nw+g)
+		}
+	}
+
+	sg.cum = make([]float64, nw)
+	sg.keeps = make([]float64, nw)
+	acc := 0.0
+	thr := o.Sample * float64(ntoks)
+	for i, c := range counts {
+		acc += math.Pow(float64(c), 0.75)
+		sg.cum[i] = acc
+		sg.keeps[i] = 1
+		if thr > 0 {
+			f := float64(c)
+			sg.keeps[i] = (math.Sqrt(f/thr) + 1) * thr / f
+		}
+	}
+	return sg
+}
+
+// keep answers if the given occurrence of the given word is to be kept,
+// rather than discarded by subsampling.
+func (sg *skipGram) keep(w int32) bool {
+	p := sg.keeps[w]
+	return p >= 1 || sg.rng.Float64() < p
+}
+
+// sentence trains the vectors on the given sentence: each word predicts
+// the words within a window of random width around it.
+func (sg *skipGram) sentence(ids []int32) {
+	alpha := sg.opts.Alpha * (1 - float64(sg.seen)/float64(sg.total+1))
+	if floor := sg.opts.Alpha * 1e-4; alpha < floor {
+		alpha = floor
+	}
+	for i, w := range ids {
+		b := 1 + sg.rng.Intn(sg.opts.Window)
+		for j := i - b; j <= i+b; j++ {
+			if j < 0 || j >= len(ids) || j == i {
+				continue
+			}
+			sg.pair(w, ids[j], float32(alpha))
+		}
+	}
+}
+
+// pair updates the vectors for the given word predicting the given
+// context word, with negative samples.
+func (sg *skipGram) pair(w, ctx int32, alpha float32) {
+	dim := sg.dim
+	rows := sg.rows[w]
+	for i := range sg.hid {
+		sg.hid[i] = 0
+		sg.grad[i] = 0
+	}
+	for _, r := range rows {
+		add(sg.hid, sg.in[r*dim:(r+1)*dim], 1)
+	}
+	scale(sg.hid, 1/float32(len(rows)))
+
+	for k := 0; k <= sg.opts.Negative; k++ {
+		target, label := int(ctx), float32(1)
+		if k > 0 {
+			target = sg.negative()
+			if target == int(ctx) {
+				continue
+			}
+			label = 0
+		}
+		ov := sg.out[target*dim : (target+1)*dim]
+		g := alpha * (label - sigmoid(dot(sg.hid, ov)))
+		add(sg.grad, ov, g)
+		add(ov, sg.hid, g)
+	}
+
+	for _, r := range rows {
+		add(sg.in[r*dim:(r+1)*dim], sg.grad, 1)
+	}
+}
+
+// negative answers a word drawn from the unigram distribution of words
+// raised to the power 3/4.
+func (sg *skipGram) negative() int {
+	x := sg.rng.Float64() * sg.cum[len(sg.cum)-1]
+	i := sort.SearchFloat64s(sg.cum, x)
+	if i >= sg.nw {
+		i = sg.nw - 1
+	}
+	return i
+}
+
+// model answers the trained model of the given words.  The vector of a
+// word is the average of its input vector and those of its subwords.
+func (sg *skipGram) model(words []string) *Model {
+	dim := sg.dim
+	vecs := make([]float32, sg.nw*dim)
+	for i, rows := range sg.rows {
+		v := vecs[i*dim : (i+1)*dim]
+		for _, r := range rows {
+			add(v, sg.in[r*dim:(r+1)*dim], 1)
+		}
+		scale(v, 1/float32(len(rows)))
+	}
+
+	m := newModel(dim, words, vecs)
+	if sg.opts.MinN > 0 {
+		m.minn, m.maxn = sg.opts.MinN, sg.opts.MaxN
+		m.subs = sg.in[sg.nw*dim:]
+	}
+	return m
+}
+
+// sigmoid answers the logistic function of the given value.
+func sigmoid(x float64) float32 {
+	switch {
+	case x > 20:
+		return 1
+	case x < -20:
+		return 0
+	}
+	return float32(1 / (1 + math.Exp(-x)))
+}
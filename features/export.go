@@ -0,0 +1,117 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package features
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Entry is a non-zero element of a sparse vector.
+type Entry struct {
+	Index int
+	Value float64
+}
+
+// Sparse answers the non-zero elements of the vector of the given
+// dimension that holds the features of this one, in increasing order
+// of their indices.  Features are placed by `Hash`; features that
+// collide add up.  It answers nil, should the dimension not be
+// positive.
+func (v *Vector) Sparse(dim int) []Entry {
+	if dim <= 0 {
+		return nil
+	}
+	counts := make(map[int]float64, len(v.features))
+	for _, f := range v.features {
+		counts[Hash(f, dim)]++
+	}
+	res := make([]Entry, 0, len(counts))
+	for i, c := range counts {
+		res = append(res, Entry{i, c})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Index < res[j].Index })
+	return res
+}
+
+// Labels answers the distinct labels of the given vectors, in sorted
+// order.
+func Labels(vs []*Vector) []string {
+	seen := make(map[string]struct{})
+	var res []string
+	for _, v := range vs {
+		if _, ok := seen[v.label]; !ok {
+			seen[v.label] = struct{}{}
+			res = append(res, v.label)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// WriteLibSVM writes the given vectors to the given writer in the
+// libsvm format, with their features hashed into vectors of the given
+// dimension.  Labels are written as their indices in the given list;
+// see `Labels`.  Feature indices begin at 1.
+func WriteLibSVM(w io.Writer, vs []*Vector, dim int, labels []string) error {
+	if dim <= 0 {
+		return fmt.Errorf("Invalid dimension : %d", dim)
+	}
+	idx := make(map[string]int, len(labels))
+	for i, l := range labels {
+		idx[l] = i
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, v := range vs {
+		li, ok := idx[v.label]
+		if !ok {
+			return fmt.Errorf("Unknown label : %s", v.label)
+		}
+		fmt.Fprintf(bw, "%d", li)
+		for _, e := range v.Sparse(dim) {
+			fmt.Fprintf(bw, " %d:%g", e.Index+1, e.Value)
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// jsonVector is the serialised form of a vector.
+type jsonVector struct {
+	Text     string    `json:"text"`
+	Begin    int       `json:"begin"`
+	End      int       `json:"end"`
+	Label    string    `json:"label"`
+	Features []string  `json:"features"`
+	Indices  []int     `json:"indices,omitempty"`
+	Values   []float64 `json:"values,omitempty"`
+}
+
+// WriteJSON writes the given vectors to the given writer as JSON, one
+// object per line, with their tokens, labels and features.  Should the
+// given dimension be positive, the indices and values of the hashed
+// sparse vectors are written as well.
+func WriteJSON(w io.Writer, vs []*Vector, dim int) error {
+	enc := json.NewEncoder(w)
+	for _, v := range vs {
+		t := v.token
+		jv := jsonVector{Text: t.Text(), Begin: t.Begin(), End: t.End(), Label: v.label, Features: v.features}
+		if dim > 0 {
+			for _, e := range v.Sparse(dim) {
+				jv.Indices = append(jv.Indices, e.Index)
+				jv.Values = append(jv.Values, e.Value)
+			}
+		}
+		if err := enc.Encode(&jv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+// Limits of the values of bucketed features.
+const (
+	maxSentPos = 4
+	maxDepth   = 3
+)
+
+// boundary is the word of the positions beyond the ends of sentences.
+const boundary = "-BOUNDARY-"
+
+// Options control the features extracted for tokens.
+type Options struct {
+	Affixes int // Length of the longest prefixes and suffixes, in runes
+	Window  int // Number of neighbours on either side
+}
+
+// DefaultOptions answers options for prefixes and suffixes of up to
+// three runes, and windows of two neighbours on either side.
+func DefaultOptions() Options {
+	return Options{Affixes: 3, Window: 2}
+}
+
+// Vector holds the features of a token, with its label.
+type Vector struct {
+	token    *tkz.TextToken
+	label    string
+	features []string
+}
+
+// Token answers the token whose features this vector holds.
+func (v *Vector) Token() *tkz.TextToken {
+	return v.token
+}
+
+// Label answers the BIO tag of the token, according to the classes of
+// the words of its section: `B-` or `I-` followed by the class of the
+// word containing it, or `O`.
+func (v *Vector) Label() string {
+	return v.label
+}
+
+// Features answers the features of the token, as `name=value` strings
+// or bare names.
+func (v *Vector) Features() []string {
+	return v.features
+}
+
+// Extractor produces the feature vectors of the tokens of documents.
+//
+// The features of a token are its lowercase text, its shapes, its
+// prefixes and suffixes, its token type, its position in its sentence,
+// its depth within brackets and its matches in gazetteers, together
+// with the text, short shape and type of its neighbours.
+type Extractor struct {
+	opts Options
+	gaz  map[string]*Gazetteer
+}
+
+// NewExtractor creates and initialises an extractor with the given
+// options, and no gazetteers.
+func NewExtractor(o Options) *Extractor {
+	return &Extractor{opts: o, gaz: make(map[string]*Gazetteer)}
+}
+
+// AddGazetteer makes the extractor mark the matches of the given
+// gazetteer, under the given name.
+func (x *Extractor) AddGazetteer(name string, g *Gazetteer) {
+	x.gaz[name] = g
+}
+
+// Section answers the feature vectors of the non-space tokens of the
+// given section of the given document, in text order.
+//
+// Sentences bound the positions and neighbours of tokens; the whole
+// section is a single sentence, should its sentences not have been
+// assembled.
+func (x *Extractor) Section(d *tkz.Document, sec string) ([]*Vector, error) {
+	if _, err := d.SectionTokenCount(sec); err != nil {
+		return nil, err
+	}
+
+	labels := tokenLabels(d, sec)
+	var res []*Vector
+	for _, g := range sentenceTokens(d, sec) {
+		for i, fs := range x.Tokens(g) {
+			t := g[i]
+			l, ok := labels[t.Begin()]
+			if !ok {
+				l = "O"
+			}
+			res = append(res, &Vector{t, l, fs})
+		}
+	}
+	return res, nil
+}
+
+// Tokens answers the features of each of the given tokens of a
+// sentence.
+func (x *Extractor) Tokens(toks []*tkz.TextToken) [][]string {
+	texts := make([]string, len(toks))
+	for i, t := range toks {
+		texts[i] = t.Text()
+	}
+	names := make([]string, 0, len(x.gaz))
+	for n := range x.gaz {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	matches := make([][]string, len(names))
+	for k, n := range names {
+		matches[k] = x.gaz[n].Match(texts)
+	}
+	depths := bracketDepths(toks)
+
+	res := make([][]string, len(toks))
+	for i, t := range toks {
+		w := texts[i]
+		rs := []rune(strings.ToLower(w))
+		fs := make([]string, 0, 16+2*x.opts.Affixes+6*x.opts.Window)
+		add := func(name, val string) {
+			fs = append(fs, name+"="+val)
+		}
+
+		add("w", string(rs))
+		add("shape", Shape(w))
+		add("sshape", ShortShape(w))
+		for n := 1; n <= x.opts.Affixes; n++ {
+			add("pre"+strconv.Itoa(n), RuneAffix(rs, -n))
+			add("suf"+strconv.Itoa(n), RuneAffix(rs, n))
+		}
+		add("type", tkz.TtDescriptions[t.Type()])
+
+		if i < maxSentPos {
+			add("sentpos", strconv.Itoa(i))
+		} else {
+			add("sentpos", strconv.Itoa(maxSentPos)+"+")
+		}
+		if i == 0 {
+			fs = append(fs, "sentfirst")
+		}
+		if i == len(toks)-1 {
+			fs = append(fs, "sentlast")
+		}
+		if depths[i] < maxDepth {
+			add("depth", strconv.Itoa(depths[i]))
+		} else {
+			add("depth", strconv.Itoa(maxDepth)+"+")
+		}
+		for k, n := range names {
+			if m := matches[k][i]; m != "" {
+				add("gaz:"+n, m)
+			}
+		}
+
+		for off := -x.opts.Window; off <= x.opts.Window; off++ {
+			j := i + off
+			if off == 0 {
+				continue
+			}
+			name := fmt.Sprintf("%+d", off)
+			if j < 0 || j >= len(toks) {
+				add("w"+name, boundary)
+				continue
+			}
+			add("w"+name, strings.ToLower(texts[j]))
+			add("sshape"+name, ShortShape(texts[j]))
+			add("type"+name, tkz.TtDescriptions[toks[j].Type()])
+		}
+		res[i] = fs
+	}
+	return res
+}
+
+// bracketDepths answers the number of brackets of any kind enclosing
+// each of the given tokens.  Brackets are at the depth of the text
+// around them.
+func bracketDepths(toks []*tkz.TextToken) []int {
+	res := make([]int, len(toks))
+	depth := 0
+	for i, t := range toks {
+		switch t.Type() {
+		case tkz.TokParenOpen, tkz.TokBracketOpen, tkz.TokBraceOpen:
+			res[i] = depth
+			depth++
+		case tkz.TokParenClose, tkz.TokBracketClose, tkz.TokBraceClose:
+			if depth > 0 {
+				depth--
+			}
+			res[i] = depth
+		default:
+			res[i] = depth
+		}
+	}
+	return res
+}
+
+// sentenceTokens answers the non-space tokens of the given section of
+// the given document, grouped by sentence.
+func sentenceTokens(d *tkz.Document, sec string) [][]*tkz.TextToken {
+	sents := d.SectionSentences(sec)
+	var res [][]*tkz.TextToken
+	var cur []*tkz.TextToken
+	last, k := -1, 0
+	for _, t := range d.SectionTokens(sec) {
+		if t.Type() == tkz.TokSpace {
+			continue
+		}
+		for k < len(sents) && sents[k].End() < t.Begin() {
+			k++
+		}
+		if k != last && len(cur) > 0 {
+			res = append(res, cur)
+			cur = nil
+		}
+		last = k
+		cur = append(cur, t)
+	}
+	if len(cur) > 0 {
+		res = append(res, cur)
+	}
+	return res
+}
+
+// tokenLabels answers the BIO tags of the tokens of the given section
+// of the given document that are part of words with classes, by the
+// beginnings of the tokens.
+func tokenLabels(d *tkz.Document, sec string) map[int]string {
+	toks := d.SectionTokens(sec)
+	res := make(map[int]string)
+	for _, w := range d.SectionWords(sec) {
+		if w.Class() == "" {
+			continue
+		}
+		tag := "B-" + w.Class()
+		i := sort.Search(len(toks), func(i int) bool { return toks[i].Begin() >= w.Begin() })
+		for ; i < len(toks) && toks[i].End() <= w.End(); i++ {
+			if toks[i].Type() == tkz.TokSpace {
+				continue
+			}
+			if _, ok := res[toks[i].Begin()]; !ok {
+				res[toks[i].Begin()] = tag
+			}
+			tag = "I-" + w.Class()
+		}
+	}
+	return res
+}
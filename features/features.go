@@ -0,0 +1,93 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+// Package features extracts the features of tokens and words, for
+// machine learning.
+//
+// It provides the building blocks common to the models of the suite
+// -- shapes, affixes, gazetteers and feature hashing -- and an
+// extractor that produces a feature vector for every token of a
+// section of a document.  Vectors can be exported as sparse vectors
+// of a fixed dimension, in the libsvm format or as JSON.
+package features
+
+import (
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// Shape answers the shape of the given word: its uppercase letters
+// become `X`, lowercase ones `x` and digits `d`, with runs of the same
+// character limited to two.  Other characters are retained.  `MgSO4`
+// becomes `XxXXd`, and `31.1` becomes `dd.d`.
+func Shape(w string) string {
+	return shape(w, 2)
+}
+
+// ShortShape answers the shape of the given word, with runs of the
+// same character limited to one.  `Benzene` becomes `Xx`, and `MgSO4`
+// becomes `XxXd`.
+func ShortShape(w string) string {
+	return shape(w, 1)
+}
+
+// shape answers the shape of the given word, with runs of the same
+// character limited to the given length.
+func shape(w string, maxRun int) string {
+	var sb strings.Builder
+	var last rune
+	run := 0
+	for _, r := range w {
+		switch {
+		case unicode.IsUpper(r):
+			r = 'X'
+		case unicode.IsLower(r):
+			r = 'x'
+		case unicode.IsDigit(r):
+			r = 'd'
+		}
+		if r == last {
+			run++
+		} else {
+			last, run = r, 1
+		}
+		if run <= maxRun {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// Affix answers the last (or first, if negative) `n` runes of the
+// given word, or the word itself if it is shorter.
+func Affix(w string, n int) string {
+	return RuneAffix([]rune(w), n)
+}
+
+// RuneAffix is `Affix` for words already converted to runes, for use
+// when several affixes of the same word are needed.
+func RuneAffix(rs []rune, n int) string {
+	if n < 0 {
+		if -n >= len(rs) {
+			return string(rs)
+		}
+		return string(rs[:-n])
+	}
+	if n >= len(rs) {
+		return string(rs)
+	}
+	return string(rs[len(rs)-n:])
+}
+
+// Hash answers the index of the given feature in a vector of the
+// given dimension.  It uses the 32-bit FNV-1a hash of the feature,
+// which is the same across runs and platforms.  The dimension must be
+// positive; Hash panics otherwise.
+func Hash(feat string, dim int) int {
+	h := fnv.New32a()
+	h.Write([]byte(feat))
+	return int(h.Sum32() % uint32(dim))
+}
@@ -0,0 +1,263 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package features
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+// hasFeature answers if the given features include the given one.
+func hasFeature(fs []string, f string) bool {
+	for _, g := range fs {
+		if g == f {
+			return true
+		}
+	}
+	return false
+}
+
+// extract answers the feature vectors of a test document, with the
+// given text and entities of class `CHEM`.
+func extract(t *testing.T, x *Extractor, in string, ents ...string) []*Vector {
+	d, _ := tkz.NewDocument("Features")
+	d.SetInput("P", in)
+	d.Tokenize()
+	d.AssembleSentences()
+	for _, e := range ents {
+		b := strings.Index(in, e)
+		a := &tkz.Annotation{DocumentID: "Features", Section: "P",
+			Begin: b, End: b + len(e) - 1, Entity: e, Property: "CHEM"}
+		if err := d.Annotate(a, "CLS"); err != nil {
+			t.Fatalf("Unable to annotate : %s", err.Error())
+		}
+	}
+
+	vs, err := x.Section(d, "P")
+	if err != nil {
+		t.Fatalf("Unable to extract features : %s", err.Error())
+	}
+	return vs
+}
+
+//
+
+func TestShape001(t *testing.T) {
+	cases := []struct {
+		in    string
+		shape string
+		short string
+	}{
+		{"Benzene", "Xxx", "Xx"},
+		{"31.1", "dd.d", "d.d"},
+		{"MgSO4", "XxXXd", "XxXd"},
+		{"α-pinene", "x-xx", "x-x"},
+	}
+	for _, c := range cases {
+		if obs := Shape(c.in); obs != c.shape {
+			t.Errorf("Expected shape of %s : %s, observed : %s", c.in, c.shape, obs)
+		}
+		if obs := ShortShape(c.in); obs != c.short {
+			t.Errorf("Expected short shape of %s : %s, observed : %s", c.in, c.short, obs)
+		}
+	}
+
+	if obs := Affix("α-pinene", 3); obs != "ene" {
+		t.Errorf("Expected suffix : ene, observed : %s", obs)
+	}
+	if obs := Affix("α-pinene", -2); obs != "α-" {
+		t.Errorf("Expected prefix : α-, observed : %s", obs)
+	}
+	if obs := Affix("ab", 3); obs != "ab" {
+		t.Errorf("Expected suffix : ab, observed : %s", obs)
+	}
+
+	// Hashes must never change, lest saved models break.
+	if obs := Hash("w=benzene", 1<<20); obs != 775566 {
+		t.Errorf("Expected hash : 775566, observed : %d", obs)
+	}
+	if obs := Hash("w=benzene", 16); obs != 14 {
+		t.Errorf("Expected hash : 14, observed : %d", obs)
+	}
+}
+
+//
+
+func TestGazetteer001(t *testing.T) {
+	in := "# Solvents\n" +
+		"Ethyl acetate\n" +
+		"\n" +
+		"4-methoxyphenol\n" +
+		"ethyl\n"
+	g, err := ReadGazetteer(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Unable to read gazetteer : %s", err.Error())
+	}
+	if g.Len() != 3 {
+		t.Errorf("Expected phrase count : 3, observed : %d", g.Len())
+	}
+	if obs := strings.Join(g.Phrases(), "|"); obs != "4-methoxyphenol|ethyl|ethyl acetate" {
+		t.Errorf("Expected phrases : 4-methoxyphenol|ethyl|ethyl acetate, observed : %s", obs)
+	}
+
+	cases := []struct {
+		in  string
+		exp string
+	}{
+		{"wash with ethyl acetate and ethyl ether", "__BI_B_"},
+		{"add 4-methoxyphenol", "_B"},
+		{"add 4 - methoxyphenol", "_BII"},
+		{"add 4 - methoxy", "____"},
+	}
+	for _, c := range cases {
+		var sb strings.Builder
+		for _, m := range g.Match(strings.Fields(c.in)) {
+			if m == "" {
+				m = "_"
+			}
+			sb.WriteString(m)
+		}
+		if sb.String() != c.exp {
+			t.Errorf("Expected matches of %q : %s, observed : %s", c.in, c.exp, sb.String())
+		}
+	}
+}
+
+//
+
+func TestExtractor001(t *testing.T) {
+	x := NewExtractor(DefaultOptions())
+	x.AddGazetteer("solvent", NewGazetteer("ethyl acetate"))
+	in := "The oil (in ethyl acetate) was dried. It was NaCl."
+	vs := extract(t, x, in, "ethyl acetate", "NaCl")
+
+	if len(vs) != 14 {
+		t.Fatalf("Expected vector count : 14, observed : %d", len(vs))
+	}
+	exp := []string{"O", "O", "O", "O", "B-CHEM", "I-CHEM", "O", "O", "O", "O", "O", "O", "B-CHEM", "O"}
+	for i, v := range vs {
+		if v.Label() != exp[i] {
+			t.Errorf("Expected label of %s : %s, observed : %s", v.Token().Text(), exp[i], v.Label())
+		}
+	}
+
+	checks := []struct {
+		idx  int
+		feat string
+		exp  bool
+	}{
+		{0, "sentfirst", true},
+		{0, "w-1=-BOUNDARY-", true},
+		{0, "w=the", true},
+		{0, "shape=Xxx", true},
+		{0, "sshape=Xx", true},
+		{0, "pre1=t", true},
+		{0, "suf3=the", true},
+		{0, "type=TokMayBeWord", true},
+		{2, "depth=0", true},
+		{2, "type=TokParenOpen", true},
+		{4, "depth=1", true},
+		{4, "gaz:solvent=B", true},
+		{5, "gaz:solvent=I", true},
+		{5, "sentpos=4+", true},
+		{5, "w+1=)", true},
+		{5, "type+1=TokParenClose", true},
+		{5, "w-2=ethyl", false},
+		{5, "w-2=in", true},
+		{6, "depth=0", true},
+		{9, "sentlast", true},
+		{9, "w+1=-BOUNDARY-", true},
+		{10, "sentfirst", true},
+		{10, "w-1=-BOUNDARY-", true},
+		{12, "sshape=XxXx", true},
+	}
+	for _, c := range checks {
+		if obs := hasFeature(vs[c.idx].Features(), c.feat); obs != c.exp {
+			t.Errorf("Expected feature %s of %s : %v, observed : %v", c.feat, vs[c.idx].Token().Text(), c.exp, obs)
+		}
+	}
+
+	d, _ := tkz.NewDocument("Features")
+	if _, err := x.Section(d, "Q"); err == nil {
+		t.Errorf("Expected an error for an unknown section")
+	}
+}
+
+//
+
+func TestExport001(t *testing.T) {
+	x := NewExtractor(Options{Affixes: 1, Window: 1})
+	vs := extract(t, x, "Add NaCl.", "NaCl")
+
+	labels := Labels(vs)
+	if strings.Join(labels, " ") != "B-CHEM O" {
+		t.Fatalf("Expected labels : B-CHEM O, observed : %v", labels)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteLibSVM(&buf, vs, 1<<20, labels); err != nil {
+		t.Fatalf("Unable to write vectors : %s", err.Error())
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected line count : 3, observed : %d", len(lines))
+	}
+	fs := strings.Fields(lines[1])
+	if fs[0] != "0" {
+		t.Errorf("Expected label : 0, observed : %s", fs[0])
+	}
+	if len(fs)-1 != len(vs[1].Sparse(1<<20)) {
+		t.Errorf("Expected feature count : %d, observed : %d", len(vs[1].Sparse(1<<20)), len(fs)-1)
+	}
+	for _, f := range fs[1:] {
+		if !strings.HasSuffix(f, ":1") {
+			t.Errorf("Expected a feature of value 1, observed : %s", f)
+		}
+	}
+
+	// In a vector of dimension 1, all the features collide.
+	if sp := vs[0].Sparse(1); len(sp) != 1 || sp[0].Value != float64(len(vs[0].Features())) {
+		t.Errorf("Expected a single entry of value %d, observed : %v", len(vs[0].Features()), sp)
+	}
+
+	if err := WriteLibSVM(&buf, vs, 1<<20, []string{"O"}); err == nil {
+		t.Errorf("Expected an error for an unknown label")
+	}
+	if sp := vs[0].Sparse(0); sp != nil {
+		t.Errorf("Expected no entries for an invalid dimension, observed : %v", sp)
+	}
+	if err := WriteLibSVM(&buf, vs, 0, labels); err == nil {
+		t.Errorf("Expected an error for an invalid dimension")
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, vs, 64); err != nil {
+		t.Fatalf("Unable to write vectors : %s", err.Error())
+	}
+	sc := bufio.NewScanner(&buf)
+	n := 0
+	for ; sc.Scan(); n++ {
+		var jv jsonVector
+		if err := json.Unmarshal(sc.Bytes(), &jv); err != nil {
+			t.Fatalf("Unable to read vector : %s", err.Error())
+		}
+		v := vs[n]
+		if jv.Text != v.Token().Text() || jv.Label != v.Label() || len(jv.Features) != len(v.Features()) {
+			t.Errorf("Expected vector of %s, observed : %v", v.Token().Text(), jv)
+		}
+		if len(jv.Indices) != len(v.Sparse(64)) || len(jv.Values) != len(jv.Indices) {
+			t.Errorf("Expected hashed entry count : %d, observed : %d", len(v.Sparse(64)), len(jv.Indices))
+		}
+	}
+	if n != len(vs) {
+		t.Errorf("Expected vector count : %d, observed : %d", len(vs), n)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package features
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+// Gazetteer holds a list of phrases -- names of chemicals, reagents,
+// apparatus and the like -- to be found in sequences of words or
+// tokens.  Phrases are matched ignoring case.
+type Gazetteer struct {
+	phrases map[string]struct{} // As given, in lowercase
+	keys    map[string]struct{} // Words or tokens, separated by space
+	maxLen  int                 // Of the keys, in words or tokens
+}
+
+// NewGazetteer creates and initialises a gazetteer with the given
+// phrases.
+func NewGazetteer(phrases ...string) *Gazetteer {
+	g := &Gazetteer{}
+	g.phrases = make(map[string]struct{})
+	g.keys = make(map[string]struct{})
+	g.Add(phrases...)
+	return g
+}
+
+// ReadGazetteer reads a gazetteer from the given reader, with one
+// phrase per line.  Empty lines and those beginning with `#` are
+// ignored.
+func ReadGazetteer(r io.Reader) (*Gazetteer, error) {
+	g := NewGazetteer()
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		g.Add(line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Add records the given phrases in the gazetteer.
+//
+// Each phrase is recorded both as a sequence of words separated by
+// space, and as a sequence of the tokens of the tokenizer, so that
+// `4-methoxyphenol` matches the word `4-methoxyphenol` as well as the
+// tokens `4`, `-` and `methoxyphenol`.
+func (g *Gazetteer) Add(phrases ...string) {
+	for _, p := range phrases {
+		lp := strings.ToLower(strings.TrimSpace(p))
+		if lp == "" {
+			continue
+		}
+		g.phrases[lp] = struct{}{}
+		g.addKey(strings.Fields(lp))
+
+		var toks []string
+		ti := tkz.NewTextTokenIterator(lp)
+		for err := ti.MoveNext(); err == nil; err = ti.MoveNext() {
+			if t := ti.Item(); t.Type() != tkz.TokSpace {
+				toks = append(toks, t.Text())
+			}
+		}
+		g.addKey(toks)
+	}
+}
+
+// addKey records the given sequence of words or tokens.
+func (g *Gazetteer) addKey(ws []string) {
+	if len(ws) == 0 {
+		return
+	}
+	g.keys[strings.Join(ws, " ")] = struct{}{}
+	if len(ws) > g.maxLen {
+		g.maxLen = len(ws)
+	}
+}
+
+// Len answers the number of phrases in the gazetteer.
+func (g *Gazetteer) Len() int {
+	return len(g.phrases)
+}
+
+// Phrases answers the phrases of the gazetteer, in lowercase and in
+// sorted order.
+func (g *Gazetteer) Phrases() []string {
+	res := make([]string, 0, len(g.phrases))
+	for p := range g.phrases {
+		res = append(res, p)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Match answers the positions of the phrases of the gazetteer in the
+// given sequence of words or tokens: `B` for the first of a phrase, `I`
+// for the rest, and empty for the others.  The longest phrases are
+// chosen, from left to right.
+func (g *Gazetteer) Match(words []string) []string {
+	res := make([]string, len(words))
+	if len(g.keys) == 0 {
+		return res
+	}
+
+	lws := make([]string, len(words))
+	for i, w := range words {
+		lws[i] = strings.ToLower(w)
+	}
+	for i := 0; i < len(lws); {
+		n := g.maxLen
+		if i+n > len(lws) {
+			n = len(lws) - i
+		}
+		for ; n > 0; n-- {
+			if _, ok := g.keys[strings.Join(lws[i:i+n], " ")]; ok {
+				res[i] = "B"
+				for j := i + 1; j < i+n; j++ {
+					res[j] = "I"
+				}
+				break
+			}
+		}
+		if n == 0 {
+			n = 1
+		}
+		i += n
+	}
+	return res
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+// Package perceptron implements a multi-class averaged perceptron over
+// sparse, binary string features.
+//
+// Weights are averaged over all the updates seen during training, which
+// makes the model far less sensitive to the order of training examples
+// than a plain perceptron.  The technique follows Collins (2002).
+package perceptron
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Model holds the weights of the features, for each class.
+//
+// During training, it also keeps track of the accumulated weights
+// needed for averaging.  `Average` must be invoked once training is
+// complete.
+type Model struct {
+	classes []string
+	weights map[string]map[string]float64 // Feature -> class -> weight
+
+	totals  map[string]map[string]float64 // Accumulated weights
+	stamps  map[string]map[string]int     // Update at which each weight last changed
+	updates int
+}
+
+// New creates and initialises an empty model for the given classes.
+func New(classes []string) *Model {
+	m := &Model{}
+	m.classes = append([]string(nil), classes...)
+	m.weights = make(map[string]map[string]float64)
+	m.totals = make(map[string]map[string]float64)
+	m.stamps = make(map[string]map[string]int)
+	return m
+}
+
+// Classes answers the classes of this model, in the order given at
+// creation.
+func (m *Model) Classes() []string {
+	return m.classes
+}
+
+// AddClass makes the model aware of the given class, should it not
+// already be.
+func (m *Model) AddClass(class string) {
+	for _, c := range m.classes {
+		if c == class {
+			return
+		}
+	}
+	m.classes = append(m.classes, class)
+}
+
+// Scores answers the score of each class for the given features.
+func (m *Model) Scores(feats []string) map[string]float64 {
+	res := make(map[string]float64, len(m.classes))
+	for _, c := range m.classes {
+		res[c] = 0
+	}
+	for _, f := range feats {
+		for c, w := range m.weights[f] {
+			res[c] += w
+		}
+	}
+	return res
+}
+
+// Predict answers the class with the highest score for the given
+// features.  Ties are broken in favour of the class given first.
+func (m *Model) Predict(feats []string) string {
+	return m.Best(m.Scores(feats))
+}
+
+// Best answers the class with the highest of the given scores.  Ties
+// are broken in favour of the class given first.
+func (m *Model) Best(scores map[string]float64) string {
+	best := ""
+	for _, c := range m.classes {
+		if best == "" || scores[c] > scores[best] {
+			best = c
+		}
+	}
+	return best
+}
+
+// Tick marks the end of one training example.  Averaging weighs each
+// weight by the number of examples it survived.
+func (m *Model) Tick() {
+	m.updates++
+}
+
+// Update rewards the features for the given true class, and penalises
+// them for the given guessed class, should the two differ.  It does not
+// mark the end of the example; see `Tick`.
+func (m *Model) Update(truth, guess string, feats []string) {
+	if truth == guess {
+		return
+	}
+	m.AddClass(truth)
+	for _, f := range feats {
+		m.Adjust(f, truth, 1)
+		m.Adjust(f, guess, -1)
+	}
+}
+
+// Adjust changes the weight of the given feature for the given class
+// by the given amount.
+func (m *Model) Adjust(feat, class string, delta float64) {
+	ws, ok := m.weights[feat]
+	if !ok {
+		ws = make(map[string]float64)
+		m.weights[feat] = ws
+		m.totals[feat] = make(map[string]float64)
+		m.stamps[feat] = make(map[string]int)
+	}
+
+	w := ws[class]
+	m.totals[feat][class] += float64(m.updates-m.stamps[feat][class]) * w
+	m.stamps[feat][class] = m.updates
+	ws[class] = w + delta
+}
+
+// Average replaces the weights with their averages over all the
+// training examples seen.  The model can not be trained further
+// afterwards.
+func (m *Model) Average() {
+	if m.updates == 0 {
+		return
+	}
+	for f, ws := range m.weights {
+		for c, w := range ws {
+			total := m.totals[f][c] + float64(m.updates-m.stamps[f][c])*w
+			avg := total / float64(m.updates)
+			if avg == 0 {
+				delete(ws, c)
+				continue
+			}
+			ws[c] = avg
+		}
+		if len(ws) == 0 {
+			delete(m.weights, f)
+		}
+	}
+	m.totals = make(map[string]map[string]float64)
+	m.stamps = make(map[string]map[string]int)
+	m.updates = 0
+}
+
+// Len answers the number of features that have non-zero weights.
+func (m *Model) Len() int {
+	return len(m.weights)
+}
+
+// jsonModel is the serialised form of a model.
+type jsonModel struct {
+	Classes []string                      `json:"classes"`
+	Weights map[string]map[string]float64 `json:"weights"`
+}
+
+// MarshalJSON answers the classes and weights of the model, as JSON
+// with its features in sorted order.  Only averaged models should be
+// serialised.
+func (m *Model) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonModel{Classes: m.classes, Weights: m.weights})
+}
+
+// UnmarshalJSON replaces the model with one serialised by
+// `MarshalJSON`.
+func (m *Model) UnmarshalJSON(b []byte) error {
+	var jm jsonModel
+	if err := json.Unmarshal(b, &jm); err != nil {
+		return fmt.Errorf("Invalid model : %s", err.Error())
+	}
+	if len(jm.Classes) == 0 {
+		return fmt.Errorf("Invalid model : %s", "no classes")
+	}
+
+	*m = *New(jm.Classes)
+	if jm.Weights != nil {
+		m.weights = jm.Weights
+	}
+	return nil
+}
+
+// Save writes the model to the given writer, as JSON.
+func (m *Model) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(m)
+}
+
+// Load reads a model saved by `Save` from the given reader.
+func Load(r io.Reader) (*Model, error) {
+	m := &Model{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package perceptron
+
+import (
+	"bytes"
+	"testing"
+)
+
+// train trains a model to tell the classes of simple examples apart.
+func train() *Model {
+	exs := []struct {
+		feats []string
+		class string
+	}{
+		{[]string{"bias", "w=cat", "suf=at"}, "noun"},
+		{[]string{"bias", "w=hat", "suf=at"}, "noun"},
+		{[]string{"bias", "w=run", "suf=un"}, "verb"},
+		{[]string{"bias", "w=sat", "suf=at", "prev=he"}, "verb"},
+		{[]string{"bias", "w=dog", "suf=og"}, "noun"},
+		{[]string{"bias", "w=ran", "suf=an", "prev=he"}, "verb"},
+	}
+
+	m := New([]string{"noun", "verb"})
+	for it := 0; it < 10; it++ {
+		for _, ex := range exs {
+			m.Update(ex.class, m.Predict(ex.feats), ex.feats)
+			m.Tick()
+		}
+	}
+	m.Average()
+	return m
+}
+
+//
+
+func TestPerceptron001(t *testing.T) {
+	m := train()
+	cases := []struct {
+		feats []string
+		exp   string
+	}{
+		{[]string{"bias", "w=cat", "suf=at"}, "noun"},
+		{[]string{"bias", "w=sat", "suf=at", "prev=he"}, "verb"},
+		{[]string{"bias", "w=bat", "suf=at"}, "noun"},
+		{[]string{"bias", "w=fan", "suf=an", "prev=he"}, "verb"},
+	}
+	for _, c := range cases {
+		if obs := m.Predict(c.feats); obs != c.exp {
+			t.Errorf("Expected class for %v : %s, observed : %s", c.feats, c.exp, obs)
+		}
+	}
+}
+
+//
+
+func TestPerceptron002(t *testing.T) {
+	m := train()
+	var buf bytes.Buffer
+	if err := m.Save(&buf); err != nil {
+		t.Fatalf("Unable to save model : %s", err.Error())
+	}
+	m2, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Unable to load model : %s", err.Error())
+	}
+
+	if m2.Len() != m.Len() {
+		t.Fatalf("Expected feature count : %d, observed : %d", m.Len(), m2.Len())
+	}
+	feats := []string{"bias", "w=sat", "suf=at", "prev=he"}
+	s1, s2 := m.Scores(feats), m2.Scores(feats)
+	for _, c := range m.Classes() {
+		if s1[c] != s2[c] {
+			t.Errorf("Expected score for %s : %v, observed : %v", c, s1[c], s2[c])
+		}
+	}
+
+	if _, err := Load(bytes.NewBufferString(`{"classes":[]}`)); err == nil {
+		t.Errorf("Expected an error for a model without classes")
+	}
+}
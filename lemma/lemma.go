@@ -0,0 +1,473 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+// Package lemma reduces English words to their lemmas: `oxides` to
+// `oxide`, `stirred` to `stir`, `was` to `be`, etc.
+//
+// Its lemmatizer first consults a table of exceptions, which holds
+// irregular forms, and which users can extend.  Other words are
+// reduced by suffix rules.  When a rule yields more than one
+// candidate, the one found in a dictionary of base forms is preferred;
+// failing that, spelling heuristics choose.  Parts of speech, when
+// known, select the rules that apply.
+package lemma
+
+import (
+	"bufio"
+	_ "embed" // For the bundled data
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+//go:embed data/exceptions.txt
+var bundledExceptions string
+
+//go:embed data/words.txt
+var bundledWords string
+
+// wordClass is the coarse part of speech that selects lemmatisation
+// rules.
+type wordClass byte
+
+// Word classes.
+const (
+	classAny    wordClass = iota // Unknown part of speech
+	classNoun                    // Common nouns
+	classVerb                    // Verbs and auxiliaries
+	classAdj                     // Adjectives
+	classAdv                     // Adverbs
+	classProper                  // Proper nouns; left as they are
+	classOther                   // Everything else; only lowercased
+)
+
+// posClass answers the word class of the given part of speech tag.
+// Both Universal Dependencies tags and Penn Treebank tags are
+// understood.
+func posClass(pos string) wordClass {
+	p := strings.ToUpper(pos)
+	switch {
+	case p == "":
+		return classAny
+	case p == "PROPN" || p == "NNP" || p == "NNPS":
+		return classProper
+	case p == "NOUN" || strings.HasPrefix(p, "NN"):
+		return classNoun
+	case p == "VERB" || p == "AUX" || p == "MD" || strings.HasPrefix(p, "VB"):
+		return classVerb
+	case p == "ADJ" || strings.HasPrefix(p, "JJ"):
+		return classAdj
+	case p == "ADV" || strings.HasPrefix(p, "RB"):
+		return classAdv
+	}
+	return classOther
+}
+
+// exceptionClass answers the word class of the given part of speech
+// of an exception entry, which must be empty or name an open class.
+func exceptionClass(pos string) (wordClass, error) {
+	c := posClass(pos)
+	switch c {
+	case classAny, classNoun, classVerb, classAdj, classAdv:
+		return c, nil
+	}
+	return c, fmt.Errorf("Invalid part of speech for an exception : %s", pos)
+}
+
+// Lemmatizer answers the lemmas of English words.
+type Lemmatizer struct {
+	exceptions map[string]map[wordClass]string
+	words      map[string]struct{} // Known base forms
+}
+
+// NewLemmatizer creates and initialises a lemmatizer with the bundled
+// exceptions and dictionary.
+func NewLemmatizer() *Lemmatizer {
+	lz := &Lemmatizer{}
+	lz.exceptions = make(map[string]map[wordClass]string)
+	lz.words = make(map[string]struct{})
+
+	if err := lz.ReadExceptions(strings.NewReader(bundledExceptions)); err != nil {
+		panic(err)
+	}
+	for _, line := range strings.Split(bundledWords, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lz.AddWords(line)
+	}
+	return lz
+}
+
+// AddException records the given lemma of the given word form.  The
+// entry is restricted to the given part of speech, unless it is empty.
+// Later entries replace earlier ones.
+func (lz *Lemmatizer) AddException(form, lemma, pos string) error {
+	c, err := exceptionClass(pos)
+	if err != nil {
+		return err
+	}
+	if form == "" || lemma == "" {
+		return fmt.Errorf("Invalid exception : %q %q", form, lemma)
+	}
+
+	form = strings.ToLower(form)
+	m, ok := lz.exceptions[form]
+	if !ok {
+		m = make(map[wordClass]string)
+		lz.exceptions[form] = m
+	}
+	m[c] = lemma
+	return nil
+}
+
+// ReadExceptions reads exceptions from the given reader.  Each line
+// holds a word form, its lemma and, optionally, a part of speech,
+// separated by space.  Empty lines and those beginning with `#` are
+// ignored.
+func (lz *Lemmatizer) ReadExceptions(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fs := strings.Fields(line)
+		if len(fs) < 2 || len(fs) > 3 {
+			return fmt.Errorf("Invalid exception at line %d : %s", n, line)
+		}
+		pos := ""
+		if len(fs) == 3 {
+			pos = fs[2]
+		}
+		if err := lz.AddException(fs[0], fs[1], pos); err != nil {
+			return fmt.Errorf("Invalid exception at line %d : %s", n, line)
+		}
+	}
+	return sc.Err()
+}
+
+// AddWords records the given base forms in the dictionary of the
+// lemmatizer.
+func (lz *Lemmatizer) AddWords(words ...string) {
+	for _, w := range words {
+		lz.words[strings.ToLower(w)] = struct{}{}
+	}
+}
+
+// knows answers if the given word is a known base form.
+func (lz *Lemmatizer) knows(w string) bool {
+	_, ok := lz.words[w]
+	return ok
+}
+
+// exception answers the lemma of the given lowercase word, of the
+// given class, from the exceptions, and if there is one.
+func (lz *Lemmatizer) exception(lw string, c wordClass) (string, bool) {
+	m, ok := lz.exceptions[lw]
+	if !ok {
+		return "", false
+	}
+	if l, ok := m[c]; ok {
+		return l, true
+	}
+	if l, ok := m[classAny]; ok {
+		return l, true
+	}
+	if c == classAny {
+		for _, c := range []wordClass{classVerb, classNoun, classAdj, classAdv} {
+			if l, ok := m[c]; ok {
+				return l, true
+			}
+		}
+	}
+	return "", false
+}
+
+// keepsCase answers if the given word should be left as it is: one
+// with digits, or with uppercase letters after its first, such as
+// chemical formulae (`MgSO4`) and acronyms (`THF`, `pH`), or one with
+// no letters at all.
+func keepsCase(w string) bool {
+	letter := false
+	for i, r := range w {
+		switch {
+		case unicode.IsDigit(r):
+			return true
+		case unicode.IsUpper(r) && i > 0:
+			return true
+		case unicode.IsLetter(r):
+			letter = true
+		}
+	}
+	return !letter
+}
+
+// Lemma answers the lemma of the given word, of the given part of
+// speech.  The part of speech may be a Universal Dependencies tag, a
+// Penn Treebank tag or empty, when the ending of the word selects the
+// rules.
+//
+// Lemmas are lowercase.  Proper nouns, formulae and acronyms are
+// answered as they are.
+func (lz *Lemmatizer) Lemma(word, pos string) string {
+	c := posClass(pos)
+	if c == classProper {
+		return word
+	}
+
+	// Only the last part of a hyphenated word inflects, and only it
+	// decides if the word is a formula: `2-nitrobenzaldehydes`.
+	if i := strings.LastIndex(word, "-"); i > 0 && i < len(word)-1 {
+		if keepsCase(word[i+1:]) {
+			return word
+		}
+		if l, ok := lz.exception(strings.ToLower(word), c); ok {
+			return l
+		}
+		return strings.ToLower(word[:i+1]) + lz.Lemma(word[i+1:], pos)
+	}
+	if keepsCase(word) {
+		return word
+	}
+
+	lw := strings.ToLower(word)
+	if l, ok := lz.exception(lw, c); ok {
+		return l
+	}
+	if c == classOther || c == classAdv {
+		return lw
+	}
+
+	if lz.knows(lw) {
+		return lw
+	}
+
+	if c == classAny {
+		switch {
+		case strings.HasSuffix(lw, "ed") || strings.HasSuffix(lw, "ing"):
+			c = classVerb
+		case strings.HasSuffix(lw, "s"):
+			c = classNoun
+		default:
+			return lw
+		}
+	}
+
+	var cands []string
+	var fallback string
+	switch c {
+	case classNoun:
+		cands, fallback = nounCandidates(lw)
+	case classVerb:
+		cands, fallback = verbCandidates(lw)
+	case classAdj:
+		cands, fallback = adjCandidates(lw)
+	}
+	for _, s := range cands {
+		if lz.knows(s) {
+			return s
+		}
+	}
+	return fallback
+}
+
+// LemmatizeSection fills the lemmas of the words of the given section
+// of the given document, using their parts of speech when they have
+// been tagged.  Words are created for the tokens that are not already
+// part of one; see `Document.EnsureWords`.  Words that already have
+// lemmas, such as those annotated by hand, are left as they are.
+func (lz *Lemmatizer) LemmatizeSection(d *tkz.Document, sec string) error {
+	ws, err := d.EnsureWords(sec)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range ws {
+		if w.Lemma() == "" {
+			w.SetLemma(lz.Lemma(w.Text(), w.POS()))
+		}
+	}
+	return nil
+}
+
+// isVowel answers if the given byte is an English vowel.
+func isVowel(b byte) bool {
+	return strings.IndexByte("aeiou", b) >= 0
+}
+
+// hasVowel answers if the given word has a vowel, counting `y`.
+func hasVowel(w string) bool {
+	return strings.ContainsAny(w, "aeiouy")
+}
+
+// nounCandidates answers the candidate singulars of the given
+// lowercase plural noun, and the one to use when none is known.
+func nounCandidates(lw string) ([]string, string) {
+	n := len(lw)
+	switch {
+	case n <= 3 || !strings.HasSuffix(lw, "s"):
+		return nil, lw
+	case strings.HasSuffix(lw, "ss") || strings.HasSuffix(lw, "us") || strings.HasSuffix(lw, "is"):
+		return nil, lw
+	case strings.HasSuffix(lw, "yses") || strings.HasSuffix(lw, "theses"):
+		return nil, lw[:n-2] + "is" // `hydrolyses`, `syntheses`
+	case strings.HasSuffix(lw, "ies") && n > 4:
+		return []string{lw[:n-3] + "y", lw[:n-1]}, lw[:n-3] + "y"
+	case strings.HasSuffix(lw, "es"):
+		stem := lw[:n-2]
+		fallback := lw[:n-1]
+		for _, suf := range []string{"x", "ch", "sh", "ss", "zz"} {
+			if strings.HasSuffix(stem, suf) {
+				fallback = stem
+				break
+			}
+		}
+		return []string{lw[:n-1], stem}, fallback
+	}
+	return []string{lw[:n-1]}, lw[:n-1]
+}
+
+// verbCandidates answers the candidate base forms of the given
+// lowercase inflected verb, and the one to use when none is known.
+func verbCandidates(lw string) ([]string, string) {
+	n := len(lw)
+	var stem string
+	switch {
+	case strings.HasSuffix(lw, "ied") && n > 4:
+		return []string{lw[:n-3] + "y"}, lw[:n-3] + "y"
+	case strings.HasSuffix(lw, "eed"):
+		return nil, lw // `proceed`, `exceed`; `agreed` is an exception
+	case strings.HasSuffix(lw, "ed") && n > 3:
+		stem = lw[:n-2] // `used`, `aged`, `dyed`
+	case strings.HasSuffix(lw, "ing") && n > 4:
+		stem = lw[:n-3]
+	case strings.HasSuffix(lw, "s"):
+		return nounCandidates(lw) // Third person singular
+	default:
+		return nil, lw
+	}
+	if !hasVowel(stem) {
+		return nil, lw // `thing`, `bring`
+	}
+
+	cands := []string{stem, stem + "e"}
+	u, undoubled := undouble(stem)
+	if undoubled {
+		cands = append(cands, u)
+	}
+
+	switch {
+	case undoubled && keepsDouble(stem):
+		return cands, stem
+	case undoubled:
+		return cands, u
+	case restoresE(stem):
+		return cands, stem + "e"
+	}
+	return cands, stem
+}
+
+// adjCandidates answers the candidate positive forms of the given
+// lowercase comparative or superlative adjective.  Since many
+// adjectives end in `er` (`other`, `proper`), only known forms are
+// chosen.
+func adjCandidates(lw string) ([]string, string) {
+	n := len(lw)
+	var stem string
+	switch {
+	case strings.HasSuffix(lw, "ier") && n > 4:
+		return []string{lw[:n-3] + "y"}, lw
+	case strings.HasSuffix(lw, "iest") && n > 5:
+		return []string{lw[:n-4] + "y"}, lw
+	case strings.HasSuffix(lw, "er") && n > 4:
+		stem = lw[:n-2]
+	case strings.HasSuffix(lw, "est") && n > 5:
+		stem = lw[:n-3]
+	default:
+		return nil, lw
+	}
+
+	cands := []string{stem, stem + "e"}
+	if u, ok := undouble(stem); ok {
+		cands = append(cands, u)
+	}
+	return cands, lw
+}
+
+// undouble answers the given stem without its final consonant, if
+// that is doubled: `stirr` becomes `stir`.
+func undouble(stem string) (string, bool) {
+	n := len(stem)
+	if n < 3 || stem[n-1] != stem[n-2] || isVowel(stem[n-1]) || stem[n-1] == 'y' {
+		return stem, false
+	}
+	return stem[:n-1], true
+}
+
+// keepsDouble answers if the doubled final consonant of the given
+// stem belongs to its base form: `fill`, `press`, `buzz`, `stuff`.
+// Long stems ending in `ell` and `oll` are the British doublings of
+// `label` and `control`.
+func keepsDouble(stem string) bool {
+	n := len(stem)
+	switch stem[n-1] {
+	case 'l':
+		switch {
+		case stem[n-3] == 'e' && n > 5:
+			return false
+		case stem[n-3] == 'o' && n > 6:
+			return false
+		}
+		return true
+	case 's', 'z', 'f':
+		return true
+	}
+	return false
+}
+
+// restoresE answers if the base form of the given stem, from which
+// `ed` or `ing` has been removed, most likely ends in a silent `e`:
+// `evaporat`, `dissolv`, `purg`, `includ`, `combin`, etc.
+func restoresE(stem string) bool {
+	n := len(stem)
+	if n < 2 {
+		return false
+	}
+	last, prev := stem[n-1], stem[n-2]
+
+	// A single vowel after a consonant, before the last consonant.
+	cvc := isVowel(prev) && (n < 3 || !isVowel(stem[n-3]))
+
+	switch last {
+	case 'v':
+		return true
+	case 'z':
+		return prev != 'z'
+	case 'c':
+		return true
+	case 's':
+		return prev != 's' && !strings.HasSuffix(stem, "cus") && !strings.HasSuffix(stem, "ias")
+	case 'g':
+		return prev == 'r' || prev == 'd' || ((prev == 'n') && n > 2 && (stem[n-3] == 'a' || stem[n-3] == 'e'))
+	case 't':
+		return cvc && (prev == 'a' || prev == 'u' || prev == 'o')
+	case 'n':
+		return cvc && (prev == 'i' || prev == 'u')
+	case 'l':
+		return (cvc && prev != 'e') || strings.IndexByte("bcdfgkptz", prev) >= 0
+	case 'r':
+		return cvc && prev != 'e'
+	case 'p':
+		return cvc && prev != 'o' && prev != 'e'
+	case 'b', 'd', 'k', 'm':
+		return cvc && prev != 'e'
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package lemma
+
+import (
+	"strings"
+	"testing"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+func TestLemma001(t *testing.T) {
+	lz := NewLemmatizer()
+	cases := []struct {
+		in  string
+		pos string
+		exp string
+	}{
+		{"oxides", "NOUN", "oxide"},
+		{"aldehydes", "NOUN", "aldehyde"},
+		{"impurities", "NOUN", "impurity"},
+		{"flasks", "NOUN", "flask"},
+		{"mixtures", "NOUN", "mixture"},
+		{"washes", "NOUN", "wash"},
+		{"boxes", "NOUN", "box"},
+		{"analyses", "NOUN", "analysis"},
+		{"spectra", "NOUN", "spectrum"},
+		{"stirred", "VERB", "stir"},
+		{"purged", "VERB", "purge"},
+		{"cooled", "VERB", "cool"},
+		{"evaporated", "VERB", "evaporate"},
+		{"dried", "VERB", "dry"},
+		{"heating", "VERB", "heat"},
+		{"dissolving", "VERB", "dissolve"},
+		{"using", "VERB", "use"},
+		{"tying", "VERB", "tie"},
+		{"added", "VERB", "add"},
+		{"was", "AUX", "be"},
+		{"ran", "VBD", "run"},
+		{"Washed", "VBN", "wash"},
+		{"larger", "ADJ", "large"},
+		{"better", "JJR", "good"},
+	}
+	for _, c := range cases {
+		if obs := lz.Lemma(c.in, c.pos); obs != c.exp {
+			t.Errorf("Expected lemma of %s/%s : %s, observed : %s", c.in, c.pos, c.exp, obs)
+		}
+	}
+}
+
+//
+
+func TestLemma002(t *testing.T) {
+	lz := NewLemmatizer()
+	cases := []struct {
+		in  string
+		pos string
+		exp string
+	}{
+		// Unknown words rely on the spelling heuristics.
+		{"nitrated", "VERB", "nitrate"},
+		{"plotted", "VERB", "plot"},
+		{"labelled", "VERB", "label"},
+		{"sonicated", "VERB", "sonicate"},
+		{"ozonolyses", "NOUN", "ozonolysis"},
+		{"carbamates", "NOUN", "carbamate"},
+		{"used", "VERB", "use"},
+		{"aged", "VBN", "age"},
+		{"iced", "VERB", "ice"},
+		{"dyed", "VERB", "dye"},
+
+		// Without a part of speech, the ending chooses the rules.
+		{"stirred", "", "stir"},
+		{"oxides", "", "oxide"},
+		{"was", "", "be"},
+		{"other", "", "other"},
+		{"used", "", "use"},
+		{"always", "", "always"},
+		{"perhaps", "", "perhaps"},
+		{"does", "NOUN", "do"},
+
+		// Parts of speech condition the rules.
+		{"coating", "NOUN", "coating"},
+		{"coating", "VERB", "coat"},
+		{"filtered", "ADJ", "filtered"},
+		{"Smith", "PROPN", "Smith"},
+		{"Solutions", "NNPS", "Solutions"},
+
+		// Formulae, acronyms and hyphenated words.
+		{"MgSO4", "NOUN", "MgSO4"},
+		{"THF", "NOUN", "THF"},
+		{"pH", "NOUN", "pH"},
+		{"tert-butyl-esters", "NOUN", "tert-butyl-ester"},
+		{"2-nitrobenzaldehydes", "NOUN", "2-nitrobenzaldehyde"},
+		{"4-methoxybenzaldehydes", "", "4-methoxybenzaldehyde"},
+		{"N-oxides", "NOUN", "n-oxide"},
+		{"Ni-MgSO4", "NOUN", "Ni-MgSO4"},
+		{".", "PUNCT", "."},
+	}
+	for _, c := range cases {
+		if obs := lz.Lemma(c.in, c.pos); obs != c.exp {
+			t.Errorf("Expected lemma of %s/%s : %s, observed : %s", c.in, c.pos, c.exp, obs)
+		}
+	}
+}
+
+//
+
+func TestLemma003(t *testing.T) {
+	lz := NewLemmatizer()
+	if obs := lz.Lemma("formulae", "NOUN"); obs != "formula" {
+		t.Errorf("Expected lemma : formula, observed : %s", obs)
+	}
+
+	in := "# Local additions\n" +
+		"mesyl mesylate\n" +
+		"lay lay VERB\n"
+	if err := lz.ReadExceptions(strings.NewReader(in)); err != nil {
+		t.Fatalf("Unable to read exceptions : %s", err.Error())
+	}
+	if obs := lz.Lemma("mesyl", "NOUN"); obs != "mesylate" {
+		t.Errorf("Expected lemma : mesylate, observed : %s", obs)
+	}
+	if obs := lz.Lemma("lay", "VERB"); obs != "lay" {
+		t.Errorf("Expected lemma : lay, observed : %s", obs)
+	}
+
+	if err := lz.AddException("data", "datum", "NOUN"); err != nil {
+		t.Fatalf("Unable to add exception : %s", err.Error())
+	}
+	if obs := lz.Lemma("data", "NOUN"); obs != "datum" {
+		t.Errorf("Expected lemma : datum, observed : %s", obs)
+	}
+	if obs := lz.Lemma("data", "VERB"); obs != "data" {
+		t.Errorf("Expected lemma : data, observed : %s", obs)
+	}
+
+	if err := lz.ReadExceptions(strings.NewReader("x y NOUN z\n")); err == nil {
+		t.Errorf("Expected an error for too many fields")
+	}
+	if err := lz.AddException("x", "y", "PUNCT"); err == nil {
+		t.Errorf("Expected an error for a closed class part of speech")
+	}
+}
+
+//
+
+func TestLemma004(t *testing.T) {
+	lz := NewLemmatizer()
+	in := "The oxides were stirred. The flask was purged and cooled."
+	d, _ := tkz.NewDocument("Lemma004")
+	d.SetInput("P", in)
+	d.Tokenize()
+
+	ws, _ := d.EnsureWords("P")
+	a := &tkz.Annotation{DocumentID: "Lemma004", Section: "P",
+		Begin: ws[1].Begin(), End: ws[1].End(), Entity: ws[1].Text(), Property: "metal oxide"}
+	if err := d.Annotate(a, "LEM"); err != nil {
+		t.Fatalf("Unable to annotate : %s", err.Error())
+	}
+
+	if err := lz.LemmatizeSection(d, "P"); err != nil {
+		t.Fatalf("Unable to lemmatize : %s", err.Error())
+	}
+	exp := []string{"the", "metal oxide", "be", "stir", ".", "the", "flask", "be", "purge", "and", "cool", "."}
+	ws = d.SectionWords("P")
+	if len(ws) != len(exp) {
+		t.Fatalf("Expected word count : %d, observed : %d", len(exp), len(ws))
+	}
+	for i, w := range ws {
+		if w.Lemma() != exp[i] {
+			t.Errorf("Expected lemma of %s : %s, observed : %s", w.Text(), exp[i], w.Lemma())
+		}
+	}
+
+	if err := lz.LemmatizeSection(d, "Q"); err == nil {
+		t.Errorf("Expected an error for an unknown section")
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package ner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Sequence is a sentence of words with their BIO tags, for training
+// and evaluating recognisers.  Its parts of speech are optional.
+type Sequence struct {
+	Words []string
+	POS   []string
+	Tags  []string
+}
+
+// ReadCoNLL reads BIO-tagged sentences in the CoNLL format from the
+// given reader.  Each line holds a word in its first column and its
+// tag in its last, separated by white space.  With three or more
+// columns, the second holds the part of speech.
+//
+// Blank lines separate sentences.  Lines beginning with `-DOCSTART-`
+// are skipped, as are comment lines beginning with `#` before the
+// words of sentences.  Lines such as `#	O` are words.
+func ReadCoNLL(r io.Reader) ([]Sequence, error) {
+	var res []Sequence
+	var cur Sequence
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			if len(cur.Words) > 0 {
+				res = append(res, cur)
+				cur = Sequence{}
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "-DOCSTART-") {
+			continue
+		}
+
+		fs := strings.Fields(line)
+		if len(cur.Words) == 0 && fs[0][0] == '#' && (len(fs) < 2 || !validTag(fs[len(fs)-1])) {
+			continue // A comment, rather than a `#` word
+		}
+		if len(fs) < 2 {
+			return nil, fmt.Errorf("Expected at least 2 columns at line %d : %s", n, line)
+		}
+		tag := fs[len(fs)-1]
+		if !validTag(tag) {
+			return nil, fmt.Errorf("Invalid tag at line %d : %s", n, tag)
+		}
+		cur.Words = append(cur.Words, fs[0])
+		cur.Tags = append(cur.Tags, tag)
+		if len(fs) > 2 {
+			cur.POS = append(cur.POS, fs[1])
+		} else {
+			cur.POS = append(cur.POS, "")
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(cur.Words) > 0 {
+		res = append(res, cur)
+	}
+	return res, nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package ner
+
+import (
+	"strings"
+)
+
+// Tag of words outside entities, and prefixes of the tags of words
+// that begin and continue entities.
+const (
+	Outside      = "O"
+	beginPrefix  = "B-"
+	insidePrefix = "I-"
+)
+
+// validTag answers if the given tag is `O`, or is a `B-` or `I-` tag
+// with a class.
+func validTag(t string) bool {
+	if t == Outside {
+		return true
+	}
+	return len(t) > 2 && (strings.HasPrefix(t, beginPrefix) || strings.HasPrefix(t, insidePrefix))
+}
+
+// Span is an entity in a sequence: the words from `Begin` up to, but
+// not including, `End`, of the given class.
+type Span struct {
+	Begin int
+	End   int
+	Class string
+}
+
+// Spans answers the entities of the given BIO tags.  An `I-` tag that
+// does not continue an entity of its class begins one, as in the
+// CoNLL evaluation.
+func Spans(tags []string) []Span {
+	var res []Span
+	cur := -1 // Index of the open span in `res`
+	for i, t := range tags {
+		switch {
+		case strings.HasPrefix(t, insidePrefix) && cur >= 0 && res[cur].Class == t[2:]:
+			res[cur].End = i + 1
+		case strings.HasPrefix(t, beginPrefix) || strings.HasPrefix(t, insidePrefix):
+			res = append(res, Span{i, i + 1, t[2:]})
+			cur = len(res) - 1
+		default:
+			cur = -1
+		}
+	}
+	return res
+}
+
+// spanTags answers the BIO tags of a sequence of the given length with
+// the given entities.
+func spanTags(spans []Span, n int) []string {
+	res := make([]string, n)
+	for i := range res {
+		res[i] = Outside
+	}
+	for _, s := range spans {
+		res[s.Begin] = beginPrefix + s.Class
+		for i := s.Begin + 1; i < s.End; i++ {
+			res[i] = insidePrefix + s.Class
+		}
+	}
+	return res
+}
+
+// Scores counts the entities found correctly (true positives), found
+// wrongly (false positives) and missed (false negatives).  An entity is
+// found correctly only when both its extent and its class match.
+type Scores struct {
+	TP int
+	FP int
+	FN int
+}
+
+// Add accumulates the scores of the given gold and guessed entities.
+func (s *Scores) Add(gold, guess []Span) {
+	gm := make(map[Span]bool, len(gold))
+	for _, g := range gold {
+		gm[g] = true
+	}
+	for _, g := range guess {
+		if gm[g] {
+			s.TP++
+			delete(gm, g)
+		} else {
+			s.FP++
+		}
+	}
+	s.FN += len(gm)
+}
+
+// Precision answers the fraction of found entities that are correct.
+func (s *Scores) Precision() float64 {
+	if s.TP+s.FP == 0 {
+		return 0
+	}
+	return float64(s.TP) / float64(s.TP+s.FP)
+}
+
+// Recall answers the fraction of entities that are found.
+func (s *Scores) Recall() float64 {
+	if s.TP+s.FN == 0 {
+		return 0
+	}
+	return float64(s.TP) / float64(s.TP+s.FN)
+}
+
+// F1 answers the harmonic mean of precision and recall.
+func (s *Scores) F1() float64 {
+	p, r := s.Precision(), s.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
@@ -0,0 +1,698 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+// Package ner recognises named entities -- chemicals, quantities and
+// the like -- among the words of documents.
+//
+// Its recogniser is a structured perceptron over BIO tags, after
+// Collins (2002).  Tags are chosen for whole sentences at a time by
+// the Viterbi algorithm, over the features of words and the
+// transitions between tags, and the weights are averaged.  The
+// confidence in each tag is its marginal probability, obtained by the
+// forward-backward algorithm over the same scores.
+package ner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/RxnWeaver/RxnMiner/features"
+	"github.com/RxnWeaver/RxnMiner/internal/perceptron"
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+// Pseudo-tag of the position before the beginning of a sentence.
+const startTag = "-START-"
+
+// Recognizer assigns BIO tags and classes to the words of sentences.
+type Recognizer struct {
+	pm   *perceptron.Model
+	dict map[string]*features.Gazetteer // By class
+}
+
+// NewRecognizer creates and initialises an untrained recogniser.
+func NewRecognizer() *Recognizer {
+	r := &Recognizer{}
+	r.pm = perceptron.New(nil)
+	r.dict = make(map[string]*features.Gazetteer)
+	return r
+}
+
+// Classes answers the entity classes known to the recogniser, in
+// sorted order.
+func (r *Recognizer) Classes() []string {
+	var res []string
+	for _, t := range r.pm.Classes() {
+		if strings.HasPrefix(t, beginPrefix) {
+			res = append(res, t[2:])
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// AddDictionary records the given phrases as names of entities of the
+// given class.  Words matching them get dictionary features, which
+// help recognisers generalise beyond their training data.  Phrases
+// are matched as by `features.Gazetteer`.
+//
+// Dictionaries should be complete before training, and are saved with
+// the recogniser.
+func (r *Recognizer) AddDictionary(class string, phrases ...string) {
+	g, ok := r.dict[class]
+	if !ok {
+		g = features.NewGazetteer()
+		r.dict[class] = g
+	}
+	g.Add(phrases...)
+}
+
+// dictTags answers the BIO tags of the given words according to the
+// dictionary.  Words matched by phrases of several classes are tagged
+// with the first class, in sorted order; words not matched have empty
+// tags.
+func (r *Recognizer) dictTags(words []string) []string {
+	res := make([]string, len(words))
+	if len(r.dict) == 0 {
+		return res
+	}
+
+	classes := make([]string, 0, len(r.dict))
+	for c := range r.dict {
+		classes = append(classes, c)
+	}
+	sort.Strings(classes)
+	for _, c := range classes {
+		for i, m := range r.dict[c].Match(words) {
+			if m != "" && res[i] == "" {
+				res[i] = m + "-" + c
+			}
+		}
+	}
+	return res
+}
+
+// Train trains the recogniser over the given sequences, for the given
+// number of iterations.  Sequences are visited in a pseudo-random order
+// that is the same for every run, so that training is reproducible.
+// Any earlier training is discarded.
+func (r *Recognizer) Train(seqs []Sequence, iters int) {
+	classes := make(map[string]struct{})
+	for _, s := range seqs {
+		for _, sp := range Spans(s.Tags) {
+			classes[sp.Class] = struct{}{}
+		}
+	}
+	tags := []string{Outside}
+	for c := range classes {
+		tags = append(tags, beginPrefix+c, insidePrefix+c)
+	}
+	sort.Strings(tags)
+	r.pm = perceptron.New(tags)
+
+	feats := make([][][]string, len(seqs))
+	golds := make([][]string, len(seqs))
+	for k, s := range seqs {
+		feats[k] = r.sentenceFeatures(s.Words, s.POS)
+		golds[k] = spanTags(Spans(s.Tags), len(s.Words))
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for it := 0; it < iters; it++ {
+		for _, k := range rnd.Perm(len(seqs)) {
+			guess := r.newLattice(feats[k]).best()
+			r.update(golds[k], guess, feats[k])
+			r.pm.Tick()
+		}
+	}
+	r.pm.Average()
+}
+
+// update rewards the features and transitions of the given true tags,
+// and penalises those of the given guessed ones, where they differ.
+func (r *Recognizer) update(gold, guess []string, feats [][]string) {
+	gp, pp := startTag, startTag
+	for i := range gold {
+		if gold[i] != guess[i] {
+			r.pm.Update(gold[i], guess[i], feats[i])
+		}
+		if gold[i] != guess[i] || gp != pp {
+			r.pm.Adjust(transFeature(gp), gold[i], 1)
+			r.pm.Adjust(transFeature(pp), guess[i], -1)
+		}
+		gp, pp = gold[i], guess[i]
+	}
+}
+
+// Tag answers the BIO tags of the given words of a sentence, with the
+// confidence in each.  Parts of speech are optional; `pos` may be nil.
+func (r *Recognizer) Tag(words, pos []string) ([]string, []float64) {
+	tags := make([]string, len(words))
+	confs := make([]float64, len(words))
+	if len(words) == 0 || len(r.pm.Classes()) == 0 {
+		for i := range tags {
+			tags[i] = Outside
+		}
+		return tags, confs
+	}
+
+	l := r.newLattice(r.sentenceFeatures(words, pos))
+	path := l.viterbi()
+	ms := l.marginals()
+	for i, j := range path {
+		tags[i] = l.tags[j]
+		confs[i] = ms[i][j]
+	}
+	return tags, confs
+}
+
+// Evaluate answers the entity-level scores of the recogniser over the
+// given sequences.
+func (r *Recognizer) Evaluate(seqs []Sequence) *Scores {
+	s := &Scores{}
+	for _, sq := range seqs {
+		tags, _ := r.Tag(sq.Words, sq.POS)
+		s.Add(Spans(sq.Tags), Spans(tags))
+	}
+	return s
+}
+
+// TagSection tags every word of the given section of the given
+// document, sentence by sentence.  Each word gets its IOB status, its
+// class -- empty outside entities -- and the confidence in them.
+// Parts of speech, when tagged, are used as features.  Words are
+// created for the tokens that are not already part of one; see
+// `Document.EnsureWords`.
+//
+// Tags are chosen token by token, as by `DocumentSequences`, so that
+// words spanning several tokens are tagged as they were learned.  Such
+// a word gets the tag of its first token, and the lowest confidence of
+// its tokens.
+func (r *Recognizer) TagSection(d *tkz.Document, sec string) error {
+	groups, err := d.SentenceWords(sec)
+	if err != nil {
+		return err
+	}
+	toks := d.SectionTokens(sec)
+
+	for _, ws := range groups {
+		idx, poss := sentenceTokens(toks, ws)
+		texts := make([]string, len(idx))
+		at := make(map[int]int, len(idx)) // Position in the sentence, by token
+		for j, i := range idx {
+			texts[j] = toks[i].Text()
+			at[i] = j
+		}
+		tags, confs := r.Tag(texts, poss)
+
+		for _, w := range ws {
+			ts := wordTokens(toks, w)
+			if len(ts) == 0 {
+				continue
+			}
+			t := tags[at[ts[0]]]
+			if t == Outside {
+				w.SetIOB('O')
+				w.SetClass("")
+			} else {
+				w.SetIOB(t[0])
+				w.SetClass(t[2:])
+			}
+			c := confs[at[ts[0]]]
+			for _, i := range ts[1:] {
+				c = math.Min(c, confs[at[i]])
+			}
+			w.SetConfidence(c)
+		}
+	}
+	return nil
+}
+
+// DocumentSequences answers the sequences of the given section of the
+// given document, tagged according to its class annotations.  They can
+// be used to train recognisers.  Every sentence of the section is
+// answered: its tokens outside annotated words are taken to be
+// outside entities.
+//
+// Entities are the words annotated with a class ("CLS"), or, should
+// classes be given, the words of those classes.  Classes set by other
+// recognisers -- of identifiers or references, say -- are otherwise
+// ignored.
+//
+// Sequences are of the non-space tokens of the section, so that
+// entities are learned token by token, as in the CoNLL format.  Tokens
+// have the parts of speech of their words.
+func DocumentSequences(d *tkz.Document, sec string, classes ...string) ([]Sequence, error) {
+	groups, err := d.SentenceWords(sec)
+	if err != nil {
+		return nil, err
+	}
+	toks := d.SectionTokens(sec)
+	entity := entityFilter(d, sec, classes)
+
+	var res []Sequence
+	for _, ws := range groups {
+		idx, poss := sentenceTokens(toks, ws)
+		tags := make(map[int]string, len(idx)) // By token
+		for _, w := range ws {
+			if !entity(w) {
+				continue
+			}
+			tag := beginPrefix + w.Class()
+			for _, i := range wordTokens(toks, w) {
+				if _, ok := tags[i]; !ok {
+					tags[i] = tag
+				}
+				tag = insidePrefix + w.Class()
+			}
+		}
+
+		var s Sequence
+		for j, i := range idx {
+			tag, ok := tags[i]
+			if !ok {
+				tag = Outside
+			}
+			s.Words = append(s.Words, toks[i].Text())
+			s.POS = append(s.POS, poss[j])
+			s.Tags = append(s.Tags, tag)
+		}
+		if len(s.Words) > 0 {
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
+
+// entityFilter answers a function answering if a given word of the
+// given section is an entity: if it has one of the given classes, or,
+// should none be given, if its class is that of an annotation of the
+// same text.
+func entityFilter(d *tkz.Document, sec string, classes []string) func(*tkz.Word) bool {
+	if len(classes) > 0 {
+		set := make(map[string]struct{}, len(classes))
+		for _, c := range classes {
+			set[c] = struct{}{}
+		}
+		return func(w *tkz.Word) bool {
+			_, ok := set[w.Class()]
+			return ok
+		}
+	}
+
+	type key struct {
+		begin, end int
+		class      string
+	}
+	annotated := make(map[key]struct{})
+	for _, a := range d.SectionAnnotations(sec) {
+		annotated[key{a.Begin, a.End, a.Property}] = struct{}{}
+	}
+	return func(w *tkz.Word) bool {
+		if w.Class() == "" {
+			return false
+		}
+		_, ok := annotated[key{w.Begin(), w.End(), w.Class()}]
+		return ok
+	}
+}
+
+// sentenceTokens answers the indices of the non-space tokens of the
+// given words of a sentence, from the given tokens of their section,
+// in text order, with their parts of speech.  A token has the part of
+// speech of the first of its words having one.
+func sentenceTokens(toks []*tkz.TextToken, ws []*tkz.Word) ([]int, []string) {
+	pos := make(map[int]string)
+	for _, w := range ws {
+		for _, i := range wordTokens(toks, w) {
+			if p, ok := pos[i]; !ok || p == "" {
+				pos[i] = w.POS()
+			}
+		}
+	}
+
+	idx := make([]int, 0, len(pos))
+	for i := range pos {
+		idx = append(idx, i)
+	}
+	sort.Ints(idx)
+	poss := make([]string, len(idx))
+	for j, i := range idx {
+		poss[j] = pos[i]
+	}
+	return idx, poss
+}
+
+// wordTokens answers the indices of the non-space tokens of the given
+// word, from the given tokens of its section.
+func wordTokens(toks []*tkz.TextToken, w *tkz.Word) []int {
+	i := sort.Search(len(toks), func(i int) bool { return toks[i].Begin() >= w.Begin() })
+	var res []int
+	for ; i < len(toks) && toks[i].End() <= w.End(); i++ {
+		if toks[i].Type() != tkz.TokSpace {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// jsonRecognizer is the serialised form of a recogniser.
+type jsonRecognizer struct {
+	Dictionary map[string][]string `json:"dictionary,omitempty"` // Phrases by class
+	Model      *perceptron.Model   `json:"model"`
+}
+
+// Save writes the recogniser to the given writer, as JSON.
+func (r *Recognizer) Save(w io.Writer) error {
+	jr := jsonRecognizer{Dictionary: make(map[string][]string, len(r.dict)), Model: r.pm}
+	for c, g := range r.dict {
+		jr.Dictionary[c] = g.Phrases()
+	}
+	return json.NewEncoder(w).Encode(&jr)
+}
+
+// Load reads a recogniser saved by `Save` from the given reader.
+func Load(rd io.Reader) (*Recognizer, error) {
+	var jr jsonRecognizer
+	if err := json.NewDecoder(rd).Decode(&jr); err != nil {
+		return nil, err
+	}
+	if jr.Model == nil {
+		return nil, fmt.Errorf("Invalid recogniser : %s", "no model")
+	}
+
+	r := &Recognizer{pm: jr.Model, dict: make(map[string]*features.Gazetteer)}
+	for c, ps := range jr.Dictionary {
+		r.AddDictionary(c, ps...)
+	}
+	return r, nil
+}
+
+// transFeature answers the feature of the transition from the given
+// tag.
+func transFeature(prev string) string {
+	return "trans=" + prev
+}
+
+// allowed answers if a word with the given tag may follow one with the
+// given previous tag: `I-` tags only continue entities of their class.
+func allowed(prev, tag string) bool {
+	if !strings.HasPrefix(tag, insidePrefix) {
+		return true
+	}
+	c := tag[2:]
+	return prev == beginPrefix+c || prev == insidePrefix+c
+}
+
+// lattice holds the scores of every tag of every word of a sentence.
+type lattice struct {
+	tags  []string
+	emit  [][]float64 // By word, then tag
+	trans [][]float64 // By previous tag, the last being the start, then tag
+}
+
+// newLattice answers the lattice of a sentence whose words have the
+// given features.
+func (r *Recognizer) newLattice(feats [][]string) *lattice {
+	tags := r.pm.Classes()
+	k := len(tags)
+	l := &lattice{tags: tags}
+
+	l.emit = make([][]float64, len(feats))
+	for i, fs := range feats {
+		sc := r.pm.Scores(fs)
+		row := make([]float64, k)
+		for j, t := range tags {
+			row[j] = sc[t]
+		}
+		l.emit[i] = row
+	}
+
+	l.trans = make([][]float64, k+1)
+	for p := 0; p <= k; p++ {
+		prev := startTag
+		if p < k {
+			prev = tags[p]
+		}
+		sc := r.pm.Scores([]string{transFeature(prev)})
+		row := make([]float64, k)
+		for j, t := range tags {
+			if allowed(prev, t) {
+				row[j] = sc[t]
+			} else {
+				row[j] = math.Inf(-1)
+			}
+		}
+		l.trans[p] = row
+	}
+	return l
+}
+
+// best answers the tags of the best path through the lattice.
+func (l *lattice) best() []string {
+	path := l.viterbi()
+	res := make([]string, len(path))
+	for i, j := range path {
+		res[i] = l.tags[j]
+	}
+	return res
+}
+
+// viterbi answers the indices of the tags of the best path through
+// the lattice.  Ties are broken in favour of the tag given first.
+func (l *lattice) viterbi() []int {
+	n, k := len(l.emit), len(l.tags)
+	if n == 0 {
+		return nil
+	}
+
+	score := make([][]float64, n)
+	back := make([][]int, n)
+	for i := 0; i < n; i++ {
+		score[i] = make([]float64, k)
+		back[i] = make([]int, k)
+		for j := 0; j < k; j++ {
+			if i == 0 {
+				score[i][j] = l.trans[k][j] + l.emit[i][j]
+				continue
+			}
+			best, bp := math.Inf(-1), 0
+			for p := 0; p < k; p++ {
+				if s := score[i-1][p] + l.trans[p][j]; s > best {
+					best, bp = s, p
+				}
+			}
+			score[i][j] = best + l.emit[i][j]
+			back[i][j] = bp
+		}
+	}
+
+	path := make([]int, n)
+	best := math.Inf(-1)
+	for j := 0; j < k; j++ {
+		if score[n-1][j] > best {
+			best, path[n-1] = score[n-1][j], j
+		}
+	}
+	for i := n - 1; i > 0; i-- {
+		path[i-1] = back[i][path[i]]
+	}
+	return path
+}
+
+// marginals answers the probability of every tag of every word, with
+// the scores of the lattice taken as log potentials.
+func (l *lattice) marginals() [][]float64 {
+	n, k := len(l.emit), len(l.tags)
+	alpha := make([][]float64, n)
+	beta := make([][]float64, n)
+	buf := make([]float64, k)
+
+	for i := 0; i < n; i++ {
+		alpha[i] = make([]float64, k)
+		for j := 0; j < k; j++ {
+			if i == 0 {
+				alpha[i][j] = l.trans[k][j] + l.emit[i][j]
+				continue
+			}
+			for p := 0; p < k; p++ {
+				buf[p] = alpha[i-1][p] + l.trans[p][j]
+			}
+			alpha[i][j] = logSumExp(buf) + l.emit[i][j]
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		beta[i] = make([]float64, k)
+		if i == n-1 {
+			continue
+		}
+		for j := 0; j < k; j++ {
+			for q := 0; q < k; q++ {
+				buf[q] = l.trans[j][q] + l.emit[i+1][q] + beta[i+1][q]
+			}
+			beta[i][j] = logSumExp(buf)
+		}
+	}
+
+	z := logSumExp(alpha[n-1])
+	res := make([][]float64, n)
+	for i := range res {
+		res[i] = make([]float64, k)
+		for j := range res[i] {
+			res[i][j] = math.Exp(alpha[i][j] + beta[i][j] - z)
+		}
+	}
+	return res
+}
+
+// logSumExp answers the logarithm of the sum of the exponentials of
+// the given values, avoiding overflow.
+func logSumExp(xs []float64) float64 {
+	m := math.Inf(-1)
+	for _, x := range xs {
+		if x > m {
+			m = x
+		}
+	}
+	if math.IsInf(m, -1) {
+		return m
+	}
+	sum := 0.0
+	for _, x := range xs {
+		sum += math.Exp(x - m)
+	}
+	return m + math.Log(sum)
+}
+
+// sentenceFeatures answers the features of every word of the given
+// sentence.
+func (r *Recognizer) sentenceFeatures(words, pos []string) [][]string {
+	dict := r.dictTags(words)
+	res := make([][]string, len(words))
+	for i := range words {
+		p := ""
+		if i < len(pos) {
+			p = pos[i]
+		}
+		res[i] = wordFeatures(words, i, p, dict)
+	}
+	return res
+}
+
+// wordFeatures answers the features of the word at the given index of
+// the given sentence, with the given part of speech and dictionary
+// tags.
+func wordFeatures(words []string, i int, pos string, dict []string) []string {
+	w := words[i]
+	lw := strings.ToLower(w)
+	rs := []rune(lw)
+	fs := make([]string, 0, 40)
+	add := func(name, val string) {
+		fs = append(fs, name+"="+val)
+	}
+
+	fs = append(fs, "bias")
+	add("w", lw)
+	add("shape", features.Shape(w))
+	add("sshape", features.ShortShape(w))
+	for n := 2; n <= 5; n++ {
+		add("suf"+strconv.Itoa(n), features.RuneAffix(rs, n))
+	}
+	for n := 2; n <= 4; n++ {
+		add("pre"+strconv.Itoa(n), features.RuneAffix(rs, -n))
+	}
+
+	var upper, lower, digit, hyphen, greek, bracket, comma bool
+	for _, r := range w {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+			if unicode.Is(unicode.Greek, r) {
+				greek = true
+			}
+		case unicode.IsDigit(r):
+			digit = true
+		case r == '-':
+			hyphen = true
+		case strings.ContainsRune("()[]{}", r):
+			bracket = true
+		case r == ',':
+			comma = true
+		}
+	}
+	first, _ := firstRune(w)
+	switch {
+	case upper && digit:
+		fs = append(fs, "formula") // `MgSO4`, `NaBH4`
+	case upper && !lower && len(rs) > 1:
+		fs = append(fs, "caps") // `THF`, `DMSO`
+	case upper && unicode.IsUpper(first):
+		fs = append(fs, "title")
+	case upper:
+		fs = append(fs, "mixed") // `pH`, `nBuLi`
+	}
+	if digit {
+		fs = append(fs, "digit")
+	}
+	if hyphen {
+		fs = append(fs, "hyphen")
+	}
+	if greek {
+		fs = append(fs, "greek")
+	}
+	if bracket {
+		fs = append(fs, "bracket")
+	}
+	if comma && digit {
+		fs = append(fs, "locants") // `2,3-dimethyl`
+	}
+	if pos != "" {
+		add("pos", pos)
+	}
+	if dict[i] != "" {
+		add("dict", dict[i])
+	}
+
+	for _, off := range []int{-2, -1, 1, 2} {
+		j := i + off
+		name := strconv.Itoa(off)
+		if j < 0 || j >= len(words) {
+			if off == -1 || off == 1 {
+				add("w"+name, "-BOUNDARY-")
+			}
+			continue
+		}
+		add("w"+name, strings.ToLower(words[j]))
+		add("sshape"+name, features.ShortShape(words[j]))
+		if dict[j] != "" {
+			add("dict"+name, dict[j])
+		}
+	}
+	if i > 0 {
+		add("w-1|w", strings.ToLower(words[i-1])+"|"+lw)
+	}
+	if i+1 < len(words) {
+		add("w|w+1", lw+"|"+strings.ToLower(words[i+1]))
+	}
+	return fs
+}
+
+// firstRune answers the first rune of the given string, and if it has
+// one.
+func firstRune(s string) (rune, bool) {
+	for _, r := range s {
+		return r, true
+	}
+	return 0, false
+}
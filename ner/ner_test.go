@@ -0,0 +1,312 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package ner
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+// readSequences answers the sequences of the given test file.
+func readSequences(t *testing.T, fn string) []Sequence {
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatalf("Unable to read file : %s", err.Error())
+	}
+	defer f.Close()
+	seqs, err := ReadCoNLL(f)
+	if err != nil {
+		t.Fatalf("Unable to read sequences : %s", err.Error())
+	}
+	return seqs
+}
+
+// trainChem answers a recogniser trained over the test sequences.
+func trainChem(t *testing.T) *Recognizer {
+	r := NewRecognizer()
+	r.Train(readSequences(t, "testdata/train.bio"), 10)
+	return r
+}
+
+//
+
+func TestReadCoNLL001(t *testing.T) {
+	in := "-DOCSTART- -X- O O\n\n" +
+		"Add NN O\n" +
+		"sodium NN B-CHEM\n" +
+		"hydroxide NN I-CHEM\n" +
+		"\n" +
+		"Stir O\n"
+
+	seqs, err := ReadCoNLL(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Unable to read sequences : %s", err.Error())
+	}
+	if len(seqs) != 2 {
+		t.Fatalf("Expected sequence count : 2, observed : %d", len(seqs))
+	}
+	if strings.Join(seqs[0].Tags, " ") != "O B-CHEM I-CHEM" || seqs[0].POS[1] != "NN" {
+		t.Errorf("Expected tags and part of speech : O B-CHEM I-CHEM, NN, observed : %v, %v", seqs[0].Tags, seqs[0].POS)
+	}
+	if seqs[1].Words[0] != "Stir" || seqs[1].POS[0] != "" {
+		t.Errorf("Expected word without part of speech : Stir, observed : %s, %s", seqs[1].Words[0], seqs[1].POS[0])
+	}
+
+	in = "# sent_id = 1\n" +
+		"#\tO\n" +
+		"5\tO\n" +
+		"#\tO\n" +
+		"\n" +
+		"# text = NaCl\n" +
+		"NaCl B-CHEM\n"
+	seqs, err = ReadCoNLL(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Unable to read sequences : %s", err.Error())
+	}
+	if len(seqs) != 2 || strings.Join(seqs[0].Words, " ") != "# 5 #" || strings.Join(seqs[1].Words, " ") != "NaCl" {
+		t.Errorf("Expected sequences : [# 5 #] [NaCl], observed : %v", seqs)
+	}
+
+	if _, err := ReadCoNLL(strings.NewReader("Add\n")); err == nil {
+		t.Errorf("Expected an error for a line without a tag")
+	}
+	if _, err := ReadCoNLL(strings.NewReader("Add X-CHEM\n")); err == nil {
+		t.Errorf("Expected an error for an invalid tag")
+	}
+}
+
+//
+
+func TestSpans001(t *testing.T) {
+	tags := []string{"B-CHEM", "I-CHEM", "O", "I-QTY", "I-QTY", "B-CHEM", "B-CHEM", "I-QTY"}
+	exp := []Span{{0, 2, "CHEM"}, {3, 5, "QTY"}, {5, 6, "CHEM"}, {6, 7, "CHEM"}, {7, 8, "QTY"}}
+	obs := Spans(tags)
+	if len(obs) != len(exp) {
+		t.Fatalf("Expected span count : %d, observed : %d", len(exp), len(obs))
+	}
+	for i := range exp {
+		if obs[i] != exp[i] {
+			t.Errorf("Expected span : %v, observed : %v", exp[i], obs[i])
+		}
+	}
+
+	s := &Scores{}
+	s.Add(exp, []Span{{0, 2, "CHEM"}, {3, 5, "CHEM"}, {5, 6, "CHEM"}})
+	if s.TP != 2 || s.FP != 1 || s.FN != 3 {
+		t.Errorf("Expected scores : 2, 1, 3, observed : %d, %d, %d", s.TP, s.FP, s.FN)
+	}
+	if math.Abs(s.F1()-0.5) > 1e-9 {
+		t.Errorf("Expected F1 : 0.5, observed : %.4f", s.F1())
+	}
+}
+
+//
+
+func TestRecognizer001(t *testing.T) {
+	r := trainChem(t)
+	if s := r.Evaluate(readSequences(t, "testdata/train.bio")); s.F1() < 0.99 {
+		t.Errorf("Expected training F1 of at least 0.99, observed : %.4f", s.F1())
+	}
+
+	// The held-out sequences name chemicals not seen in training.
+	s := r.Evaluate(readSequences(t, "testdata/test.bio"))
+	if s.Precision() < 0.85 || s.Recall() < 0.85 {
+		t.Errorf("Expected held-out precision and recall of at least 0.85, observed : %.4f, %.4f", s.Precision(), s.Recall())
+	}
+
+	if cs := r.Classes(); strings.Join(cs, " ") != "CHEM QTY" {
+		t.Errorf("Expected classes : CHEM QTY, observed : %v", cs)
+	}
+
+	tags, confs := r.Tag(strings.Fields("The residue was washed with ethyl acetate ."), nil)
+	if strings.Join(tags, " ") != "O O O O O B-CHEM I-CHEM O" {
+		t.Errorf("Expected tags : O O O O O B-CHEM I-CHEM O, observed : %v", tags)
+	}
+	for i, c := range confs {
+		if c <= 0.5 || c > 1+1e-9 {
+			t.Errorf("Expected confidence of %s in (0.5, 1], observed : %.4f", tags[i], c)
+		}
+	}
+}
+
+//
+
+func TestRecognizer002(t *testing.T) {
+	seqs := readSequences(t, "testdata/train.bio")
+	r := NewRecognizer()
+	r.AddDictionary("CHEM", "Sodium bicarbonate", "cesium  carbonate")
+	r.Train(seqs, 5)
+
+	var buf bytes.Buffer
+	if err := r.Save(&buf); err != nil {
+		t.Fatalf("Unable to save recogniser : %s", err.Error())
+	}
+	r2, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Unable to load recogniser : %s", err.Error())
+	}
+
+	words := strings.Fields("A mixture of cesium carbonate ( 2 g ) and sodium bicarbonate was stirred .")
+	t1, c1 := r.Tag(words, nil)
+	t2, c2 := r2.Tag(words, nil)
+	if strings.Join(t1, " ") != strings.Join(t2, " ") {
+		t.Errorf("Expected tags : %v, observed : %v", t1, t2)
+	}
+	for i := range c1 {
+		if math.Abs(c1[i]-c2[i]) > 1e-9 {
+			t.Errorf("Expected confidence : %.4f, observed : %.4f", c1[i], c2[i])
+		}
+	}
+	if d := r2.dictTags(words); d[3] != "B-CHEM" || d[4] != "I-CHEM" || d[10] != "B-CHEM" {
+		t.Errorf("Expected dictionary matches of the reloaded recogniser, observed : %v", d)
+	}
+
+	if _, err := Load(strings.NewReader("{}")); err == nil {
+		t.Errorf("Expected an error for a recogniser without a model")
+	}
+}
+
+//
+
+func TestRecognizer003(t *testing.T) {
+	in := "The residue was washed with ethyl acetate. Then 5 mL of water was added."
+	d, _ := tkz.NewDocument("Recognizer003")
+	d.SetInput("P", in)
+	d.Tokenize()
+	d.AssembleSentences()
+
+	for _, e := range []string{"ethyl acetate", "5 mL", "water"} {
+		cls := "CHEM"
+		if e == "5 mL" {
+			cls = "QTY"
+		}
+		b := strings.Index(in, e)
+		a := &tkz.Annotation{DocumentID: "Recognizer003", Section: "P",
+			Begin: b, End: b + len(e) - 1, Entity: e, Property: cls}
+		if err := d.Annotate(a, "CLS"); err != nil {
+			t.Fatalf("Unable to annotate : %s", err.Error())
+		}
+	}
+
+	b := strings.Index(in, "water")
+	a := &tkz.Annotation{DocumentID: "Recognizer003", Section: "P",
+		Begin: b, End: b + 4, Entity: "water", Property: "NN"}
+	if err := d.Annotate(a, "POS"); err != nil {
+		t.Fatalf("Unable to annotate : %s", err.Error())
+	}
+
+	seqs, err := DocumentSequences(d, "P")
+	if err != nil {
+		t.Fatalf("Unable to read sequences : %s", err.Error())
+	}
+	if len(seqs) != 2 {
+		t.Fatalf("Expected sequence count : 2, observed : %d", len(seqs))
+	}
+	exp := "O O O O O B-CHEM I-CHEM O"
+	if obs := strings.Join(seqs[0].Tags, " "); obs != exp {
+		t.Errorf("Expected tags : %s, observed : %s", exp, obs)
+	}
+	exp = "O B-QTY I-QTY O B-CHEM O O O"
+	if obs := strings.Join(seqs[1].Tags, " "); obs != exp {
+		t.Errorf("Expected tags : %s, observed : %s", exp, obs)
+	}
+	if seqs[1].Words[4] != "water" || seqs[1].POS[4] != "NN" {
+		t.Errorf("Expected entity token with its part of speech : water, NN, observed : %s, %s", seqs[1].Words[4], seqs[1].POS[4])
+	}
+
+	seqs, _ = DocumentSequences(d, "P", "QTY")
+	exp = "O B-QTY I-QTY O O O O O"
+	if obs := strings.Join(seqs[1].Tags, " "); obs != exp {
+		t.Errorf("Expected tags of the given class : %s, observed : %s", exp, obs)
+	}
+
+	r := trainChem(t)
+	d2, _ := tkz.NewDocument("Recognizer003")
+	d2.SetInput("P", in)
+	d2.Tokenize()
+	d2.AssembleSentences()
+	if err := r.TagSection(d2, "P"); err != nil {
+		t.Fatalf("Unable to tag : %s", err.Error())
+	}
+
+	var obs []string
+	for _, w := range d2.SectionWords("P") {
+		if w.Class() != "" {
+			obs = append(obs, string(w.IOB())+"-"+w.Class()+":"+w.Text())
+			if w.Confidence() <= 0 {
+				t.Errorf("Expected a confidence for %s, observed : %.4f", w.Text(), w.Confidence())
+			}
+		} else if w.IOB() != 'O' {
+			t.Errorf("Expected IOB status of %s : O, observed : %c", w.Text(), w.IOB())
+		}
+	}
+	expw := "B-CHEM:ethyl I-CHEM:acetate B-QTY:5 I-QTY:mL B-CHEM:water"
+	if strings.Join(obs, " ") != expw {
+		t.Errorf("Expected entity words : %s, observed : %s", expw, strings.Join(obs, " "))
+	}
+
+	if err := r.TagSection(d2, "Q"); err == nil {
+		t.Errorf("Expected an error for an unknown section")
+	}
+}
+
+//
+
+func TestRecognizer004(t *testing.T) {
+	in := "Ethyl acetate (CAS 141-78-6) was added to the residue. " +
+		"The mixture was washed with ethyl acetate and water."
+	d, _ := tkz.NewDocument("Recognizer004")
+	d.SetInput("P", in)
+	d.Tokenize()
+	d.AssembleSentences()
+
+	for _, e := range []string{"Ethyl acetate", "residue", "ethyl acetate", "water"} {
+		b := strings.Index(in, e)
+		a := &tkz.Annotation{DocumentID: "Recognizer004", Section: "P",
+			Begin: b, End: b + len(e) - 1, Entity: e, Property: "CHEM"}
+		if err := d.Annotate(a, "CLS"); err != nil {
+			t.Fatalf("Unable to annotate : %s", err.Error())
+		}
+	}
+	ids, _ := d.RecognizeIdentifiers("P")
+	if len(ids) != 1 || ids[0].Word().Class() != "CAS_RN" {
+		t.Fatalf("Expected a CAS registry number, observed : %v", ids)
+	}
+
+	seqs, err := DocumentSequences(d, "P")
+	if err != nil {
+		t.Fatalf("Unable to read sequences : %s", err.Error())
+	}
+	for _, s := range seqs {
+		for _, sp := range Spans(s.Tags) {
+			if sp.Class != "CHEM" {
+				t.Errorf("Expected only annotated classes, observed : %s", sp.Class)
+			}
+		}
+	}
+
+	// Tagging the words of the training document answers its entities.
+	r := NewRecognizer()
+	r.Train(seqs, 10)
+	if err := r.TagSection(d, "P"); err != nil {
+		t.Fatalf("Unable to tag : %s", err.Error())
+	}
+	var obs []string
+	for _, w := range d.SectionWords("P") {
+		if w.Class() != "" {
+			obs = append(obs, string(w.IOB())+"-"+w.Class()+":"+w.Text())
+		}
+	}
+	exp := "B-CHEM:Ethyl acetate B-CHEM:residue B-CHEM:ethyl acetate B-CHEM:water"
+	if strings.Join(obs, " ") != exp {
+		t.Errorf("Expected entity words : %s, observed : %s", exp, strings.Join(obs, " "))
+	}
+}
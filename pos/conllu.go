@@ -0,0 +1,72 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package pos
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Columns of CoNLL-U files that hold part of speech tags.
+const (
+	UPOS = 3 // Universal part of speech tags
+	XPOS = 4 // Language-specific part of speech tags
+)
+
+// TaggedSentence is a sequence of words with their part of speech
+// tags, for training and evaluating taggers.
+type TaggedSentence struct {
+	Words []string
+	Tags  []string
+}
+
+// ReadCoNLLU reads the sentences of a CoNLL-U file from the given
+// reader, with the tags in the given column: `UPOS` or `XPOS`.
+//
+// Comments are skipped, as are the ranges of multi-word tokens and
+// empty nodes; the syntactic words are read.
+func ReadCoNLLU(r io.Reader, col int) ([]TaggedSentence, error) {
+	if col != UPOS && col != XPOS {
+		return nil, fmt.Errorf("Invalid tag column : %d", col)
+	}
+
+	var res []TaggedSentence
+	var cur TaggedSentence
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			if len(cur.Words) > 0 {
+				res = append(res, cur)
+				cur = TaggedSentence{}
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fs := strings.Split(line, "\t")
+		if len(fs) != 10 {
+			return nil, fmt.Errorf("Expected 10 columns at line %d : %s", n, line)
+		}
+		if strings.ContainsAny(fs[0], "-.") {
+			continue
+		}
+		cur.Words = append(cur.Words, fs[1])
+		cur.Tags = append(cur.Tags, fs[col])
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(cur.Words) > 0 {
+		res = append(res, cur)
+	}
+	return res, nil
+}
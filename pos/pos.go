@@ -0,0 +1,312 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+// Package pos tags the words of documents with their parts of speech.
+//
+// Its tagger is a greedy, left-to-right averaged perceptron, after
+// Collins (2002), with features suited to chemical text: affixes,
+// shapes, digits, hyphens and the like.  It can be trained from
+// documents carrying part of speech annotations, or from CoNLL-U files.
+package pos
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/RxnWeaver/RxnMiner/features"
+	"github.com/RxnWeaver/RxnMiner/internal/perceptron"
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+// Words that are at least this frequent in training data, and which
+// have the same tag at least this often, are tagged from a dictionary.
+const (
+	dictMinFreq  = 20
+	dictMinRatio = 0.97
+)
+
+// Pseudo-tags of the positions before the beginning of a sentence.
+const (
+	start1 = "-START-"
+	start2 = "-START2-"
+)
+
+// Tagger assigns parts of speech to the words of sentences.
+type Tagger struct {
+	pm      *perceptron.Model
+	tagdict map[string]string // Unambiguous frequent words
+}
+
+// NewTagger creates and initialises an untrained tagger.
+func NewTagger() *Tagger {
+	tg := &Tagger{}
+	tg.pm = perceptron.New(nil)
+	tg.tagdict = make(map[string]string)
+	return tg
+}
+
+// Tags answers the tags known to the tagger, in sorted order.
+func (tg *Tagger) Tags() []string {
+	return tg.pm.Classes()
+}
+
+// Train trains the tagger over the given sentences, for the given
+// number of iterations.  Sentences are visited in a pseudo-random
+// order that is the same for every run, so that training is
+// reproducible.  Any earlier training is discarded.
+func (tg *Tagger) Train(sents []TaggedSentence, iters int) {
+	counts := make(map[string]map[string]int)
+	tagSet := make(map[string]struct{})
+	for _, s := range sents {
+		for i, w := range s.Words {
+			t := s.Tags[i]
+			tagSet[t] = struct{}{}
+			if counts[w] == nil {
+				counts[w] = make(map[string]int)
+			}
+			counts[w][t]++
+		}
+	}
+
+	tg.tagdict = make(map[string]string)
+	for w, tc := range counts {
+		n, best, bestN := 0, "", 0
+		for t, c := range tc {
+			n += c
+			if c > bestN || (c == bestN && t < best) {
+				best, bestN = t, c
+			}
+		}
+		if n >= dictMinFreq && float64(bestN)/float64(n) >= dictMinRatio {
+			tg.tagdict[w] = best
+		}
+	}
+
+	tags := make([]string, 0, len(tagSet))
+	for t := range tagSet {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	tg.pm = perceptron.New(tags)
+
+	rnd := rand.New(rand.NewSource(1))
+	for it := 0; it < iters; it++ {
+		for _, k := range rnd.Perm(len(sents)) {
+			s := sents[k]
+			p1, p2 := start1, start2
+			for i, w := range s.Words {
+				guess, ok := tg.tagdict[w]
+				if !ok {
+					feats := wordFeatures(s.Words, i, p1, p2)
+					guess = tg.pm.Predict(feats)
+					tg.pm.Update(s.Tags[i], guess, feats)
+				}
+				tg.pm.Tick()
+				p2, p1 = p1, guess
+			}
+		}
+	}
+	tg.pm.Average()
+}
+
+// Tag answers the tags of the given words of a sentence.
+func (tg *Tagger) Tag(words []string) []string {
+	res := make([]string, len(words))
+	p1, p2 := start1, start2
+	for i, w := range words {
+		t, ok := tg.tagdict[w]
+		if !ok {
+			t = tg.pm.Predict(wordFeatures(words, i, p1, p2))
+		}
+		res[i] = t
+		p2, p1 = p1, t
+	}
+	return res
+}
+
+// Evaluate answers the fraction of the words of the given sentences
+// that the tagger tags correctly.
+func (tg *Tagger) Evaluate(sents []TaggedSentence) float64 {
+	n, ok := 0, 0
+	for _, s := range sents {
+		for i, t := range tg.Tag(s.Words) {
+			n++
+			if t == s.Tags[i] {
+				ok++
+			}
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return float64(ok) / float64(n)
+}
+
+// TagSection tags every word of the given section of the given
+// document, sentence by sentence.  Words are created for the tokens
+// that are not already part of one; see `Document.EnsureWords`.
+func (tg *Tagger) TagSection(d *tkz.Document, sec string) error {
+	groups, err := d.SentenceWords(sec)
+	if err != nil {
+		return err
+	}
+
+	for _, ws := range groups {
+		texts := make([]string, len(ws))
+		for i, w := range ws {
+			texts[i] = w.Text()
+		}
+		for i, t := range tg.Tag(texts) {
+			ws[i].SetPOS(t)
+		}
+	}
+	return nil
+}
+
+// DocumentSentences answers the sentences of the given section of the
+// given document, all of whose words carry part of speech annotations.
+// They can be used to train taggers.
+func DocumentSentences(d *tkz.Document, sec string) ([]TaggedSentence, error) {
+	groups, err := d.SentenceWords(sec)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []TaggedSentence
+outer:
+	for _, ws := range groups {
+		var s TaggedSentence
+		for _, w := range ws {
+			if w.POS() == "" {
+				continue outer
+			}
+			s.Words = append(s.Words, w.Text())
+			s.Tags = append(s.Tags, w.POS())
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+// jsonTagger is the serialised form of a tagger.
+type jsonTagger struct {
+	TagDict map[string]string `json:"tagdict"`
+	Model   *perceptron.Model `json:"model"`
+}
+
+// Save writes the tagger to the given writer, as JSON.
+func (tg *Tagger) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(&jsonTagger{tg.tagdict, tg.pm})
+}
+
+// Load reads a tagger saved by `Save` from the given reader.
+func Load(r io.Reader) (*Tagger, error) {
+	var jt jsonTagger
+	if err := json.NewDecoder(r).Decode(&jt); err != nil {
+		return nil, err
+	}
+	if jt.Model == nil {
+		return nil, fmt.Errorf("Invalid tagger : %s", "no model")
+	}
+
+	tg := &Tagger{pm: jt.Model, tagdict: jt.TagDict}
+	if tg.tagdict == nil {
+		tg.tagdict = make(map[string]string)
+	}
+	return tg, nil
+}
+
+// isGreek answers if the given rune is a Greek letter: `α`, `β`, etc.
+func isGreek(r rune) bool {
+	return unicode.Is(unicode.Greek, r)
+}
+
+// wordFeatures answers the features of the word at the given index of the
+// given sentence, given the tags of the two preceding words.
+func wordFeatures(words []string, i int, p1, p2 string) []string {
+	w := words[i]
+	lw := strings.ToLower(w)
+	rs := []rune(lw)
+	fs := make([]string, 0, 32)
+	add := func(name, val string) {
+		fs = append(fs, name+"="+val)
+	}
+
+	fs = append(fs, "bias")
+	add("w", lw)
+	add("suf1", features.RuneAffix(rs, 1))
+	add("suf2", features.RuneAffix(rs, 2))
+	add("suf3", features.RuneAffix(rs, 3))
+	add("suf4", features.RuneAffix(rs, 4))
+	add("pre1", features.RuneAffix(rs, -1))
+	add("pre2", features.RuneAffix(rs, -2))
+	add("shape", features.Shape(w))
+
+	var upper, digit, hyphen, greek, other bool
+	for _, r := range w {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case r == '-':
+			hyphen = true
+		case isGreek(r):
+			greek = true
+		case !unicode.IsLetter(r):
+			other = true
+		}
+	}
+	if upper && digit {
+		fs = append(fs, "formula") // `MgSO4`, `NaBH4`
+	}
+	if hyphen {
+		fs = append(fs, "hyphen")
+	}
+	if digit {
+		fs = append(fs, "digit")
+	}
+	if greek {
+		fs = append(fs, "greek")
+	}
+	if other && !digit && !upper && len(rs) == 1 {
+		fs = append(fs, "punct")
+	}
+	if i == 0 {
+		fs = append(fs, "first")
+	}
+
+	add("p1", p1)
+	add("p2", p2)
+	add("p1+p2", p1+"|"+p2)
+	add("p1+w", p1+"|"+lw)
+
+	if i > 0 {
+		pw := []rune(strings.ToLower(words[i-1]))
+		add("p1w", string(pw))
+		add("p1suf3", features.RuneAffix(pw, 3))
+	} else {
+		add("p1w", start1)
+	}
+	if i > 1 {
+		add("p2w", strings.ToLower(words[i-2]))
+	}
+	if i+1 < len(words) {
+		nw := []rune(strings.ToLower(words[i+1]))
+		add("n1w", string(nw))
+		add("n1suf3", features.RuneAffix(nw, 3))
+	} else {
+		add("n1w", "-END-")
+	}
+	if i+2 < len(words) {
+		add("n2w", strings.ToLower(words[i+2]))
+	}
+	return fs
+}
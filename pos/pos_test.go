@@ -0,0 +1,186 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package pos
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+// trainChem answers a tagger trained over the test sentences.
+func trainChem(t *testing.T) (*Tagger, []TaggedSentence) {
+	f, err := os.Open("testdata/chem.conllu")
+	if err != nil {
+		t.Fatalf("Unable to read file : %s", err.Error())
+	}
+	defer f.Close()
+	sents, err := ReadCoNLLU(f, UPOS)
+	if err != nil {
+		t.Fatalf("Unable to read sentences : %s", err.Error())
+	}
+
+	tg := NewTagger()
+	tg.Train(sents, 10)
+	return tg, sents
+}
+
+//
+
+func TestReadCoNLLU001(t *testing.T) {
+	in := "# sent_id = 1\n" +
+		"1-2\tdel\t_\t_\t_\t_\t_\t_\t_\t_\n" +
+		"1\tde\tde\tADP\tPREP\t_\t_\t_\t_\t_\n" +
+		"2\tel\tel\tDET\tART\t_\t_\t_\t_\t_\n" +
+		"2.1\tx\t_\t_\t_\t_\t_\t_\t_\t_\n" +
+		"3\tagua\tagua\tNOUN\tNC\t_\t_\t_\t_\t_\n" +
+		"\n" +
+		"1\tSí\tsí\tINTJ\tI\t_\t_\t_\t_\t_\n"
+
+	sents, err := ReadCoNLLU(strings.NewReader(in), XPOS)
+	if err != nil {
+		t.Fatalf("Unable to read sentences : %s", err.Error())
+	}
+	if len(sents) != 2 {
+		t.Fatalf("Expected sentence count : 2, observed : %d", len(sents))
+	}
+	if strings.Join(sents[0].Words, " ") != "de el agua" || strings.Join(sents[0].Tags, " ") != "PREP ART NC" {
+		t.Errorf("Expected words and tags : de el agua, PREP ART NC, observed : %v, %v", sents[0].Words, sents[0].Tags)
+	}
+	if sents[1].Words[0] != "Sí" || sents[1].Tags[0] != "I" {
+		t.Errorf("Expected word and tag : Sí, I, observed : %s, %s", sents[1].Words[0], sents[1].Tags[0])
+	}
+
+	if _, err := ReadCoNLLU(strings.NewReader("1\tde\tADP\n"), UPOS); err == nil {
+		t.Errorf("Expected an error for a line with too few columns")
+	}
+	if _, err := ReadCoNLLU(strings.NewReader(in), 5); err == nil {
+		t.Errorf("Expected an error for an invalid tag column")
+	}
+}
+
+//
+
+func TestTagger001(t *testing.T) {
+	tg, sents := trainChem(t)
+	if acc := tg.Evaluate(sents); acc < 0.98 {
+		t.Errorf("Expected training accuracy of at least 0.98, observed : %.4f", acc)
+	}
+
+	cases := []struct {
+		in  string
+		exp string
+	}{
+		{"The filtrate was stirred for 3 h .", "DET NOUN AUX VERB ADP NUM NOUN PUNCT"},
+		{"The residue was washed with cold ethanol .", "DET NOUN AUX VERB ADP ADJ NOUN PUNCT"},
+		{"The solvent was evaporated .", "DET NOUN AUX VERB PUNCT"},
+	}
+	for _, c := range cases {
+		obs := strings.Join(tg.Tag(strings.Fields(c.in)), " ")
+		if obs != c.exp {
+			t.Errorf("Expected tags for %q : %s, observed : %s", c.in, c.exp, obs)
+		}
+	}
+}
+
+//
+
+func TestTagger002(t *testing.T) {
+	tg, sents := trainChem(t)
+	var buf bytes.Buffer
+	if err := tg.Save(&buf); err != nil {
+		t.Fatalf("Unable to save tagger : %s", err.Error())
+	}
+	tg2, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Unable to load tagger : %s", err.Error())
+	}
+
+	for _, s := range sents {
+		exp, obs := strings.Join(tg.Tag(s.Words), " "), strings.Join(tg2.Tag(s.Words), " ")
+		if obs != exp {
+			t.Errorf("Expected tags : %s, observed : %s", exp, obs)
+		}
+	}
+	if len(tg2.Tags()) != len(tg.Tags()) {
+		t.Errorf("Expected tag count : %d, observed : %d", len(tg.Tags()), len(tg2.Tags()))
+	}
+
+	if _, err := Load(strings.NewReader("{}")); err == nil {
+		t.Errorf("Expected an error for a tagger without a model")
+	}
+}
+
+//
+
+func TestTagger003(t *testing.T) {
+	tg, _ := trainChem(t)
+	in := "The mixture was stirred for 2 h. The solution was filtered."
+	d, _ := tkz.NewDocument("Tagger003")
+	d.SetInput("P", in)
+	d.Tokenize()
+	d.AssembleSentences()
+	if err := tg.TagSection(d, "P"); err != nil {
+		t.Fatalf("Unable to tag : %s", err.Error())
+	}
+
+	ws := d.SectionWords("P")
+	exp := []string{"DET", "NOUN", "AUX", "VERB", "ADP", "NUM", "NOUN", "PUNCT", "DET", "NOUN", "AUX", "VERB", "PUNCT"}
+	if len(ws) != len(exp) {
+		t.Fatalf("Expected word count : %d, observed : %d", len(exp), len(ws))
+	}
+	for i, w := range ws {
+		if w.POS() != exp[i] {
+			t.Errorf("Expected tag for %s : %s, observed : %s", w.Text(), exp[i], w.POS())
+		}
+	}
+
+	sents, err := DocumentSentences(d, "P")
+	if err != nil {
+		t.Fatalf("Unable to read sentences : %s", err.Error())
+	}
+	if len(sents) != 2 || len(sents[0].Words) != 8 || sents[1].Tags[3] != "VERB" {
+		t.Errorf("Expected 2 tagged sentences of the document, observed : %v", sents)
+	}
+
+	if err := tg.TagSection(d, "Q"); err == nil {
+		t.Errorf("Expected an error for an unknown section")
+	}
+}
+
+//
+
+func TestTagger004(t *testing.T) {
+	in := "Raamu is a good boy. He plays."
+	d, _ := tkz.NewDocument("Tagger004")
+	d.SetInput("P", in)
+	d.Tokenize()
+	d.AssembleSentences()
+
+	tags := []string{"PROPN", "AUX", "DET", "ADJ", "NOUN", "PUNCT"}
+	ws, _ := d.EnsureWords("P")
+	for i, tag := range tags {
+		a := &tkz.Annotation{DocumentID: "Tagger004", Section: "P",
+			Begin: ws[i].Begin(), End: ws[i].End(), Entity: ws[i].Text(), Property: tag}
+		if err := d.Annotate(a, "POS"); err != nil {
+			t.Fatalf("Unable to annotate : %s", err.Error())
+		}
+	}
+
+	sents, err := DocumentSentences(d, "P")
+	if err != nil {
+		t.Fatalf("Unable to read sentences : %s", err.Error())
+	}
+	if len(sents) != 1 {
+		t.Fatalf("Expected only the fully annotated sentence, observed : %d", len(sents))
+	}
+	if strings.Join(sents[0].Tags, " ") != strings.Join(tags, " ") {
+		t.Errorf("Expected tags : %v, observed : %v", tags, sents[0].Tags)
+	}
+}
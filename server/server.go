@@ -0,0 +1,354 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+// Package server exposes tokenization, sentence assembly and
+// annotation of documents as an HTTP/JSON service.
+//
+// All endpoints that process documents accept a `POST` of a request
+// of the following form, and answer one result per document, in
+// order.
+//
+//	{
+//	  "technical": false,
+//	  "language": "de",
+//	  "segment": false,
+//	  "abbreviations": "chemistry",
+//	  "extra_abbreviations": ["approx"],
+//	  "stages": ["tokenize", "sentences", "identifiers"],
+//	  "documents": [
+//	    {
+//	      "id": "D1",
+//	      "sections": [{"name": "T", "text": "..."}],
+//	      "annotations": [{"section": "T", "begin": 0, "end": 6,
+//	                       "entity": "Benzene", "property": "NN", "type": "POS"}]
+//	    }
+//	  ]
+//	}
+//
+// `stages` is honoured only by `/v1/process`.  Annotations are applied
+// by `/v1/annotate` and `/v1/process`.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+// Config controls the limits and the abbreviation sets of a server.
+type Config struct {
+	MaxBodyBytes int64                     // Defaults to 1 MiB
+	MaxDocuments int                       // Per request; defaults to 100
+	AbbrevSets   map[string]*tkz.AbbrevSet // In addition to `default` and `chemistry`
+}
+
+// Section is the JSON form of a section of an input document.
+type Section struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+// Annotation is the JSON form of an annotation of an input document.
+// Its type is one of `POS`, `LEM` or `CLS`.
+type Annotation struct {
+	Section  string `json:"section"`
+	Begin    int    `json:"begin"`
+	End      int    `json:"end"`
+	Entity   string `json:"entity"`
+	Property string `json:"property"`
+	Type     string `json:"type"`
+}
+
+// Document is the JSON form of an input document.
+type Document struct {
+	ID          string       `json:"id"`
+	Sections    []Section    `json:"sections"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+}
+
+// Request is the JSON form of a request to process documents.
+type Request struct {
+	Technical          bool       `json:"technical"`
+	Language           string     `json:"language,omitempty"`
+	Segment            bool       `json:"segment,omitempty"` // Subdivide CJK runs into words?
+	Abbreviations      string     `json:"abbreviations,omitempty"`
+	ExtraAbbreviations []string   `json:"extra_abbreviations,omitempty"`
+	Stages             []string   `json:"stages,omitempty"`
+	Documents          []Document `json:"documents"`
+}
+
+// Result is the JSON form of the outcome of processing one document.
+type Result struct {
+	ID       string        `json:"id"`
+	Error    string        `json:"error,omitempty"`
+	Document *tkz.Document `json:"document,omitempty"`
+}
+
+// Response is the JSON form of the response to a request to process
+// documents.
+type Response struct {
+	Results []Result `json:"results"`
+}
+
+// endpoint describes the processing performed by an endpoint.
+type endpoint struct {
+	stages   []string
+	annotate bool
+	custom   bool // Honour the stages given in the request?
+}
+
+var endpoints = map[string]endpoint{
+	"/v1/tokenize":  {[]string{"tokenize"}, false, false},
+	"/v1/sentences": {[]string{"tokenize", "sentences"}, false, false},
+	"/v1/annotate":  {[]string{"tokenize", "sentences"}, true, false},
+	"/v1/process":   {[]string{"tokenize", "sentences"}, true, true},
+}
+
+// counters holds the counters of a server.
+type counters struct {
+	mu        sync.Mutex
+	requests  map[string]int64
+	errors    map[string]int64
+	documents int64
+	failed    int64
+	seconds   float64
+}
+
+// Server is an `http.Handler` that serves the endpoints.
+type Server struct {
+	cfg    Config
+	abbrev map[string]*tkz.AbbrevSet
+	mux    *http.ServeMux
+	start  time.Time
+	m      counters
+}
+
+// NewServer creates a server with the given configuration.
+func NewServer(cfg Config) *Server {
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 1 << 20
+	}
+	if cfg.MaxDocuments <= 0 {
+		cfg.MaxDocuments = 100
+	}
+
+	s := &Server{cfg: cfg, mux: http.NewServeMux(), start: time.Now()}
+	s.abbrev = map[string]*tkz.AbbrevSet{
+		"default":   tkz.DefaultAbbrevs(),
+		"chemistry": tkz.ChemistryAbbrevs(),
+	}
+	for name, a := range cfg.AbbrevSets {
+		s.abbrev[name] = a
+	}
+	s.m.requests = make(map[string]int64)
+	s.m.errors = make(map[string]int64)
+
+	for path, ep := range endpoints {
+		s.mux.Handle(path, s.handler(path, ep))
+	}
+	s.mux.HandleFunc("/healthz", s.health)
+	s.mux.HandleFunc("/metrics", s.metrics)
+	return s
+}
+
+// ServeHTTP dispatches the given request to the appropriate endpoint.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handler answers the handler of the given document processing
+// endpoint.
+func (s *Server) handler(path string, ep endpoint) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t0 := time.Now()
+		status, resp, err := s.serve(w, r, ep)
+		s.m.mu.Lock()
+		s.m.requests[path]++
+		if err != nil {
+			s.m.errors[path]++
+		} else {
+			for _, res := range resp.Results {
+				s.m.documents++
+				if res.Error != "" {
+					s.m.failed++
+				}
+			}
+		}
+		s.m.seconds += time.Since(t0).Seconds()
+		s.m.mu.Unlock()
+
+		if err != nil {
+			writeJSON(w, status, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, status, resp)
+	})
+}
+
+// serve decodes and validates the given request, and processes its
+// documents.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, ep endpoint) (int, *Response, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed, nil, fmt.Errorf("Method not allowed : %s", r.Method)
+	}
+
+	var req Request
+	body := http.MaxBytesReader(w, r.Body, s.cfg.MaxBodyBytes)
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		if err.Error() == "http: request body too large" {
+			return http.StatusRequestEntityTooLarge, nil, fmt.Errorf("Request body exceeds %d bytes", s.cfg.MaxBodyBytes)
+		}
+		return http.StatusBadRequest, nil, fmt.Errorf("Malformed request : %s", err.Error())
+	}
+	if len(req.Documents) == 0 {
+		return http.StatusBadRequest, nil, fmt.Errorf("No documents given")
+	}
+	if len(req.Documents) > s.cfg.MaxDocuments {
+		return http.StatusRequestEntityTooLarge, nil, fmt.Errorf("Too many documents : %d > %d", len(req.Documents), s.cfg.MaxDocuments)
+	}
+
+	var abbrevs *tkz.AbbrevSet
+	if req.Abbreviations != "" {
+		a, ok := s.abbrev[req.Abbreviations]
+		if !ok {
+			return http.StatusBadRequest, nil, fmt.Errorf("Unknown abbreviation set : %s", req.Abbreviations)
+		}
+		abbrevs = a
+	}
+	if len(req.ExtraAbbreviations) > 0 {
+		if abbrevs == nil {
+			abbrevs = tkz.DefaultAbbrevs()
+		}
+		abbrevs = abbrevs.With(req.ExtraAbbreviations...)
+	}
+
+	var lang *tkz.LanguagePack
+	if req.Language != "" {
+		if lang = tkz.Language(req.Language); lang == nil {
+			return http.StatusBadRequest, nil, fmt.Errorf("Unknown language : %s", req.Language)
+		}
+	}
+
+	stages := ep.stages
+	if ep.custom && len(req.Stages) > 0 {
+		stages = req.Stages
+	}
+	pl, err := tkz.NewPipelineFromNames(stages...)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	resp := &Response{Results: make([]Result, 0, len(req.Documents))}
+	for _, doc := range req.Documents {
+		resp.Results = append(resp.Results, process(doc, &req, lang, abbrevs, pl, ep.annotate))
+	}
+	return http.StatusOK, resp, nil
+}
+
+// segmenter segments CJK runs using the bundled lexicon.  It is safe
+// for concurrent use.
+var segmenter = tkz.NewLexiconSegmenter(tkz.DefaultLexicon())
+
+// process builds a document from its JSON form, and runs it through
+// the given pipeline.  Failures are reported in the result.
+func process(in Document, req *Request, lang *tkz.LanguagePack, abbrevs *tkz.AbbrevSet, pl *tkz.Pipeline, annotate bool) Result {
+	res := Result{ID: in.ID}
+	var doc *tkz.Document
+	var err error
+	if req.Technical {
+		doc, err = tkz.NewTechnicalDocument(in.ID)
+	} else {
+		doc, err = tkz.NewDocument(in.ID)
+	}
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if lang != nil {
+		doc.SetLanguage(lang)
+	}
+	if req.Segment {
+		doc.SetSegmenter(segmenter)
+	}
+	if abbrevs != nil {
+		doc.SetAbbreviations(abbrevs)
+	}
+	for _, sec := range in.Sections {
+		if err := doc.SetInput(sec.Name, sec.Text); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+	}
+
+	if _, err := pl.Run(doc); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if annotate {
+		for _, a := range in.Annotations {
+			anno := &tkz.Annotation{DocumentID: in.ID, Section: a.Section, Begin: a.Begin, End: a.End,
+				Entity: a.Entity, Property: a.Property}
+			if err := doc.Annotate(anno, a.Type); err != nil {
+				res.Error = err.Error()
+				return res
+			}
+		}
+	}
+
+	res.Document = doc
+	return res
+}
+
+// health answers the liveness of the server.
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+		"uptime": time.Since(s.start).Seconds(),
+	})
+}
+
+// metrics answers the counters of the server, in the Prometheus text
+// exposition format.
+func (s *Server) metrics(w http.ResponseWriter, r *http.Request) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	paths := make([]string, 0, len(endpoints))
+	for path := range endpoints {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	fmt.Fprintln(w, "# TYPE rxnminer_requests_total counter")
+	for _, path := range paths {
+		fmt.Fprintf(w, "rxnminer_requests_total{endpoint=%q} %d\n", path, s.m.requests[path])
+	}
+	fmt.Fprintln(w, "# TYPE rxnminer_request_errors_total counter")
+	for _, path := range paths {
+		fmt.Fprintf(w, "rxnminer_request_errors_total{endpoint=%q} %d\n", path, s.m.errors[path])
+	}
+	fmt.Fprintln(w, "# TYPE rxnminer_documents_total counter")
+	fmt.Fprintf(w, "rxnminer_documents_total %d\n", s.m.documents)
+	fmt.Fprintln(w, "# TYPE rxnminer_document_failures_total counter")
+	fmt.Fprintf(w, "rxnminer_document_failures_total %d\n", s.m.failed)
+	fmt.Fprintln(w, "# TYPE rxnminer_request_seconds_total counter")
+	fmt.Fprintf(w, "rxnminer_request_seconds_total %g\n", s.m.seconds)
+	fmt.Fprintln(w, "# TYPE rxnminer_uptime_seconds gauge")
+	fmt.Fprintf(w, "rxnminer_uptime_seconds %g\n", time.Since(s.start).Seconds())
+}
+
+// writeJSON writes the given value as the JSON body of a response with
+// the given status.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tkz "github.com/RxnWeaver/RxnMiner/tokenizer"
+)
+
+// jsonResponse mirrors the JSON form of responses, for decoding.
+type jsonResponse struct {
+	Error   string `json:"error"`
+	Results []struct {
+		ID       string `json:"id"`
+		Error    string `json:"error"`
+		Document struct {
+			ID       string `json:"id"`
+			Sections []struct {
+				Name   string `json:"name"`
+				Offset int    `json:"offset"`
+				Tokens []struct {
+					Text string `json:"text"`
+					Type string `json:"type"`
+				} `json:"tokens"`
+				Sentences []struct {
+					Text string `json:"text"`
+				} `json:"sentences"`
+				Words []struct {
+					Text  string `json:"text"`
+					POS   string `json:"pos"`
+					Class string `json:"class"`
+				} `json:"words"`
+			} `json:"sections"`
+		} `json:"document"`
+	} `json:"results"`
+}
+
+func post(t *testing.T, ts *httptest.Server, path, body string) (int, *jsonResponse) {
+	resp, err := http.Post(ts.URL+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Request failed : %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	res := &jsonResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		t.Fatalf("Malformed response : %s", err.Error())
+	}
+	return resp.StatusCode, res
+}
+
+func TestSentences001(t *testing.T) {
+	ts := httptest.NewServer(NewServer(Config{}))
+	defer ts.Close()
+
+	body := `{"documents": [
+		{"id": "D1", "sections": [{"name": "T", "text": "A title"}, {"name": "A", "text": "See cf. Table 2 for data. It was cooled."}]},
+		{"id": "", "sections": [{"name": "T", "text": "No identifier."}]}
+	]}`
+	status, res := post(t, ts, "/v1/sentences", body)
+	if status != http.StatusOK || len(res.Results) != 2 {
+		t.Fatalf("Expected status 200 with 2 results, observed : %d, %d", status, len(res.Results))
+	}
+	if res.Results[1].Error == "" {
+		t.Errorf("Expected an error for a document without identifier")
+	}
+	secs := res.Results[0].Document.Sections
+	if len(secs) != 2 || secs[0].Name != "T" || secs[1].Name != "A" || secs[1].Offset != 9 {
+		t.Fatalf("Unexpected sections : %v", secs)
+	}
+	if len(secs[1].Sentences) != 3 {
+		t.Errorf("Expected sentence count : 3, observed : %d", len(secs[1].Sentences))
+	}
+	if secs[1].Tokens[7].Text != "2" || secs[1].Tokens[7].Type != "TokMayBeWord" {
+		t.Errorf("Unexpected token : %v", secs[1].Tokens[7])
+	}
+
+	body = strings.Replace(body, `"documents"`, `"abbreviations": "chemistry", "documents"`, 1)
+	_, res = post(t, ts, "/v1/sentences", body)
+	if c := len(res.Results[0].Document.Sections[1].Sentences); c != 2 {
+		t.Errorf("Expected sentence count with chemistry abbreviations : 2, observed : %d", c)
+	}
+
+	body = `{"language": "de", "documents": [{"id": "D1", "sections": [{"name": "A", "text": "Am 3. Mai, z. B. um 4 Uhr. Gut."}]}]}`
+	_, res = post(t, ts, "/v1/sentences", body)
+	if c := len(res.Results[0].Document.Sections[0].Sentences); c != 2 {
+		t.Errorf("Expected sentence count in German : 2, observed : %d", c)
+	}
+
+	body = `{"language": "zh", "segment": true, "documents": [{"id": "D1", "sections": [{"name": "A", "text": "将甲苯加入乙醇溶液中。"}]}]}`
+	_, res = post(t, ts, "/v1/sentences", body)
+	if toks := res.Results[0].Document.Sections[0].Tokens; len(toks) != 7 || toks[1].Text != "甲苯" {
+		t.Errorf("Unexpected segmented tokens : %v", toks)
+	}
+}
+
+//
+
+func TestAnnotate001(t *testing.T) {
+	ts := httptest.NewServer(NewServer(Config{}))
+	defer ts.Close()
+
+	body := `{"documents": [{"id": "D1", "sections": [{"name": "A", "text": "Benzene (CAS 71-43-2) was distilled."}],
+		"annotations": [{"section": "A", "begin": 0, "end": 6, "entity": "Benzene", "property": "NN", "type": "POS"}]}]}`
+	_, res := post(t, ts, "/v1/annotate", body)
+	words := res.Results[0].Document.Sections[0].Words
+	if len(words) != 1 || words[0].Text != "Benzene" || words[0].POS != "NN" {
+		t.Fatalf("Unexpected words : %v", words)
+	}
+
+	body = strings.Replace(body, `"documents"`, `"stages": ["tokenize", "identifiers"], "documents"`, 1)
+	_, res = post(t, ts, "/v1/process", body)
+	words = res.Results[0].Document.Sections[0].Words
+	if len(words) != 2 || words[0].Class != "CAS_RN" || words[1].POS != "NN" || res.Results[0].Document.Sections[0].Sentences != nil {
+		t.Errorf("Unexpected words : %v", words)
+	}
+}
+
+//
+
+func TestLimits001(t *testing.T) {
+	a := tkz.DefaultAbbrevs().With("approx")
+	ts := httptest.NewServer(NewServer(Config{MaxBodyBytes: 200, MaxDocuments: 1, AbbrevSets: map[string]*tkz.AbbrevSet{"lab": a}}))
+	defer ts.Close()
+
+	cases := []struct {
+		path   string
+		body   string
+		status int
+	}{
+		{"/v1/tokenize", `{"documents": [{"id": "D1", "sections": [{"name": "T", "text": "` + strings.Repeat("x", 300) + `"}]}]}`, http.StatusRequestEntityTooLarge},
+		{"/v1/tokenize", `{"documents": [{"id": "D1"}, {"id": "D2"}]}`, http.StatusRequestEntityTooLarge},
+		{"/v1/tokenize", `{"documents": []}`, http.StatusBadRequest},
+		{"/v1/tokenize", `{"documents": `, http.StatusBadRequest},
+		{"/v1/tokenize", `{"abbreviations": "none", "documents": [{"id": "D1"}]}`, http.StatusBadRequest},
+		{"/v1/process", `{"stages": ["none"], "documents": [{"id": "D1"}]}`, http.StatusBadRequest},
+		{"/v1/tokenize", `{"language": "xx", "documents": [{"id": "D1"}]}`, http.StatusBadRequest},
+		{"/v1/tokenize", `{"abbreviations": "lab", "documents": [{"id": "D1", "sections": [{"name": "T", "text": "Text."}]}]}`, http.StatusOK},
+	}
+	for _, c := range cases {
+		if status, _ := post(t, ts, c.path, c.body); status != c.status {
+			t.Errorf("Expected status : %d, observed : %d, for : %s", c.status, status, c.body)
+		}
+	}
+
+	resp, _ := http.Get(ts.URL + "/v1/tokenize")
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status : %d, observed : %d", http.StatusMethodNotAllowed, resp.StatusCode)
+	}
+	resp.Body.Close()
+}
+
+//
+
+func TestHealthAndMetrics001(t *testing.T) {
+	ts := httptest.NewServer(NewServer(Config{}))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/healthz")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Health check failed")
+	}
+	resp.Body.Close()
+
+	post(t, ts, "/v1/tokenize", `{"documents": [{"id": "D1", "sections": [{"name": "T", "text": "Text."}]}]}`)
+	post(t, ts, "/v1/tokenize", `{"documents": []}`)
+
+	resp, _ = http.Get(ts.URL + "/metrics")
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, exp := range []string{
+		`rxnminer_requests_total{endpoint="/v1/tokenize"} 2`,
+		`rxnminer_request_errors_total{endpoint="/v1/tokenize"} 1`,
+		`rxnminer_documents_total 1`,
+	} {
+		if !strings.Contains(string(b), exp) {
+			t.Errorf("Expected metric : %s", exp)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Synthetic procedures introduce compounds by their full names,
+// followed by short labels in parentheses: `...-4,6-dione (1)`.
+// Subsequent text refers to them by their labels -- `compound 1`,
+// `1 (5.0 g)` -- or by definite noun phrases: `the dione`.  The
+// functions in this file resolve such references to the full names,
+// across sentences and sections.
+
+// MentionKind represents the ways in which a labelled compound can be
+// mentioned in text.
+type MentionKind byte
+
+// List of mention kinds.
+const (
+	MentionName       MentionKind = iota // Full name at definition
+	MentionDefinition                    // `(1)` following a full name
+	MentionLabel                         // `compound 1`, `1 (5.0 g)`
+	MentionNounPhrase                    // `the dione`
+)
+
+// MkDescriptions helps in printing mention kinds.
+var MkDescriptions = map[MentionKind]string{
+	MentionName:       "MentionName",
+	MentionDefinition: "MentionDefinition",
+	MentionLabel:      "MentionLabel",
+	MentionNounPhrase: "MentionNounPhrase",
+}
+
+// Mention represents one occurrence of a labelled compound in a
+// section of a document.
+type Mention struct {
+	Section string
+	Begin   int
+	End     int
+	Text    string
+	Kind    MentionKind
+}
+
+// CompoundChain represents a coreference chain of a labelled
+// compound.
+//
+// It holds the label, the full name as given at the first definition
+// of the label, and all mentions of the compound -- including the
+// name itself -- in document order.
+type CompoundChain struct {
+	Label    string
+	Name     *Mention
+	Mentions []*Mention
+}
+
+// labelKeywords lists the words that introduce compound labels.
+var labelKeywords = map[string]struct{}{
+	"compound":  {},
+	"compounds": {},
+	"cpd":       {},
+	"cpds":      {},
+}
+
+// labelUnits lists the units of quantity that can follow a bare label
+// in parentheses: `1 (5.0 g)`.
+var labelUnits = map[string]struct{}{
+	"g": {}, "mg": {}, "kg": {}, "µg": {},
+	"l": {}, "ml": {}, "µl": {}, "μl": {},
+	"mol": {}, "mmol": {}, "µmol": {}, "μmol": {},
+	"equiv": {}, "eq": {},
+}
+
+// chemSuffixes lists the endings of chemical names that, together
+// with structural cues such as locants and hyphens, indicate a
+// systematic name.
+var chemSuffixes = []string{
+	"ane", "ene", "yne", "ol", "al", "one", "ide", "ate", "ite", "ine",
+	"ium", "yl", "ole", "ose", "ether", "ester",
+}
+
+// chemStrongSuffixes lists the endings that indicate a chemical name
+// by themselves, in sufficiently long words.
+var chemStrongSuffixes = []string{
+	"ane", "ene", "yne", "dione", "amine", "amide", "oxide", "idine",
+	"azole", "anol", "enol", "one", "ate",
+}
+
+// chemHeadNouns lists the nouns that complete two-word chemical names
+// such as `acetic acid` and `ethyl acetate`.
+var chemHeadNouns = map[string]struct{}{
+	"acid": {}, "ester": {}, "ether": {}, "alcohol": {}, "anhydride": {},
+	"acetate": {}, "chloride": {}, "bromide": {}, "iodide": {}, "fluoride": {},
+	"oxide": {}, "hydroxide": {}, "hydride": {}, "ketone": {}, "aldehyde": {},
+	"amine": {}, "amide": {}, "salt": {}, "hydrochloride": {}, "carbonate": {},
+	"sulfate": {}, "nitrate": {}, "phosphate": {}, "triflate": {},
+}
+
+// ResolveCompoundLabels detects compound label definitions in the
+// given sections, and resolves subsequent references to them.
+//
+// The sections are processed in the given order, which is taken to be
+// the document order.  Should no section be given, all sections of
+// the document are processed in document order.  The sections must
+// have been tokenized already.
+func (d *Document) ResolveCompoundLabels(secs ...string) ([]*CompoundChain, error) {
+	if len(secs) == 0 {
+		secs = d.Sections()
+	}
+	for _, sec := range secs {
+		if _, ok := d.tokens[sec]; !ok {
+			return nil, fmt.Errorf("Unknown section : %s", sec)
+		}
+	}
+
+	r := &labelResolver{doc: d, chains: make(map[string]*CompoundChain)}
+	for i, sec := range secs {
+		r.findDefinitions(i, sec)
+	}
+	for i, sec := range secs {
+		r.findReferences(i, sec)
+	}
+
+	var res []*CompoundChain
+	for _, c := range r.chains {
+		sort.SliceStable(c.Mentions, func(i, j int) bool {
+			return r.before(c.Mentions[i], c.Mentions[j])
+		})
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return r.before(res[i].Name, res[j].Name)
+	})
+
+	d.chains = res
+	return res, nil
+}
+
+// CompoundChains answers the coreference chains of labelled compounds
+// resolved most recently.
+func (d *Document) CompoundChains() []*CompoundChain {
+	return d.chains
+}
+
+// labelResolver holds the working state of compound label
+// resolution.
+type labelResolver struct {
+	doc     *Document
+	chains  map[string]*CompoundChain
+	secIdx  map[string]int
+	defined []*labelDef
+}
+
+// labelDef records a definition of a label, for resolving noun
+// phrases to the nearest preceding definition.
+type labelDef struct {
+	chain *CompoundChain
+	at    *Mention
+	head  string
+}
+
+// before answers if mention `a` precedes mention `b` in document
+// order.
+func (r *labelResolver) before(a, b *Mention) bool {
+	sa, sb := r.secIdx[a.Section], r.secIdx[b.Section]
+	if sa != sb {
+		return sa < sb
+	}
+	return a.Begin < b.Begin
+}
+
+// findDefinitions detects labels in parentheses or brackets that
+// immediately follow chemical names.
+func (r *labelResolver) findDefinitions(si int, sec string) {
+	if r.secIdx == nil {
+		r.secIdx = make(map[string]int)
+	}
+	r.secIdx[sec] = si
+
+	toks := r.doc.tokens[sec]
+	inp := r.doc.input[sec]
+	for i, t := range toks {
+		if t.Type() != TokParenOpen && t.Type() != TokBracketOpen {
+			continue
+		}
+		label, ci, ok := parseLabel(toks, i+1)
+		if !ok || ci >= len(toks) || !closes(t.Type(), toks[ci].Type()) {
+			continue
+		}
+		if i < 2 || toks[i-1].Type() != TokSpace {
+			continue
+		}
+		nb, ne, ok := chemicalNameBefore(inp, toks, i-2)
+		if !ok {
+			continue
+		}
+
+		name := &Mention{sec, nb, ne, inp[nb : ne+1], MentionName}
+		def := &Mention{sec, t.Begin(), toks[ci].End(), inp[t.Begin() : toks[ci].End()+1], MentionDefinition}
+		c, ok := r.chains[label]
+		if !ok {
+			c = &CompoundChain{Label: label, Name: name}
+			r.chains[label] = c
+		}
+		c.Mentions = append(c.Mentions, name, def)
+		r.defined = append(r.defined, &labelDef{c, def, nameHead(name.Text)})
+	}
+}
+
+// findReferences detects references to defined labels: keyword
+// phrases, bare labels followed by quantities, and definite noun
+// phrases.
+func (r *labelResolver) findReferences(si int, sec string) {
+	toks := r.doc.tokens[sec]
+	inp := r.doc.input[sec]
+	l := len(toks)
+
+	add := func(label string, b, e int, kind MentionKind) {
+		c, ok := r.chains[label]
+		if !ok {
+			return
+		}
+		m := &Mention{sec, b, e, inp[b : e+1], kind}
+		if !r.before(c.Mentions[1], m) {
+			return
+		}
+		c.Mentions = append(c.Mentions, m)
+	}
+
+	for i := 0; i < l; i++ {
+		t := toks[i]
+		if t.Type() != TokMayBeWord || !idBoundary(toks, i-1) {
+			continue
+		}
+		lower := strings.ToLower(t.Text())
+
+		// `compound 1`, `compounds 1, 2 and 3a`
+		if _, ok := labelKeywords[lower]; ok {
+			j := i + 1
+			for j < l && toks[j].Type() == TokSpace {
+				j++
+				label, e, ok := parseLabel(toks, j)
+				if !ok || !idBoundary(toks, e) {
+					break
+				}
+				add(label, toks[j].Begin(), toks[e-1].End(), MentionLabel)
+				j = labelListNext(toks, e)
+			}
+			i = j - 1
+			continue
+		}
+
+		// `the dione`
+		if lower == "the" && i+2 < l && toks[i+1].Type() == TokSpace && idBoundary(toks, i+3) {
+			w := toks[i+2]
+			if def := r.nearestDefinition(strings.ToLower(w.Text()), sec, w.Begin()); def != nil {
+				m := &Mention{sec, t.Begin(), w.End(), inp[t.Begin() : w.End()+1], MentionNounPhrase}
+				def.chain.Mentions = append(def.chain.Mentions, m)
+			}
+			continue
+		}
+
+		// `1 (5.0 g)`
+		if label, e, ok := parseLabel(toks, i); ok && i > 0 && toks[i-1].Type() == TokSpace {
+			if quantityFollows(toks, e) {
+				add(label, t.Begin(), toks[e-1].End(), MentionLabel)
+			}
+		}
+	}
+}
+
+// nearestDefinition answers the latest definition preceding the given
+// position, whose name ends with the given head noun.
+func (r *labelResolver) nearestDefinition(head, sec string, pos int) *labelDef {
+	if head == "" {
+		return nil
+	}
+
+	at := &Mention{Section: sec, Begin: pos}
+	var res *labelDef
+	for _, def := range r.defined {
+		if def.head == head && r.before(def.at, at) {
+			if res == nil || r.before(res.at, def.at) {
+				res = def
+			}
+		}
+	}
+	return res
+}
+
+//
+
+// parseLabel parses a compound label -- digits optionally followed by
+// up to two lowercase letters -- beginning at the token at the given
+// index.  It answers the label and the index of the token following
+// it.
+func parseLabel(toks []*TextToken, i int) (string, int, bool) {
+	l := len(toks)
+	if i >= l || toks[i].Type() != TokMayBeWord {
+		return "", -1, false
+	}
+	s := toks[i].Text()
+	if !isASCIIDigits(s) || len(s) > 3 {
+		return "", -1, false
+	}
+	i++
+
+	if i < l && toks[i].Type() == TokMayBeWord {
+		sfx := toks[i].Text()
+		if len(sfx) > 2 || strings.IndexFunc(sfx, func(r rune) bool { return r < 'a' || r > 'z' }) >= 0 {
+			return "", -1, false
+		}
+		s += sfx
+		i++
+	}
+
+	return s, i, true
+}
+
+// labelListNext answers the index of the space that may precede the
+// next label in a list such as `1, 2 and 3`, skipping any comma and
+// conjunction that follow the label ending before the given index.
+// The caller checks that a space and a label do follow.
+func labelListNext(toks []*TextToken, i int) int {
+	l := len(toks)
+	if i < l && toks[i].Text() == "," {
+		i++
+	}
+	if i+2 < l && toks[i].Type() == TokSpace {
+		switch toks[i+1].Text() {
+		case "and", "or":
+			if toks[i+2].Type() == TokSpace {
+				return i + 2
+			}
+		}
+	}
+	return i
+}
+
+// quantityFollows answers if the tokens from the given index are of
+// the form ` (5.0 g`.
+func quantityFollows(toks []*TextToken, i int) bool {
+	l := len(toks)
+	if i+3 >= l || toks[i].Type() != TokSpace || toks[i+1].Type() != TokParenOpen {
+		return false
+	}
+	j := i + 2
+	if !isASCIIDigits(toks[j].Text()) {
+		return false
+	}
+	for j+2 < l && toks[j+1].Text() == "." && isASCIIDigits(toks[j+2].Text()) {
+		j += 2
+	}
+	if j+2 >= l || toks[j+1].Type() != TokSpace {
+		return false
+	}
+	_, ok := labelUnits[strings.ToLower(toks[j+2].Text())]
+	return ok
+}
+
+// closes answers if the given closing token type matches the given
+// opening token type.
+func closes(open, close TokenType) bool {
+	return (open == TokParenOpen && close == TokParenClose) ||
+		(open == TokBracketOpen && close == TokBracketClose)
+}
+
+// chemicalNameBefore answers the offsets of the chemical name that
+// ends with the token at the given index, if the text there looks
+// like one.
+func chemicalNameBefore(inp string, toks []*TextToken, i int) (int, int, bool) {
+	b := i
+	for b > 0 && toks[b-1].Type() != TokSpace {
+		b--
+	}
+	begin, end := toks[b].Begin(), toks[i].End()
+	chunk := inp[begin : end+1]
+
+	if _, ok := chemHeadNouns[strings.ToLower(chunk)]; ok {
+		if b < 2 || toks[b-1].Text() != " " {
+			return -1, -1, false
+		}
+		pb := b - 2
+		for pb > 0 && toks[pb-1].Type() != TokSpace {
+			pb--
+		}
+		prev := inp[toks[pb].Begin() : toks[b-2].End()+1]
+		lp := strings.ToLower(prev)
+		if isChemicalName(prev) || strings.HasSuffix(lp, "ic") ||
+			strings.HasSuffix(lp, "yl") || strings.HasSuffix(lp, "ous") {
+			return toks[pb].Begin(), end, true
+		}
+		return -1, -1, false
+	}
+
+	if isChemicalName(chunk) {
+		return begin, end, true
+	}
+	return -1, -1, false
+}
+
+// isChemicalName answers if the given single-word text looks like a
+// chemical name: a systematic name with locants, hyphens or brackets
+// and a chemical ending, or a sufficiently long word with a
+// characteristic ending.
+func isChemicalName(s string) bool {
+	lower := strings.ToLower(s)
+	if strings.IndexFunc(lower, unicode.IsLetter) == -1 {
+		return false
+	}
+
+	structural := strings.ContainsAny(lower, "-(),[]0123456789")
+	if structural {
+		for _, sfx := range chemSuffixes {
+			if strings.HasSuffix(lower, sfx) {
+				return true
+			}
+		}
+	}
+
+	if len(lower) >= 8 {
+		for _, sfx := range chemStrongSuffixes {
+			if strings.HasSuffix(lower, sfx) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nameHead answers the head noun of the given chemical name: its last
+// run of letters, in lowercase.
+func nameHead(name string) string {
+	rs := []rune(strings.ToLower(name))
+	e := len(rs)
+	for e > 0 && !unicode.IsLetter(rs[e-1]) {
+		e--
+	}
+	b := e
+	for b > 0 && unicode.IsLetter(rs[b-1]) {
+		b--
+	}
+	return string(rs[b:e])
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestCompoundLabels001(t *testing.T) {
+	doc, _ := NewDocument("Labels001")
+	doc.SetInput("S1", "A solution of 2,2-dimethyl-1,3-dioxane-4,6-dione (1) in THF was cooled. "+
+		"Then ethyl acetate (2a) was added.")
+	doc.SetInput("S2", "To a flask was added 1 (5.0 g, 35 mmol). The dione dissolved slowly. "+
+		"Compounds 1 and 2a were combined, and compound 3 was not known.")
+	doc.Tokenize()
+
+	chains, err := doc.ResolveCompoundLabels("S1", "S2")
+	if err != nil {
+		t.Fatalf("Failed to resolve labels : %s", err.Error())
+	}
+	if len(chains) != 2 {
+		t.Fatalf("Expected chain count : 2, observed : %d", len(chains))
+	}
+
+	c := chains[0]
+	if c.Label != "1" || c.Name.Text != "2,2-dimethyl-1,3-dioxane-4,6-dione" {
+		t.Errorf("Unexpected chain : %s %s", c.Label, c.Name.Text)
+	}
+	exp := []struct {
+		sec  string
+		text string
+		kind MentionKind
+	}{
+		{"S1", "2,2-dimethyl-1,3-dioxane-4,6-dione", MentionName},
+		{"S1", "(1)", MentionDefinition},
+		{"S2", "1", MentionLabel},
+		{"S2", "The dione", MentionNounPhrase},
+		{"S2", "1", MentionLabel},
+	}
+	if len(c.Mentions) != len(exp) {
+		for _, m := range c.Mentions {
+			t.Logf("%s %d %s", m.Section, m.Begin, m.Text)
+		}
+		t.Fatalf("Expected mention count : %d, observed : %d", len(exp), len(c.Mentions))
+	}
+	for i, e := range exp {
+		m := c.Mentions[i]
+		if m.Section != e.sec || m.Text != e.text || m.Kind != e.kind {
+			t.Errorf("Expected : %v, observed : %s %s %s", e, m.Section, m.Text, MkDescriptions[m.Kind])
+		}
+		in, _ := doc.Input(m.Section)
+		if in[m.Begin:m.End+1] != m.Text {
+			t.Errorf("Mention span mismatch for %s : %d:%d", m.Text, m.Begin, m.End)
+		}
+	}
+
+	c = chains[1]
+	if c.Label != "2a" || c.Name.Text != "ethyl acetate" || len(c.Mentions) != 3 {
+		t.Errorf("Unexpected chain : %s %s %d", c.Label, c.Name.Text, len(c.Mentions))
+	}
+}
+
+//
+
+func TestCompoundLabels002(t *testing.T) {
+	bs, err := ioutil.ReadFile("testdata/input-article.txt")
+	if err != nil {
+		t.Fatalf("Input data file '%s' could not be read : %s", "testdata/input-article.txt", err.Error())
+	}
+
+	doc, _ := NewDocument("Labels002")
+	doc.SetInput("Article", string(bs))
+	doc.Tokenize()
+	chains, _ := doc.ResolveCompoundLabels()
+
+	exp := map[string]string{
+		"1": "5-(1-(4-Chlorophenyl)ethylidene)-2,2-dimethyl-1,3-dioxane-4,6-dione",
+		"2": "(R)-5-(2-(4-Chlorophenyl)butan-2-yl)-2,2-dimethyl-1,3-dioxane-4,6-dione",
+		"3": "(R)-3-(4-Chlorophenyl)-3-methylpentanoic acid",
+	}
+	if len(chains) != len(exp) {
+		t.Fatalf("Expected chain count : %d, observed : %d", len(exp), len(chains))
+	}
+	for _, c := range chains {
+		if exp[c.Label] != c.Name.Text {
+			t.Errorf("Expected name for label %s : %s, observed : %s", c.Label, exp[c.Label], c.Name.Text)
+		}
+	}
+
+	if _, err := doc.ResolveCompoundLabels("Missing"); err == nil {
+		t.Errorf("Expected an error for an unknown section")
+	}
+}
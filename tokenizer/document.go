@@ -16,14 +16,40 @@ import (
 // words and sentences that were recognised by other processors.  In
 // case the document has associated training annotations, it holds
 // them as well.
+//
+// A document is not safe for concurrent use.  Its `SectionXxx`
+// accessors answer its internal slices, which must not be modified by
+// callers.  Use `Freeze` to obtain a snapshot for sharing.
 type Document struct {
-	id     string // Must be unique within a run
-	isTech bool   // Is this a technical document?
-	input  map[string]string
-	tokens map[string][]*TextToken
-	words  map[string][]*Word
-	annos  map[string][]*Annotation
-	sents  map[string][]*Sentence
+	id      string     // Must be unique within a run
+	isTech  bool       // Is this a technical document?
+	secs    []string   // Section names, in the order of their addition
+	sep     string     // Separates sections in the full text
+	abbrevs *AbbrevSet // Package-level tables, if nil
+	lang    *LanguagePack
+	seg     Segmenter // Of CJK runs, if any
+	starts  StartClassifier
+	smodel  *SentenceModel // Rules, if nil
+	sopts   SentenceOptions
+	input   map[string]string
+	tokens  map[string][]*TextToken
+	words   map[string][]*Word
+	annos   map[string][]*Annotation
+	sents   map[string][]*Sentence
+	idents  map[string][]*Identifier
+	refs    map[string][]*Reference
+	prots   map[string][]span // Text protected against sentence breaks
+	breaks  map[string][]int  // Offsets that must begin sentences
+	layout  map[string][]*Block
+	notes   map[string]bool // Sections whose line notations are merged
+	chains  []*CompoundChain
+}
+
+// span represents a range of text by its beginning and ending
+// offsets, both inclusive.
+type span struct {
+	begin int
+	end   int
 }
 
 // NewDocument creates and initialises a document with the given
@@ -38,11 +64,18 @@ func NewDocument(id string) (*Document, error) {
 
 	d := &Document{}
 	d.id = id
+	d.sep = DefaultSectionSeparator
 	d.input = make(map[string]string, 2)
 	d.tokens = make(map[string][]*TextToken, 2)
 	d.words = make(map[string][]*Word, 2)
 	d.annos = make(map[string][]*Annotation, 2)
 	d.sents = make(map[string][]*Sentence, 2)
+	d.idents = make(map[string][]*Identifier, 2)
+	d.refs = make(map[string][]*Reference, 2)
+	d.prots = make(map[string][]span, 2)
+	d.breaks = make(map[string][]int, 2)
+	d.layout = make(map[string][]*Block, 2)
+	d.notes = make(map[string]bool, 2)
 
 	return d, nil
 }
@@ -62,13 +95,60 @@ func NewTechnicalDocument(id string) (*Document, error) {
 	return d, nil
 }
 
+func (d *Document) ID() string {
+	return d.id
+}
+
+func (d *Document) IsTechnical() bool {
+	return d.isTech
+}
+
+// SetAbbreviations makes sentence assembly in this document consult
+// the given abbreviation set, rather than the package-level tables.
+func (d *Document) SetAbbreviations(a *AbbrevSet) {
+	d.abbrevs = a
+}
+
+// SetLanguage makes sentence assembly in this document follow the
+// conventions of the given language pack.  Abbreviations set through
+// `SetAbbreviations` take precedence over those of the pack.
+func (d *Document) SetLanguage(p *LanguagePack) {
+	d.lang = p
+}
+
+// Language answers the language pack of this document, or `nil` if
+// none was set.
+func (d *Document) Language() *LanguagePack {
+	return d.lang
+}
+
+// SetStartClassifier makes sentence assembly in this document consult
+// the given classifier, rather than that of its language pack, at
+// candidate sentence boundaries.
+func (d *Document) SetStartClassifier(c StartClassifier) {
+	d.starts = c
+}
+
+// SetSentenceOptions changes the optional behaviour of sentence
+// assembly in this document.
+func (d *Document) SetSentenceOptions(o SentenceOptions) {
+	d.sopts = o
+}
+
 // SetInput registers the input text of the given section of the
 // document.
+//
+// Sections are ordered by the time of their first registration: the
+// title should be registered before the abstract, etc.  Registering a
+// section again replaces its text, but retains its position.
 func (d *Document) SetInput(sec, input string) error {
 	if sec == "" || input == "" {
 		return fmt.Errorf("Empty section name or body given.")
 	}
 
+	if _, ok := d.input[sec]; !ok {
+		d.secs = append(d.secs, sec)
+	}
 	d.input[sec] = input
 	return nil
 }
@@ -90,33 +170,96 @@ func (d *Document) Input(sec string) (string, error) {
 // They can also be combined into logical words for named entity
 // recognition and part of speech recognition purposes.
 func (d *Document) Tokenize() {
-	var ti *TextTokenIterator
 	var err error
 
-	for sec, inp := range d.input {
-		ti = NewTextTokenIterator(inp)
+	for _, sec := range d.secs {
+		ti := d.tokenIterator(sec, 0)
 		var toks []*TextToken
 		for err = ti.MoveNext(); err == nil; err = ti.MoveNext() {
 			toks = append(toks, ti.Item())
 		}
 		d.tokens[sec] = toks
+		delete(d.notes, sec)
+	}
+}
+
+// SetSegmenter makes tokenization of this document subdivide runs of
+// CJK text into words, using the given segmenter.
+func (d *Document) SetSegmenter(sg Segmenter) {
+	d.seg = sg
+}
+
+// tokenIterator answers a token iterator over the text of the given
+// section, beginning at the given offset.
+func (d *Document) tokenIterator(sec string, off int) TokenIterator {
+	ti := NewTextTokenIteratorWithOffset(d.input[sec], off)
+	if d.seg == nil {
+		return ti
 	}
+	return NewSegmentingIterator(ti, d.seg)
 }
 
 // AssembleSentences builds sentences the text tokens obtained as a
 // result of tokenization of the sections in the document.
 func (d *Document) AssembleSentences() {
-	var si *SentenceIterator
-	var err error
+	for _, sec := range d.secs {
+		if _, ok := d.tokens[sec]; ok {
+			d.assembleSection(sec)
+		}
+	}
+}
+
+// assembleSection builds sentences from the text tokens of the given
+// section.
+func (d *Document) assembleSection(sec string) {
+	si := d.sentenceIterator(sec)
+	var sents []*Sentence
+	for err := si.MoveNext(); err == nil; err = si.MoveNext() {
+		sents = append(sents, si.Item())
+	}
+	d.sents[sec] = sents
+}
 
-	for sec, toks := range d.tokens {
-		si = NewSentenceIterator(toks)
-		var sents []*Sentence
-		for err = si.MoveNext(); err == nil; err = si.MoveNext() {
-			sents = append(sents, si.Item())
+// sentenceAssembler is the interface common to the sentence iterators
+// that documents use.
+type sentenceAssembler interface {
+	SentenceAssembler
+	seek(idx int)
+}
+
+// sentenceIterator answers a sentence iterator over the text tokens
+// of the given section, honouring its protected spans and forced
+// breaks.  It consults the sentence model of the document, if any.
+func (d *Document) sentenceIterator(sec string) sentenceAssembler {
+	if d.smodel != nil {
+		si := NewModelSentenceIterator(d.tokens[sec], d.smodel)
+		if d.abbrevs != nil {
+			si.SetAbbreviations(d.abbrevs)
+		}
+		si.SetOptions(d.sopts)
+		for _, p := range d.prots[sec] {
+			si.Protect(p.begin, p.end)
 		}
-		d.sents[sec] = sents
+		for _, b := range d.breaks[sec] {
+			si.BreakBefore(b)
+		}
+		return si
+	}
+
+	si := NewSentenceIterator(d.tokens[sec])
+	if d.abbrevs != nil {
+		si.SetAbbreviations(d.abbrevs)
 	}
+	si.SetLanguage(d.lang)
+	si.SetStartClassifier(d.starts)
+	si.SetOptions(d.sopts)
+	for _, p := range d.prots[sec] {
+		si.Protect(p.begin, p.end)
+	}
+	for _, b := range d.breaks[sec] {
+		si.BreakBefore(b)
+	}
+	return si
 }
 
 // Annotate records the given annotation against the applicable
@@ -146,59 +289,90 @@ func (d *Document) Annotate(a *Annotation, what string) error {
 //
 
 func (d *Document) annotateWord(a *Annotation, what string, toks []*TextToken) (*Word, error) {
+	if _, _, err := tokenSpan(toks, a.Begin, a.End); err != nil {
+		return nil, fmt.Errorf("Annotation could not be matched : %v", *a)
+	}
+
+	switch what {
+	case "POS", "LEM", "CLS":
+	default:
+		return nil, fmt.Errorf("Unknown annotation type : %s", what)
+	}
+
+	w := d.wordAt(a.Section, a.Entity, a.Begin, a.End)
+	switch what {
+	case "POS":
+		w.pos = a.Property
+	case "LEM":
+		w.lemma = a.Property
+	case "CLS":
+		w.class = a.Property
+	}
+
+	return w, nil
+}
+
+// protect marks the given text of the given section as indivisible
+// for sentence assembly.
+func (d *Document) protect(sec string, b, e int) {
+	for _, p := range d.prots[sec] {
+		if p.begin == b && p.end == e {
+			return
+		}
+	}
+	d.prots[sec] = append(d.prots[sec], span{b, e})
+}
+
+// Sections answers the names of the sections of the document, in
+// document order.
+func (d *Document) Sections() []string {
+	secs := make([]string, len(d.secs))
+	copy(secs, d.secs)
+	return secs
+}
+
+// wordAt answers the word in the given section that spans exactly the
+// given offsets.  Should no such word exist, it creates one with the
+// given text, and registers it.
+func (d *Document) wordAt(sec, text string, b, e int) *Word {
+	words := d.words[sec]
+	for _, w := range words {
+		if w.Begin() == b && w.End() == e {
+			return w
+		}
+	}
+
+	w := newWord(text, b, e)
+	d.words[sec] = append(words, w)
+	return w
+}
+
+// tokenSpan answers the indices of the tokens that begin and end at
+// the given offsets, respectively.
+func tokenSpan(toks []*TextToken, b, e int) (int, int, error) {
 	bidx := -1
 	eidx := -1
 	for i, t := range toks {
-		if t.Begin() == a.Begin {
+		if t.Begin() == b {
 			bidx = i
 			break
 		}
 	}
 	if bidx == -1 {
-		return nil, fmt.Errorf("Annotation could not be matched : %v", *a)
+		return -1, -1, fmt.Errorf("No token begins at offset : %d", b)
 	}
 	l := len(toks)
 	for i := bidx; i < l; i++ {
-		if toks[i].End() == a.End {
+		if toks[i].End() == e {
 			eidx = i
 			break
 		}
 	}
 	if eidx == -1 {
-		return nil, fmt.Errorf("Annotation could not be matched : %v", *a)
-	}
-
-	var w *Word
-	found := false
-	words := d.words[a.Section]
-	for _, tw := range words {
-		if tw.Begin() == a.Begin && tw.End() == a.End {
-			w = tw
-			found = true
-			break
-		}
+		return -1, -1, fmt.Errorf("No token ends at offset : %d", e)
 	}
 
-	if !found {
-		w = newWord(a.Entity, a.Begin, a.End)
-	}
-	switch what {
-	case "POS":
-		w.pos = a.Property
-	case "LEM":
-		w.lemma = a.Property
-	case "CLS":
-		w.class = a.Property
-	default:
-		return nil, fmt.Errorf("Unknown annotation type : %s", what)
-	}
-
-	if !found {
-		words = append(words, w)
-		d.words[a.Section] = words
-	}
-
-	return w, nil
+	return bidx, eidx, nil
 }
 
 // SectionTokens answers recognised tokens in the given section.
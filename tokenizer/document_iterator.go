@@ -0,0 +1,108 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"io"
+)
+
+// DocTokenIterator iterates over the tokens of all the sections of a
+// document, in document order.
+//
+// Sections that have not been tokenized are skipped.
+type DocTokenIterator struct {
+	doc  *Document
+	sidx int // Index of the current section
+	idx  int // Index of the next token in the current section
+	ct   *TextToken
+}
+
+// NewDocTokenIterator creates and initialises a token iterator over
+// the given document.
+func NewDocTokenIterator(d *Document) *DocTokenIterator {
+	return &DocTokenIterator{doc: d}
+}
+
+// MoveNext advances the iterator to the next token, should one be
+// available.
+//
+// The return value is either `nil` (more tokens may be available) or
+// `io.EOF` (no more tokens).
+func (it *DocTokenIterator) MoveNext() error {
+	for ; it.sidx < len(it.doc.secs); it.sidx, it.idx = it.sidx+1, 0 {
+		toks := it.doc.tokens[it.doc.secs[it.sidx]]
+		if it.idx < len(toks) {
+			it.ct = toks[it.idx]
+			it.idx++
+			return nil
+		}
+	}
+
+	it.ct = nil
+	return io.EOF
+}
+
+// Item answers the current token.
+func (it *DocTokenIterator) Item() *TextToken {
+	return it.ct
+}
+
+// Section answers the name of the section of the current token.
+func (it *DocTokenIterator) Section() string {
+	if it.sidx < len(it.doc.secs) {
+		return it.doc.secs[it.sidx]
+	}
+	return ""
+}
+
+// DocSentenceIterator iterates over the sentences of all the sections
+// of a document, in document order.
+//
+// Sections whose sentences have not been assembled are skipped.
+type DocSentenceIterator struct {
+	doc  *Document
+	sidx int // Index of the current section
+	idx  int // Index of the next sentence in the current section
+	cs   *Sentence
+}
+
+// NewDocSentenceIterator creates and initialises a sentence iterator
+// over the given document.
+func NewDocSentenceIterator(d *Document) *DocSentenceIterator {
+	return &DocSentenceIterator{doc: d}
+}
+
+// MoveNext advances the iterator to the next sentence, should one be
+// available.
+//
+// The return value is either `nil` (more sentences may be available)
+// or `io.EOF` (no more sentences).
+func (it *DocSentenceIterator) MoveNext() error {
+	for ; it.sidx < len(it.doc.secs); it.sidx, it.idx = it.sidx+1, 0 {
+		sents := it.doc.sents[it.doc.secs[it.sidx]]
+		if it.idx < len(sents) {
+			it.cs = sents[it.idx]
+			it.idx++
+			return nil
+		}
+	}
+
+	it.cs = nil
+	return io.EOF
+}
+
+// Item answers the current sentence.
+func (it *DocSentenceIterator) Item() *Sentence {
+	return it.cs
+}
+
+// Section answers the name of the section of the current sentence.
+func (it *DocSentenceIterator) Section() string {
+	if it.sidx < len(it.doc.secs) {
+		return it.doc.secs[it.sidx]
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSections001(t *testing.T) {
+	doc, _ := NewDocument("Sections001")
+	names := []string{"title", "abstract", "claims", "description"}
+	texts := []string{"A title", "First sentence. Second one.", "We claim it.", "It works."}
+	for i, n := range names {
+		doc.SetInput(n, texts[i])
+	}
+	doc.SetInput("abstract", "First sentence. Second one!")
+
+	if obs := strings.Join(doc.Sections(), " "); obs != strings.Join(names, " ") {
+		t.Fatalf("Expected sections : %v, observed : %s", names, obs)
+	}
+
+	exp := 0
+	for i, n := range names {
+		off, err := doc.SectionOffset(n)
+		if err != nil || off != exp {
+			t.Errorf("Expected offset of %s : %d, observed : %d", n, exp, off)
+		}
+		exp += len(texts[i]) + len(DefaultSectionSeparator)
+	}
+	if _, err := doc.SectionOffset("none"); err == nil {
+		t.Errorf("Expected an error for an unknown section")
+	}
+}
+
+//
+
+func TestDocIterators001(t *testing.T) {
+	doc, _ := NewDocument("Iterators001")
+	doc.SetInput("T", "Title text")
+	doc.SetInput("A", "First sentence. Second one.")
+	doc.SetInput("C", "A claim.")
+	doc.Tokenize()
+	doc.AssembleSentences()
+
+	var secs []string
+	n := 0
+	ti := NewDocTokenIterator(doc)
+	for err := ti.MoveNext(); err == nil; err = ti.MoveNext() {
+		if len(secs) == 0 || secs[len(secs)-1] != ti.Section() {
+			secs = append(secs, ti.Section())
+			n = 0
+		}
+		if ti.Item() != doc.SectionTokens(ti.Section())[n] {
+			t.Fatalf("Token mismatch in section %s at index : %d", ti.Section(), n)
+		}
+		n++
+	}
+	if strings.Join(secs, "") != "TAC" {
+		t.Errorf("Expected token sections : TAC, observed : %v", secs)
+	}
+
+	var sents []string
+	si := NewDocSentenceIterator(doc)
+	for err := si.MoveNext(); err == nil; err = si.MoveNext() {
+		sents = append(sents, si.Section()+":"+si.Item().Text())
+	}
+	exp := "T:Title text|A:First sentence.|A:Second one.|C:A claim."
+	if obs := strings.Join(sents, "|"); obs != exp {
+		t.Errorf("Expected sentences : %s, observed : %s", exp, obs)
+	}
+}
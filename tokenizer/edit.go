@@ -0,0 +1,447 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// EditReport describes the effects of an edit of the text of a
+// section on the information held by the document.
+type EditReport struct {
+	Section  string
+	Offset   int // Where the edit begins
+	Deleted  int // Number of bytes deleted
+	Inserted int // Number of bytes inserted
+	Delta    int // Shift of offsets beyond the edit
+
+	FirstToken    int          // Index of the first re-tokenized token
+	RemovedTokens int          // Number of old tokens replaced
+	Tokens        []*TextToken // New tokens in their place
+
+	FirstSentence    int         // Index of the first re-assembled sentence
+	RemovedSentences int         // Number of old sentences replaced
+	Sentences        []*Sentence // New sentences in their place
+
+	ShiftedWords           []*Word
+	InvalidatedWords       []*Word
+	ShiftedAnnotations     []*Annotation
+	InvalidatedAnnotations []*Annotation
+}
+
+// Edit replaces `del` bytes of the text of the given section,
+// beginning at offset `off`, with the text `ins`.
+//
+// Should the section have been tokenized, only the tokens around the
+// edit are re-tokenized; tokens beyond are re-used, with their offsets
+// shifted.  Likewise, should its sentences have been assembled, only
+// the sentences around the edit are re-assembled.
+//
+// Words and annotations that lie entirely outside the edit are
+// retained, with their offsets shifted as necessary.  Those that
+// overlap the edit, or whose boundaries no longer coincide with those
+// of tokens, are invalidated and removed, together with any
+// identifiers and references that they represent.  Compound mentions
+// and layout blocks are adjusted similarly; compound chains whose
+// names are invalidated are dropped.  Line notations are merged again
+// in the re-tokenized text, should those of the section have been
+// recognised, and words are created for them.
+func (d *Document) Edit(sec string, off, del int, ins string) (*EditReport, error) {
+	inp, ok := d.input[sec]
+	if !ok {
+		return nil, fmt.Errorf("Unknown section : %s", sec)
+	}
+	if off < 0 || del < 0 || off+del > len(inp) {
+		return nil, fmt.Errorf("Edit out of range of section %s : %d+%d", sec, off, del)
+	}
+	ninp := inp[:off] + ins + inp[off+del:]
+	if ninp == "" {
+		return nil, fmt.Errorf("Edit empties section : %s", sec)
+	}
+
+	rep := &EditReport{Section: sec, Offset: off, Deleted: del, Inserted: len(ins), Delta: len(ins) - del}
+	d.input[sec] = ninp
+	ed := &editor{d, sec, off, del, len(ins), rep.Delta}
+
+	if _, ok := d.tokens[sec]; ok {
+		ed.retokenize(rep)
+		ed.renotate(rep)
+	}
+	ed.shiftSpans()
+	if _, ok := d.sents[sec]; ok {
+		ed.reassemble(rep)
+	}
+	ed.shiftWords(rep)
+	ed.noteWords(rep)
+	ed.shiftAnnotations(rep)
+	ed.shiftDerived()
+
+	return rep, nil
+}
+
+// editor holds the particulars of an edit being applied to a section.
+type editor struct {
+	d     *Document
+	sec   string
+	off   int
+	del   int
+	ins   int
+	delta int
+}
+
+// shift maps the given span of the old text to the new text.  It
+// answers `false` if the span overlaps the edited text.
+func (ed *editor) shift(b, e int) (int, int, bool) {
+	switch {
+	case e < ed.off:
+		return b, e, true
+	case b >= ed.off+ed.del:
+		return b + ed.delta, e + ed.delta, true
+	}
+	return -1, -1, false
+}
+
+// clamp maps the given span of the old text to the new text,
+// stretching or shrinking it to cover the edit, should it overlap the
+// edit.  It answers `false` if nothing remains of the span.
+func (ed *editor) clamp(b, e int) (int, int, bool) {
+	if b >= ed.off+ed.del {
+		b += ed.delta
+	} else if b >= ed.off {
+		b = ed.off
+	}
+	if e >= ed.off+ed.del {
+		e += ed.delta
+	} else if e >= ed.off {
+		e = ed.off + ed.ins - 1
+	}
+	return b, e, b <= e
+}
+
+// aligned answers if the given span begins and ends at token
+// boundaries of the section, or if the section has not been tokenized.
+func (ed *editor) aligned(b, e int) bool {
+	toks, ok := ed.d.tokens[ed.sec]
+	if !ok {
+		return true
+	}
+	_, _, err := tokenSpan(toks, b, e)
+	return err == nil
+}
+
+// retokenize re-tokenizes the text around the edit.
+//
+// Tokens are detected afresh from the beginning of the last token that
+// begins before the edit, since that token may now extend into the
+// inserted text.  Re-tokenization stops as soon as a new token beyond
+// the edit begins where an old one did; all subsequent old tokens are
+// re-used.
+func (ed *editor) retokenize(rep *EditReport) {
+	toks := ed.d.tokens[ed.sec]
+	l := len(toks)
+	i := sort.Search(l, func(k int) bool { return toks[k].begin >= ed.off }) - 1
+	if i < 0 {
+		i = 0
+	}
+	if ed.d.seg != nil {
+		// Segmentation depends on the entire run.
+		for i > 0 && i < l && joined(toks[i-1], toks[i]) {
+			i--
+		}
+	}
+	start := 0
+	if i < l {
+		start = toks[i].begin
+	}
+
+	j := l
+	var added []*TextToken
+	ti := ed.d.tokenIterator(ed.sec, start)
+	for err := ti.MoveNext(); err == nil; err = ti.MoveNext() {
+		t := ti.Item()
+		if t.begin >= ed.off+ed.ins && !ed.inRun(added, t) {
+			ob := t.begin - ed.delta
+			k := sort.Search(l, func(k int) bool { return toks[k].begin >= ob })
+			if k < l && toks[k].begin == ob && !(ed.d.seg != nil && k > 0 && joined(toks[k-1], toks[k])) {
+				j = k
+				break
+			}
+		}
+		added = append(added, t)
+	}
+
+	ntoks := make([]*TextToken, 0, i+len(added)+l-j)
+	ntoks = append(ntoks, toks[:i]...)
+	ntoks = append(ntoks, added...)
+	for _, t := range toks[j:] {
+		if ed.delta != 0 {
+			t = &TextToken{t.text, t.begin + ed.delta, t.end + ed.delta, t.ttype}
+		}
+		ntoks = append(ntoks, t)
+	}
+	ed.d.tokens[ed.sec] = ntoks
+
+	rep.FirstToken = i
+	rep.RemovedTokens = j - i
+	rep.Tokens = added
+}
+
+// renotate merges the line notations of the re-tokenized tokens,
+// should those of the section have been recognised.  Since notations
+// are found in runs of non-space tokens, the re-tokenized range is
+// widened to the runs that it touches.
+func (ed *editor) renotate(rep *EditReport) {
+	if !ed.d.notes[ed.sec] {
+		return
+	}
+	toks := ed.d.tokens[ed.sec]
+	a, z := rep.FirstToken, rep.FirstToken+len(rep.Tokens)
+	for a > 0 && a < z && toks[a].ttype != TokSpace && toks[a-1].ttype != TokSpace {
+		a--
+	}
+	for z > a && z < len(toks) && toks[z-1].ttype != TokSpace && toks[z].ttype != TokSpace {
+		z++
+	}
+
+	merged := MergeLineNotations(ed.d.input[ed.sec], toks[a:z])
+	ntoks := make([]*TextToken, 0, a+len(merged)+len(toks)-z)
+	ntoks = append(ntoks, toks[:a]...)
+	ntoks = append(ntoks, merged...)
+	ntoks = append(ntoks, toks[z:]...)
+	ed.d.tokens[ed.sec] = ntoks
+
+	rep.RemovedTokens += rep.FirstToken - a + z - (rep.FirstToken + len(rep.Tokens))
+	rep.FirstToken = a
+	rep.Tokens = merged
+}
+
+// noteWords creates words for the line notations among the
+// re-tokenized tokens, should those of the section have been
+// recognised.
+func (ed *editor) noteWords(rep *EditReport) {
+	if !ed.d.notes[ed.sec] {
+		return
+	}
+	for _, t := range rep.Tokens {
+		if t.ttype == TokNotation {
+			ed.d.notationWord(ed.sec, t)
+		}
+	}
+}
+
+// inRun answers if the given new token continues a run of text being
+// segmented, and hence cannot end re-tokenization.
+func (ed *editor) inRun(added []*TextToken, t *TextToken) bool {
+	if ed.d.seg == nil || len(added) == 0 {
+		return false
+	}
+	return joined(added[len(added)-1], t)
+}
+
+// reassemble re-assembles the sentences around the edit.
+//
+// Assembly begins with the sentence preceding that containing the
+// first re-tokenized token, since the edit may merge the two.  It
+// stops as soon as a new sentence beyond the re-tokenized tokens
+// begins where an old one did; all subsequent old sentences are
+// re-used.
+func (ed *editor) reassemble(rep *EditReport) {
+	sents := ed.d.sents[ed.sec]
+	l := len(sents)
+	s := sort.Search(l, func(k int) bool { return sents[k].bTokIdx > rep.FirstToken }) - 2
+	if s < 0 {
+		s = 0
+	}
+	start := 0
+	if s < l {
+		start = sents[s].bTokIdx
+	}
+
+	tokDelta := len(rep.Tokens) - rep.RemovedTokens
+	limit := rep.FirstToken + len(rep.Tokens)
+	m := l
+	var added []*Sentence
+	si := ed.d.sentenceIterator(ed.sec)
+	si.seek(start)
+	for err := si.MoveNext(); err == nil; err = si.MoveNext() {
+		ns := si.Item()
+		if ns.bTokIdx >= limit {
+			ob := ns.bTokIdx - tokDelta
+			k := sort.Search(l, func(k int) bool { return sents[k].bTokIdx >= ob })
+			if k < l && sents[k].bTokIdx == ob {
+				m = k
+				break
+			}
+		}
+		added = append(added, ns)
+	}
+
+	nsents := make([]*Sentence, 0, s+len(added)+l-m)
+	nsents = append(nsents, sents[:s]...)
+	nsents = append(nsents, added...)
+	for _, st := range sents[m:] {
+		if ed.delta != 0 || tokDelta != 0 {
+			ns := newSentence(st.token.text, st.token.begin+ed.delta, st.token.end+ed.delta,
+				st.bTokIdx+tokDelta, st.eTokIdx+tokDelta)
+			for _, bd := range st.trace {
+				bd.Token += tokDelta
+				bd.Begin += ed.delta
+				ns.trace = append(ns.trace, bd)
+			}
+			ns.groups = shiftGroups(st.groups, ed.delta, tokDelta)
+			ns.quotes = shiftGroups(st.quotes, ed.delta, tokDelta)
+			for _, gw := range st.warnings {
+				gw.Token += tokDelta
+				gw.Begin += ed.delta
+				ns.warnings = append(ns.warnings, gw)
+			}
+			st = ns
+		}
+		nsents = append(nsents, st)
+	}
+	ed.d.sents[ed.sec] = nsents
+
+	rep.FirstSentence = s
+	rep.RemovedSentences = m - s
+	rep.Sentences = added
+}
+
+// shiftSpans shifts the protected spans and forced breaks of the
+// section, dropping those that overlap the edit or no longer coincide
+// with token boundaries.
+func (ed *editor) shiftSpans() {
+	var prots []span
+	for _, p := range ed.d.prots[ed.sec] {
+		if b, e, ok := ed.shift(p.begin, p.end); ok && ed.aligned(b, e) {
+			prots = append(prots, span{b, e})
+		}
+	}
+	ed.d.prots[ed.sec] = prots
+
+	var breaks []int
+	for _, b := range ed.d.breaks[ed.sec] {
+		if nb, _, ok := ed.shift(b, b); ok {
+			breaks = append(breaks, nb)
+		}
+	}
+	ed.d.breaks[ed.sec] = breaks
+}
+
+// shiftWords shifts the words of the section, and removes those
+// invalidated by the edit.
+func (ed *editor) shiftWords(rep *EditReport) {
+	var words []*Word
+	for _, w := range ed.d.words[ed.sec] {
+		b, e, ok := ed.shift(w.Begin(), w.End())
+		if !ok || !ed.aligned(b, e) {
+			rep.InvalidatedWords = append(rep.InvalidatedWords, w)
+			continue
+		}
+		if b != w.Begin() {
+			w.token.begin, w.token.end = b, e
+			rep.ShiftedWords = append(rep.ShiftedWords, w)
+		}
+		words = append(words, w)
+	}
+	if _, ok := ed.d.words[ed.sec]; ok {
+		ed.d.words[ed.sec] = words
+	}
+}
+
+// shiftAnnotations shifts the annotations of the section, and removes
+// those invalidated by the edit.
+func (ed *editor) shiftAnnotations(rep *EditReport) {
+	var annos []*Annotation
+	for _, a := range ed.d.annos[ed.sec] {
+		b, e, ok := ed.shift(a.Begin, a.End)
+		if !ok || !ed.aligned(b, e) {
+			rep.InvalidatedAnnotations = append(rep.InvalidatedAnnotations, a)
+			continue
+		}
+		if b != a.Begin {
+			a.Begin, a.End = b, e
+			rep.ShiftedAnnotations = append(rep.ShiftedAnnotations, a)
+		}
+		annos = append(annos, a)
+	}
+	if _, ok := ed.d.annos[ed.sec]; ok {
+		ed.d.annos[ed.sec] = annos
+	}
+}
+
+// shiftDerived adjusts the information derived from the words and the
+// text of the section: identifiers, references, compound mentions and
+// layout blocks.
+func (ed *editor) shiftDerived() {
+	d := ed.d
+	live := make(map[*Word]bool, len(d.words[ed.sec]))
+	for _, w := range d.words[ed.sec] {
+		live[w] = true
+	}
+
+	var ids []*Identifier
+	for _, id := range d.idents[ed.sec] {
+		if live[id.word] {
+			ids = append(ids, id)
+		}
+	}
+	if _, ok := d.idents[ed.sec]; ok {
+		d.idents[ed.sec] = ids
+	}
+
+	var refs []*Reference
+	for _, r := range d.refs[ed.sec] {
+		if live[r.word] {
+			refs = append(refs, r)
+		}
+	}
+	if _, ok := d.refs[ed.sec]; ok {
+		d.refs[ed.sec] = refs
+	}
+
+	var chains []*CompoundChain
+	for _, c := range d.chains {
+		var ms []*Mention
+		named := true
+		for _, m := range c.Mentions {
+			if m.Section == ed.sec {
+				b, e, ok := ed.shift(m.Begin, m.End)
+				if !ok {
+					if m == c.Name {
+						named = false
+					}
+					continue
+				}
+				m.Begin, m.End = b, e
+			}
+			ms = append(ms, m)
+		}
+		if named && len(ms) > 0 {
+			c.Mentions = ms
+			chains = append(chains, c)
+		}
+	}
+	d.chains = chains
+
+	var walk func(bs []*Block) []*Block
+	walk = func(bs []*Block) []*Block {
+		var res []*Block
+		for _, blk := range bs {
+			b, e, ok := ed.clamp(blk.begin, blk.end)
+			if !ok {
+				continue
+			}
+			blk.begin, blk.end = b, e
+			blk.children = walk(blk.children)
+			res = append(res, blk)
+		}
+		return res
+	}
+	if _, ok := d.layout[ed.sec]; ok {
+		d.layout[ed.sec] = walk(d.layout[ed.sec])
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"testing"
+)
+
+func TestEdit001(t *testing.T) {
+	inserts := []string{"", "x", ". ", "The ", "(", ")", " ", "\n\n", "Dr. Smith", "5.0", "-"}
+	checkRandomEdits(t, func(d *Document) {}, inserts)
+}
+
+//
+
+func TestEdit003(t *testing.T) {
+	inserts := []string{"", "x", ". ", "The ", "(", ")", " ", "\"", "'", "s", "\u00ab", "\u00bb", "Dr. Smith", "5.0"}
+	checkRandomEdits(t, func(d *Document) {
+		d.SetSentenceOptions(SentenceOptions{GroupAware: true, QuoteAware: true, MaxGroupSpan: 40})
+	}, inserts)
+}
+
+// checkRandomEdits applies random edits to a document prepared by the
+// given function, and compares it with one tokenized and assembled
+// afresh after each edit.
+func checkRandomEdits(t *testing.T, setup func(d *Document), inserts []string) {
+	b, err := ioutil.ReadFile("testdata/input-article.txt")
+	if err != nil {
+		t.Fatalf("Unable to read file : %s", err.Error())
+	}
+	inp := string(b[:4000])
+
+	doc, _ := NewDocument("Edit001")
+	setup(doc)
+	doc.SetInput("P", inp)
+	doc.Tokenize()
+	doc.AssembleSentences()
+
+	rnd := rand.New(rand.NewSource(42))
+	for n := 0; n < 300; n++ {
+		cur, _ := doc.Input("P")
+		off := rnd.Intn(len(cur))
+		del := rnd.Intn(6)
+		if off+del > len(cur) {
+			del = len(cur) - off
+		}
+		ins := inserts[rnd.Intn(len(inserts))]
+		if _, err := doc.Edit("P", off, del, ins); err != nil {
+			t.Fatalf("Failed to apply edit %d : %s", n, err.Error())
+		}
+
+		ref, _ := NewDocument("Edit001-ref")
+		setup(ref)
+		nin, _ := doc.Input("P")
+		ref.SetInput("P", nin)
+		ref.Tokenize()
+		ref.AssembleSentences()
+
+		toks, rtoks := doc.SectionTokens("P"), ref.SectionTokens("P")
+		if len(toks) != len(rtoks) {
+			t.Fatalf("Edit %d (%d, %d, %q) : expected token count : %d, observed : %d", n, off, del, ins, len(rtoks), len(toks))
+		}
+		for i := range toks {
+			if *toks[i] != *rtoks[i] {
+				t.Fatalf("Edit %d : expected token : %v, observed : %v", n, *rtoks[i], *toks[i])
+			}
+		}
+		sents, rsents := doc.SectionSentences("P"), ref.SectionSentences("P")
+		if len(sents) != len(rsents) {
+			t.Fatalf("Edit %d (%d, %d, %q) : expected sentence count : %d, observed : %d", n, off, del, ins, len(rsents), len(sents))
+		}
+		for i := range sents {
+			s, r := sents[i], rsents[i]
+			if s.Text() != r.Text() || s.Begin() != r.Begin() || s.End() != r.End() ||
+				s.BeginToken() != r.BeginToken() || s.EndToken() != r.EndToken() {
+				t.Fatalf("Edit %d : expected sentence : %v, observed : %v", n, *r, *s)
+			}
+			if len(s.Quotes()) != len(r.Quotes()) || len(s.Groups()) != len(r.Groups()) ||
+				len(s.Warnings()) != len(r.Warnings()) {
+				t.Fatalf("Edit %d : quotation, group or warning mismatch in : %q", n, s.Text())
+			}
+		}
+	}
+}
+
+//
+
+func TestEdit002(t *testing.T) {
+	in := "Benzene (CAS 71-43-2) was distilled. Toluene was added. It was stirred."
+	doc, _ := NewDocument("Edit002")
+	doc.SetInput("P", in)
+	doc.Tokenize()
+	doc.AssembleSentences()
+	doc.RecognizeIdentifiers("P")
+	doc.Annotate(&Annotation{"Edit002", "P", 0, 6, "Benzene", "CHEMICAL"}, "CLS")
+	doc.Annotate(&Annotation{"Edit002", "P", 37, 43, "Toluene", "CHEMICAL"}, "CLS")
+	doc.Annotate(&Annotation{"Edit002", "P", 63, 69, "stirred", "VBN"}, "POS")
+
+	// `Toluene` -> `Xylene`.
+	rep, err := doc.Edit("P", 37, 7, "Xylene")
+	if err != nil {
+		t.Fatalf("Failed to apply edit : %s", err.Error())
+	}
+	if rep.Delta != -1 || rep.RemovedTokens != 2 || len(rep.Tokens) != 2 {
+		t.Errorf("Unexpected token changes : %d, %d, %d", rep.Delta, rep.RemovedTokens, len(rep.Tokens))
+	}
+	if len(rep.InvalidatedWords) != 1 || rep.InvalidatedWords[0].Text() != "Toluene" ||
+		len(rep.InvalidatedAnnotations) != 1 || rep.InvalidatedAnnotations[0].Entity != "Toluene" {
+		t.Fatalf("Expected `Toluene` to be invalidated")
+	}
+	if len(rep.ShiftedWords) != 1 || rep.ShiftedWords[0].Begin() != 62 ||
+		len(rep.ShiftedAnnotations) != 1 || rep.ShiftedAnnotations[0].Begin != 62 {
+		t.Fatalf("Expected `stirred` to be shifted")
+	}
+
+	out, _ := doc.Input("P")
+	for _, w := range doc.SectionWords("P") {
+		if out[w.Begin():w.End()+1] != w.Text() {
+			t.Errorf("Word span mismatch for %s : %d:%d", w.Text(), w.Begin(), w.End())
+		}
+	}
+
+	// Delete the CAS number.
+	rep, _ = doc.Edit("P", 8, 14, "")
+	if len(rep.InvalidatedWords) != 1 || len(doc.SectionIdentifiers("P")) != 0 {
+		t.Errorf("Expected the identifier to be invalidated")
+	}
+	if c, _ := doc.SectionSentenceCount("P"); c != 3 {
+		t.Errorf("Expected sentence count : 3, observed : %d", c)
+	}
+
+	if _, err := doc.Edit("P", 5, 1000, ""); err == nil {
+		t.Errorf("Expected an error for an out of range edit")
+	}
+}
+
+//
+
+func TestEdit004(t *testing.T) {
+	doc, _ := NewDocument("Edit004")
+	doc.SetInput("S1", "Then ethyl acetate (2a) was added. Compound 2a was stirred.")
+	doc.SetInput("S2", "Compound 2a was filtered.")
+	doc.Tokenize()
+	if chains, _ := doc.ResolveCompoundLabels("S1", "S2"); len(chains) != 1 {
+		t.Fatalf("Expected chain count : 1, observed : %d", len(chains))
+	}
+
+	// An edit elsewhere keeps the chain, shifting its mentions.
+	doc.Edit("S1", 0, 4, "Next")
+	chains := doc.CompoundChains()
+	if len(chains) != 1 || chains[0].Name == nil || chains[0].Name.Text != "ethyl acetate" {
+		t.Fatalf("Expected the chain of `ethyl acetate` to be retained")
+	}
+
+	// `ethyl acetate` -> `ethyl formate` invalidates the name.
+	if _, err := doc.Edit("S1", 11, 7, "formate"); err != nil {
+		t.Fatalf("Failed to apply edit : %s", err.Error())
+	}
+	if n := len(doc.CompoundChains()); n != 0 {
+		t.Errorf("Expected chain count : 0, observed : %d", n)
+	}
+}
+
+//
+
+func TestEdit005(t *testing.T) {
+	in := "Aspirin (CC(=O)Oc1ccccc1C(=O)O) is dissolved in brine, i.e. [Na+].[Cl-] in water."
+	doc, _ := NewDocument("Edit005")
+	doc.SetInput("P", in)
+	doc.Tokenize()
+	doc.AssembleSentences()
+	doc.RecognizeLineNotations("P")
+
+	edits := []struct {
+		off, del int
+		ins      string
+	}{
+		{30, 0, " "},     // Right after the notation
+		{9, 0, "C"},      // Right before it
+		{69, 2, "Br"},    // Within another
+		{73, 0, ", CCO"}, // A new one, after it
+	}
+	for n, e := range edits {
+		if _, err := doc.Edit("P", e.off, e.del, e.ins); err != nil {
+			t.Fatalf("Failed to apply edit %d : %s", n, err.Error())
+		}
+
+		ref, _ := NewDocument("Edit005-ref")
+		nin, _ := doc.Input("P")
+		ref.SetInput("P", nin)
+		ref.Tokenize()
+		ref.AssembleSentences()
+		rws, _ := ref.RecognizeLineNotations("P")
+
+		toks, rtoks := doc.SectionTokens("P"), ref.SectionTokens("P")
+		if len(toks) != len(rtoks) {
+			t.Fatalf("Edit %d : expected token count : %d, observed : %d", n, len(rtoks), len(toks))
+		}
+		for i := range toks {
+			if *toks[i] != *rtoks[i] {
+				t.Fatalf("Edit %d : expected token : %v, observed : %v", n, *rtoks[i], *toks[i])
+			}
+		}
+		if c, _ := doc.SectionSentenceCount("P"); c != 1 {
+			t.Errorf("Edit %d : expected sentence count : 1, observed : %d", n, c)
+		}
+		ws, _ := doc.EnsureWords("P")
+		var nws []*Word
+		for _, w := range ws {
+			if w.Class() == ClassSMILES {
+				nws = append(nws, w)
+			}
+		}
+
+		if len(nws) != len(rws) {
+			t.Fatalf("Edit %d : expected notation word count : %d, observed : %d", n, len(rws), len(nws))
+		}
+		for i, w := range nws {
+			if w.Text() != rws[i].Text() || w.Begin() != rws[i].Begin() {
+				t.Errorf("Edit %d : expected notation word : %s, observed : %s", n, rws[i].Text(), w.Text())
+			}
+		}
+	}
+}
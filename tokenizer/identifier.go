@@ -0,0 +1,501 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// IdentifierType represents the kinds of registry and catalogue
+// identifiers that can be recognised in text.
+type IdentifierType byte
+
+// List of recognised identifier types.
+const (
+	IdUnknown    IdentifierType = iota
+	IdCAS                       // CAS Registry Number, e.g. 7550-45-0
+	IdEC                        // European Community number, e.g. 200-001-8
+	IdEnzyme                    // Enzyme Commission number, e.g. EC 3.4.21.4
+	IdInChIKey                  // Hashed InChI
+	IdPubChemCID                // PubChem compound identifier
+	IdCatalogue                 // Supplier catalogue number
+)
+
+// IdDescriptions helps in printing identifier types.  The
+// descriptions are also used as the classes of the corresponding
+// words.
+var IdDescriptions = map[IdentifierType]string{
+	IdUnknown:    "UNKNOWN",
+	IdCAS:        "CAS_RN",
+	IdEC:         "EC_NUMBER",
+	IdEnzyme:     "ENZYME_EC",
+	IdInChIKey:   "INCHIKEY",
+	IdPubChemCID: "PUBCHEM_CID",
+	IdCatalogue:  "CATALOGUE_NO",
+}
+
+// Identifier represents a registry or catalogue identifier recognised
+// in a section of a document.
+//
+// It holds the word that spans the identifier in the input text, the
+// type of the identifier, its normalised value, and whether the value
+// passed the validation applicable to its type (check digits,
+// structure, etc.).
+type Identifier struct {
+	word  *Word
+	itype IdentifierType
+	value string
+	valid bool
+}
+
+func (id *Identifier) Word() *Word {
+	return id.word
+}
+
+func (id *Identifier) Type() IdentifierType {
+	return id.itype
+}
+
+func (id *Identifier) Value() string {
+	return id.value
+}
+
+func (id *Identifier) Valid() bool {
+	return id.valid
+}
+
+// idMatch is the result of a successful match of an identifier
+// pattern over a sequence of tokens.
+type idMatch struct {
+	bidx  int // Index of the first token of the identifier proper
+	eidx  int // Index of the last token of the identifier proper
+	next  int // Index of the first token after the whole match
+	itype IdentifierType
+	value string
+	valid bool
+}
+
+// idMatchers lists the identifier patterns, in the order in which they
+// are attempted at each token.
+var idMatchers = []func([]*TextToken, int) (idMatch, bool){
+	matchInChIKey,
+	matchCAS,
+	matchECNumber,
+	matchEnzyme,
+	matchPubChemCID,
+	matchCatalogue,
+}
+
+// RecognizeIdentifiers detects CAS Registry Numbers, EC numbers,
+// enzyme numbers, InChIKeys, PubChem CIDs and catalogue numbers in
+// the tokens of the given section.
+//
+// Each recognised identifier is recorded as a `TokWord` word spanning
+// all of its constituent tokens, with its class set to the
+// description of its type.  The section must have been tokenized
+// already.
+func (d *Document) RecognizeIdentifiers(sec string) ([]*Identifier, error) {
+	toks, ok := d.tokens[sec]
+	if !ok {
+		return nil, fmt.Errorf("Unknown section : %s", sec)
+	}
+	inp := d.input[sec]
+
+	var ids []*Identifier
+	l := len(toks)
+	for i := 0; i < l; i++ {
+		for _, fn := range idMatchers {
+			m, ok := fn(toks, i)
+			if !ok {
+				continue
+			}
+
+			b, e := toks[m.bidx].Begin(), toks[m.eidx].End()
+			w := d.wordAt(sec, inp[b:e+1], b, e)
+			w.token.ttype = TokWord
+			w.class = IdDescriptions[m.itype]
+			ids = append(ids, &Identifier{w, m.itype, m.value, m.valid})
+
+			i = m.next - 1
+			break
+		}
+	}
+
+	d.idents[sec] = ids
+	return ids, nil
+}
+
+// SectionIdentifiers answers the identifiers recognised in the given
+// section.
+func (d *Document) SectionIdentifiers(sec string) []*Identifier {
+	if v, ok := d.idents[sec]; ok {
+		return v
+	}
+
+	return nil
+}
+
+//
+
+// ValidCASNumber answers if the given text is a well-formed CAS
+// Registry Number with a correct check digit.
+func ValidCASNumber(s string) bool {
+	parts := strings.Split(s, "-")
+	if len(parts) != 3 {
+		return false
+	}
+	if len(parts[0]) < 2 || len(parts[0]) > 7 || len(parts[1]) != 2 || len(parts[2]) != 1 {
+		return false
+	}
+	for _, p := range parts {
+		if !isASCIIDigits(p) {
+			return false
+		}
+	}
+
+	body := parts[0] + parts[1]
+	sum := 0
+	for i, n := 0, len(body); i < n; i++ {
+		sum += (n - i) * int(body[i]-'0')
+	}
+
+	return sum%10 == int(parts[2][0]-'0')
+}
+
+// ValidECNumber answers if the given text is a well-formed European
+// Community number (EINECS, ELINCS or NLP) with a correct check
+// digit.
+func ValidECNumber(s string) bool {
+	parts := strings.Split(s, "-")
+	if len(parts) != 3 {
+		return false
+	}
+	if len(parts[0]) != 3 || len(parts[1]) != 3 || len(parts[2]) != 1 {
+		return false
+	}
+	for _, p := range parts {
+		if !isASCIIDigits(p) {
+			return false
+		}
+	}
+
+	body := parts[0] + parts[1]
+	sum := 0
+	for i := 0; i < 6; i++ {
+		sum += (i + 1) * int(body[i]-'0')
+	}
+
+	return sum%11 == int(parts[2][0]-'0')
+}
+
+// ValidInChIKey answers if the given text is a structurally valid
+// InChIKey: 14 and 10 uppercase letters, followed by a single
+// protonation letter, all separated by hyphens.  The second block
+// must end with a valid standard/non-standard flag and version
+// letter.
+func ValidInChIKey(s string) bool {
+	parts := strings.Split(s, "-")
+	if len(parts) != 3 {
+		return false
+	}
+	if len(parts[0]) != 14 || len(parts[1]) != 10 || len(parts[2]) != 1 {
+		return false
+	}
+	for _, p := range parts {
+		if !isASCIIUpper(p) {
+			return false
+		}
+	}
+
+	flag := parts[1][8]
+	return (flag == 'S' || flag == 'N') && parts[1][9] == 'A'
+}
+
+//
+
+// matchCAS matches a CAS Registry Number split by the tokenizer into
+// five tokens: digits, hyphen, two digits, hyphen, one digit.
+func matchCAS(toks []*TextToken, i int) (idMatch, bool) {
+	parts, ok := hyphenatedParts(toks, i, 3, isASCIIDigits)
+	if !ok {
+		return idMatch{}, false
+	}
+	if len(parts[0]) < 2 || len(parts[0]) > 7 || len(parts[1]) != 2 || len(parts[2]) != 1 {
+		return idMatch{}, false
+	}
+
+	v := strings.Join(parts, "-")
+	return idMatch{i, i + 4, i + 5, IdCAS, v, ValidCASNumber(v)}, true
+}
+
+// matchECNumber matches a European Community number, optionally
+// prefixed with `EC`.
+func matchECNumber(toks []*TextToken, i int) (idMatch, bool) {
+	bidx := i
+	if j, ok := afterPrefix(toks, i, "ec"); ok {
+		bidx = j
+	}
+
+	parts, ok := hyphenatedParts(toks, bidx, 3, isASCIIDigits)
+	if !ok {
+		return idMatch{}, false
+	}
+	if len(parts[0]) != 3 || len(parts[1]) != 3 || len(parts[2]) != 1 {
+		return idMatch{}, false
+	}
+
+	v := strings.Join(parts, "-")
+	return idMatch{bidx, bidx + 4, bidx + 5, IdEC, v, ValidECNumber(v)}, true
+}
+
+// matchEnzyme matches an Enzyme Commission number, which must be
+// prefixed with `EC`.  Trailing components may be `-` or preliminary
+// numbers such as `n3`.
+func matchEnzyme(toks []*TextToken, i int) (idMatch, bool) {
+	bidx, ok := afterPrefix(toks, i, "ec")
+	if !ok {
+		return idMatch{}, false
+	}
+
+	l := len(toks)
+	var parts []string
+	j := bidx
+	for len(parts) < 4 && j < l {
+		if len(parts) > 0 {
+			if toks[j].Text() != "." || j+1 >= l {
+				break
+			}
+			j++
+		}
+
+		t := toks[j].Text()
+		switch {
+		case isASCIIDigits(t):
+			parts = append(parts, t)
+		case len(parts) > 0 && isHyphen(toks[j]):
+			parts = append(parts, "-")
+		case len(parts) > 0 && t == "n" && j+1 < l && isASCIIDigits(toks[j+1].Text()):
+			j++
+			parts = append(parts, "n"+toks[j].Text())
+		default:
+			return idMatch{}, false
+		}
+		j++
+	}
+	if len(parts) != 4 || !idBoundary(toks, j) {
+		return idMatch{}, false
+	}
+
+	valid := parts[0] >= "1" && parts[0] <= "7" && len(parts[0]) == 1
+	return idMatch{bidx, j - 1, j, IdEnzyme, strings.Join(parts, "."), valid}, true
+}
+
+// matchInChIKey matches an InChIKey.  Any `InChIKey=` before it is
+// not part of the identifier.
+func matchInChIKey(toks []*TextToken, i int) (idMatch, bool) {
+	parts, ok := hyphenatedParts(toks, i, 3, isASCIIUpper)
+	if !ok {
+		return idMatch{}, false
+	}
+	if len(parts[0]) != 14 || len(parts[1]) != 10 || len(parts[2]) != 1 {
+		return idMatch{}, false
+	}
+
+	v := strings.Join(parts, "-")
+	return idMatch{i, i + 4, i + 5, IdInChIKey, v, ValidInChIKey(v)}, true
+}
+
+// matchPubChemCID matches a PubChem compound identifier of the form
+// `CID 2244`, `CID: 2244` or `PubChem CID 2244`.
+func matchPubChemCID(toks []*TextToken, i int) (idMatch, bool) {
+	j := i
+	if k, ok := afterPrefix(toks, j, "pubchem"); ok {
+		j = k
+	}
+	if j >= len(toks) || toks[j].Text() != "CID" {
+		return idMatch{}, false
+	}
+	j = skipSeparators(toks, j+1)
+	if j >= len(toks) || !isASCIIDigits(toks[j].Text()) || !idBoundary(toks, j+1) {
+		return idMatch{}, false
+	}
+
+	v := strings.TrimLeft(toks[j].Text(), "0")
+	return idMatch{j, j, j + 1, IdPubChemCID, v, v != ""}, true
+}
+
+// matchCatalogue matches a supplier catalogue number introduced by one
+// of `Cat. No.`, `Catalog No.`, `Catalogue #`, `Cat#`, etc.  The
+// number itself is a hyphenated alphanumeric code that includes at
+// least one digit.
+func matchCatalogue(toks []*TextToken, i int) (idMatch, bool) {
+	l := len(toks)
+	switch strings.ToLower(toks[i].Text()) {
+	case "cat", "catalog", "catalogue":
+	default:
+		return idMatch{}, false
+	}
+
+	j := i + 1
+	if j < l && toks[j].Text() == "." {
+		j++
+	}
+	j = skipSpaces(toks, j)
+	if j < l && strings.ToLower(toks[j].Text()) == "no" {
+		j++
+		if j < l && toks[j].Text() == "." {
+			j++
+		}
+	} else if j < l && toks[j].Text() == "#" {
+		j++
+	} else {
+		return idMatch{}, false
+	}
+	j = skipSeparators(toks, j)
+
+	bidx := j
+	digit := false
+	for ; j < l; j++ {
+		t := toks[j]
+		if t.Type() == TokMayBeWord {
+			digit = digit || strings.IndexFunc(t.Text(), unicode.IsDigit) >= 0
+			continue
+		}
+		if isHyphen(t) && j+1 < l && toks[j+1].Type() == TokMayBeWord {
+			continue
+		}
+		break
+	}
+	if j == bidx || !digit {
+		return idMatch{}, false
+	}
+
+	var buf strings.Builder
+	for k := bidx; k < j; k++ {
+		if isHyphen(toks[k]) {
+			buf.WriteByte('-')
+		} else {
+			buf.WriteString(toks[k].Text())
+		}
+	}
+	return idMatch{bidx, j - 1, j, IdCatalogue, buf.String(), true}, true
+}
+
+//
+
+// hyphenatedParts collects `n` consecutive token texts that satisfy
+// `ok`, separated by single hyphen tokens, beginning at index `i`.
+// The tokens immediately before and after the sequence must not
+// continue it.
+func hyphenatedParts(toks []*TextToken, i, n int, ok func(string) bool) ([]string, bool) {
+	l := len(toks)
+	if i+2*n-1 > l || !idBoundary(toks, i-1) || !idBoundary(toks, i+2*n-1) {
+		return nil, false
+	}
+
+	parts := make([]string, 0, n)
+	for k := 0; k < n; k++ {
+		t := toks[i+2*k]
+		if t.Type() != TokMayBeWord || !ok(t.Text()) {
+			return nil, false
+		}
+		if k > 0 && !isHyphen(toks[i+2*k-1]) {
+			return nil, false
+		}
+		parts = append(parts, t.Text())
+	}
+
+	return parts, true
+}
+
+// afterPrefix answers the index of the token following the given
+// (case-insensitive) prefix word at index `i`, and any spaces,
+// colons or equals signs after it.
+func afterPrefix(toks []*TextToken, i int, prefix string) (int, bool) {
+	if i >= len(toks) || strings.ToLower(toks[i].Text()) != prefix {
+		return -1, false
+	}
+	if !idBoundary(toks, i-1) {
+		return -1, false
+	}
+
+	j := skipSeparators(toks, i+1)
+	if j == i+1 || j >= len(toks) {
+		return -1, false
+	}
+	return j, true
+}
+
+// skipSpaces answers the index of the first non-space token at or
+// after `i`.
+func skipSpaces(toks []*TextToken, i int) int {
+	for i < len(toks) && toks[i].Type() == TokSpace {
+		i++
+	}
+	return i
+}
+
+// skipSeparators answers the index of the first token at or after `i`
+// that is neither a space, nor one of `:`, `=` and `#`.
+func skipSeparators(toks []*TextToken, i int) int {
+	for ; i < len(toks); i++ {
+		switch toks[i].Text() {
+		case ":", "=", "#":
+			continue
+		}
+		if toks[i].Type() != TokSpace {
+			break
+		}
+	}
+	return i
+}
+
+// idBoundary answers if the token at the given index can delimit an
+// identifier, i.e. it is not a part of a longer hyphenated or
+// alphanumeric sequence.
+func idBoundary(toks []*TextToken, i int) bool {
+	if i < 0 || i >= len(toks) {
+		return true
+	}
+	t := toks[i]
+	return t.Type() != TokMayBeWord && !isHyphen(t)
+}
+
+// isHyphen answers if the given token is a hyphen or one of its
+// typographic variants.
+func isHyphen(t *TextToken) bool {
+	switch t.Text() {
+	case "-", "‐", "‑", "‒", "–":
+		return true
+	}
+	return false
+}
+
+func isASCIIDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIIUpper(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"testing"
+)
+
+func TestCASCheckDigit001(t *testing.T) {
+	valid := []string{"7550-45-0", "50-00-0", "7732-18-5", "64-17-5"}
+	for _, s := range valid {
+		if !ValidCASNumber(s) {
+			t.Errorf("Expected valid CAS RN : %s", s)
+		}
+	}
+
+	invalid := []string{"7550-45-1", "7550-4-0", "1-00-0", "12345678-00-0", "75a0-45-0"}
+	for _, s := range invalid {
+		if ValidCASNumber(s) {
+			t.Errorf("Expected invalid CAS RN : %s", s)
+		}
+	}
+}
+
+//
+
+func TestECAndInChIKey001(t *testing.T) {
+	if !ValidECNumber("200-001-8") {
+		t.Errorf("Expected valid EC number : %s", "200-001-8")
+	}
+	if ValidECNumber("200-001-7") {
+		t.Errorf("Expected invalid EC number : %s", "200-001-7")
+	}
+
+	if !ValidInChIKey("BSYNRYMUTXBXSQ-UHFFFAOYSA-N") {
+		t.Errorf("Expected valid InChIKey : %s", "BSYNRYMUTXBXSQ-UHFFFAOYSA-N")
+	}
+	if ValidInChIKey("BSYNRYMUTXBXSQ-UHFFFAOYXA-N") {
+		t.Errorf("Expected invalid InChIKey : %s", "BSYNRYMUTXBXSQ-UHFFFAOYXA-N")
+	}
+}
+
+//
+
+func TestRecognizeIdentifiers001(t *testing.T) {
+	in := "Titanium(IV) chloride (CAS 7550-45-0, EC 231-441-9) and aspirin " +
+		"(InChIKey=BSYNRYMUTXBXSQ-UHFFFAOYSA-N; PubChem CID 2244) were used. " +
+		"Trypsin (EC 3.4.21.4; Sigma Cat. No. T-1426) was added; the date 2015-10-19 is not an identifier."
+	doc, _ := NewDocument("Ident001")
+	doc.SetInput("P", in)
+	doc.Tokenize()
+
+	ids, err := doc.RecognizeIdentifiers("P")
+	if err != nil {
+		t.Fatalf("Failed to recognise identifiers : %s", err.Error())
+	}
+
+	exp := []struct {
+		itype IdentifierType
+		text  string
+		value string
+		valid bool
+	}{
+		{IdCAS, "7550-45-0", "7550-45-0", true},
+		{IdEC, "231-441-9", "231-441-9", true},
+		{IdInChIKey, "BSYNRYMUTXBXSQ-UHFFFAOYSA-N", "BSYNRYMUTXBXSQ-UHFFFAOYSA-N", true},
+		{IdPubChemCID, "2244", "2244", true},
+		{IdEnzyme, "3.4.21.4", "3.4.21.4", true},
+		{IdCatalogue, "T-1426", "T-1426", true},
+	}
+	if len(ids) != len(exp) {
+		t.Fatalf("Expected identifier count : %d, observed : %d", len(exp), len(ids))
+	}
+	for i, e := range exp {
+		id := ids[i]
+		w := id.Word()
+		if id.Type() != e.itype || w.Text() != e.text || id.Value() != e.value || id.Valid() != e.valid {
+			t.Errorf("Expected : %v, observed : %s %s %s %v", e, IdDescriptions[id.Type()], w.Text(), id.Value(), id.Valid())
+		}
+		if in[w.Begin():w.End()+1] != w.Text() {
+			t.Errorf("Word span mismatch for %s : %d:%d", w.Text(), w.Begin(), w.End())
+		}
+		if w.Type() != TokWord || w.Class() != IdDescriptions[e.itype] {
+			t.Errorf("Unexpected word type or class : %d, %s", w.Type(), w.Class())
+		}
+	}
+
+	c, _ := doc.SectionWordCount("P")
+	if c != len(exp) {
+		t.Errorf("Expected word count : %d, observed : %d", len(exp), c)
+	}
+}
+
+//
+
+func TestRecognizeIdentifiers002(t *testing.T) {
+	doc, _ := NewDocument("Ident002")
+	doc.SetInput("P", "The reagent (CAS RN 7550-45-1) is listed with a wrong check digit.")
+	doc.Tokenize()
+
+	ids, _ := doc.RecognizeIdentifiers("P")
+	if len(ids) != 1 {
+		t.Fatalf("Expected identifier count : 1, observed : %d", len(ids))
+	}
+	if ids[0].Type() != IdCAS || ids[0].Valid() {
+		t.Errorf("Expected an invalid CAS RN, observed : %s %v", IdDescriptions[ids[0].Type()], ids[0].Valid())
+	}
+
+	if _, err := doc.RecognizeIdentifiers("Q"); err == nil {
+		t.Errorf("Expected an error for an unknown section")
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"encoding/json"
+)
+
+// The types in this file define the JSON form of a document.  Offsets
+// are local to their sections; `offset` gives the global offset of
+// the beginning of a section.  Token types are given by their
+// descriptions.
+
+type jsonToken struct {
+	Text  string `json:"text"`
+	Begin int    `json:"begin"`
+	End   int    `json:"end"`
+	Type  string `json:"type"`
+}
+
+type jsonGroup struct {
+	Type       string      `json:"type"`
+	Begin      int         `json:"begin"`
+	End        int         `json:"end"`
+	BeginToken int         `json:"begin_token"`
+	EndToken   int         `json:"end_token"`
+	Closed     bool        `json:"closed"`
+	Children   []jsonGroup `json:"children,omitempty"`
+}
+
+type jsonGroupWarning struct {
+	Token int    `json:"token"`
+	Begin int    `json:"begin"`
+	Text  string `json:"text"`
+	Kind  string `json:"kind"`
+}
+
+type jsonSentence struct {
+	Text       string             `json:"text"`
+	Begin      int                `json:"begin"`
+	End        int                `json:"end"`
+	BeginToken int                `json:"begin_token"`
+	EndToken   int                `json:"end_token"`
+	Groups     []jsonGroup        `json:"groups,omitempty"`
+	Quotes     []jsonGroup        `json:"quotes,omitempty"`
+	Warnings   []jsonGroupWarning `json:"warnings,omitempty"`
+}
+
+// jsonGroups answers the JSON forms of the given groups.
+func jsonGroups(gs []*Group) []jsonGroup {
+	var res []jsonGroup
+	for _, g := range gs {
+		res = append(res, jsonGroup{TtDescriptions[g.gtype], g.begin, g.end, g.bTokIdx, g.eTokIdx,
+			g.closed, jsonGroups(g.children)})
+	}
+	return res
+}
+
+type jsonWord struct {
+	Text  string  `json:"text"`
+	Begin int     `json:"begin"`
+	End   int     `json:"end"`
+	Type  string  `json:"type"`
+	IOB   string  `json:"iob"`
+	POS   string  `json:"pos,omitempty"`
+	Lemma string  `json:"lemma,omitempty"`
+	Class string  `json:"class,omitempty"`
+	Conf  float64 `json:"confidence,omitempty"`
+}
+
+type jsonAnnotation struct {
+	Begin    int    `json:"begin"`
+	End      int    `json:"end"`
+	Entity   string `json:"entity"`
+	Property string `json:"property"`
+}
+
+type jsonSection struct {
+	Name        string           `json:"name"`
+	Offset      int              `json:"offset"`
+	Text        string           `json:"text"`
+	Tokens      []jsonToken      `json:"tokens,omitempty"`
+	Sentences   []jsonSentence   `json:"sentences,omitempty"`
+	Words       []jsonWord       `json:"words,omitempty"`
+	Annotations []jsonAnnotation `json:"annotations,omitempty"`
+}
+
+type jsonDocument struct {
+	ID        string        `json:"id"`
+	Technical bool          `json:"technical"`
+	Language  string        `json:"language,omitempty"`
+	Sections  []jsonSection `json:"sections"`
+}
+
+// MarshalJSON answers the JSON form of the document: its sections in
+// document order, with their texts and whatever tokens, sentences,
+// words and annotations they hold.
+func (d *Document) MarshalJSON() ([]byte, error) {
+	jd := jsonDocument{ID: d.id, Technical: d.isTech, Sections: []jsonSection{}}
+	if d.lang != nil {
+		jd.Language = d.lang.Code
+	}
+	for _, sec := range d.secs {
+		off, _ := d.SectionOffset(sec)
+		js := jsonSection{Name: sec, Offset: off, Text: d.input[sec]}
+		for _, t := range d.tokens[sec] {
+			js.Tokens = append(js.Tokens, jsonToken{t.text, t.begin, t.end, TtDescriptions[t.ttype]})
+		}
+		for _, s := range d.sents[sec] {
+			jst := jsonSentence{s.Text(), s.Begin(), s.End(), s.bTokIdx, s.eTokIdx, jsonGroups(s.groups),
+				jsonGroups(s.quotes), nil}
+			for _, gw := range s.warnings {
+				jst.Warnings = append(jst.Warnings, jsonGroupWarning{gw.Token, gw.Begin, gw.Text, GwDescriptions[gw.Kind]})
+			}
+			js.Sentences = append(js.Sentences, jst)
+		}
+		for _, w := range d.words[sec] {
+			js.Words = append(js.Words, jsonWord{w.Text(), w.Begin(), w.End(), TtDescriptions[w.Type()],
+				string(w.iob), w.pos, w.lemma, w.class, w.conf})
+		}
+		for _, a := range d.annos[sec] {
+			js.Annotations = append(js.Annotations, jsonAnnotation{a.Begin, a.End, a.Entity, a.Property})
+		}
+		jd.Sections = append(jd.Sections, js)
+	}
+
+	return json.Marshal(jd)
+}
@@ -0,0 +1,298 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// LanguagePack groups the conventions of a language that sentence
+// assembly depends upon.
+//
+// `Terminators` lists marks that end sentences in the language, in
+// addition to those that `RuneType` recognises.  `Quotes` maps opening
+// quotation marks to their possible closing ones; it is consulted in
+// quote-aware mode.  `Starts` decides what text following a
+// terminator and space begins a sentence; it is `CasedStarts` if
+// `nil`.  A language that is not `Spaced` begins sentences right
+// after terminators.  In languages with `OrdinalFullStop`, a full stop
+// after a number marks an ordinal: `am 3. Oktober`.
+type LanguagePack struct {
+	Code            string // ISO 639-1
+	Name            string
+	Terminators     map[string]struct{}
+	Abbrevs         *AbbrevSet
+	Quotes          map[string]string
+	Starts          StartClassifier
+	Spaced          bool
+	OrdinalFullStop bool
+}
+
+// languagesMu guards the registry of language packs, which may be
+// extended while documents are being processed.
+var languagesMu sync.RWMutex
+
+// languages holds the registered language packs, by their codes.
+var languages = map[string]*LanguagePack{}
+
+// RegisterLanguage makes the given language pack available under its
+// code, replacing any existing one.  It is safe for concurrent use.
+func RegisterLanguage(p *LanguagePack) {
+	languagesMu.Lock()
+	defer languagesMu.Unlock()
+	languages[p.Code] = p
+}
+
+// Language answers the language pack registered under the given code,
+// or `nil` if none.
+func Language(code string) *LanguagePack {
+	languagesMu.RLock()
+	defer languagesMu.RUnlock()
+	return languages[strings.ToLower(code)]
+}
+
+// LanguageCodes answers the codes of the registered language packs,
+// in sorted order.
+func LanguageCodes() []string {
+	languagesMu.RLock()
+	defer languagesMu.RUnlock()
+	res := make([]string, 0, len(languages))
+	for code := range languages {
+		res = append(res, code)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// newAbbrevSet answers an abbreviation set with the given tables.
+// Non-terminating abbreviations are given without their full stops.
+// Group abbreviations are given as `i.e`, `z.B`, etc.
+func newAbbrevSet(nonTerm, mayBeTerm, group []string) *AbbrevSet {
+	a := &AbbrevSet{
+		NonTerm:   make(map[string]struct{}, len(nonTerm)),
+		MayBeTerm: make(map[string]struct{}, len(mayBeTerm)),
+		Group:     make(map[string][]string, len(group)),
+	}
+	for _, s := range nonTerm {
+		a.NonTerm[strings.ToLower(s)] = struct{}{}
+	}
+	for _, s := range mayBeTerm {
+		a.MayBeTerm[strings.ToLower(s)] = struct{}{}
+	}
+	for _, s := range group {
+		parts := strings.Split(strings.ToLower(s), ".")
+		l := len(parts)
+		var prev []string
+		for i := l - 2; i >= 0; i-- {
+			prev = append(prev, parts[i])
+		}
+		a.Group[parts[l-1]] = prev
+	}
+	return a
+}
+
+func init() {
+	RegisterLanguage(&LanguagePack{
+		Code:    "en",
+		Name:    "English",
+		Abbrevs: DefaultAbbrevs(),
+		Quotes:  quotePairs,
+		Spaced:  true,
+	})
+
+	RegisterLanguage(&LanguagePack{
+		Code:    "te",
+		Name:    "Telugu",
+		Abbrevs: newAbbrevSet([]string{"డా", "శ్రీ", "శ్రీమతి"}, nil, nil),
+		Quotes:  quotePairs,
+		Starts:  ScriptStarts,
+		Spaced:  true,
+	})
+
+	RegisterLanguage(&LanguagePack{
+		Code: "hi",
+		Name: "Hindi",
+		Terminators: map[string]struct{}{
+			"|": {}, // Common stand-in for the danda
+		},
+		Abbrevs: newAbbrevSet([]string{"डॉ", "श्री", "श्रीमती", "प्रो", "सं"}, nil, nil),
+		Quotes:  quotePairs,
+		Starts:  ScriptStarts,
+		Spaced:  true,
+	})
+
+	RegisterLanguage(&LanguagePack{
+		Code:    "zh",
+		Name:    "Chinese",
+		Abbrevs: newAbbrevSet(nil, nil, nil),
+		Quotes: map[string]string{
+			"\u201c": "\u201d", // “ ”
+			"\u2018": "\u2019", // ‘ ’
+			"\u300c": "\u300d", // 「 」
+			"\u300e": "\u300f", // 『 』
+			"\u300a": "\u300b", // 《 》
+		},
+		Starts: ScriptStarts,
+	})
+
+	RegisterLanguage(&LanguagePack{
+		Code: "ja",
+		Name: "Japanese",
+		Terminators: map[string]struct{}{
+			"\uff61": {}, // ｡ Half width ideographic full stop
+		},
+		Abbrevs: newAbbrevSet(nil, nil, nil),
+		Quotes: map[string]string{
+			"\u300c": "\u300d",       // 「 」
+			"\u300e": "\u300f",       // 『 』
+			"\u301d": "\u301e\u301f", // 〝 〞 or 〟
+			"\u201c": "\u201d",       // “ ”
+		},
+		Starts: ScriptStarts,
+	})
+
+	RegisterLanguage(&LanguagePack{
+		Code: "de",
+		Name: "German",
+		Abbrevs: newAbbrevSet(
+			[]string{"bzw", "ca", "dr", "evtl", "ggf", "nr", "prof", "s", "sog", "vgl", "abb", "tab", "gew", "std", "min"},
+			[]string{"usw", "etc"},
+			[]string{"z.b", "d.h", "u.a", "o.a", "i.a"}),
+		Quotes: map[string]string{
+			"\u201e": "\u201c", // „ “
+			"\u201a": "\u2018", // ‚ ‘
+			"\u00bb": "\u00ab", // » «
+			"\u203a": "\u2039", // › ‹
+			"\"":     "\"",
+		},
+		Spaced:          true,
+		OrdinalFullStop: true,
+	})
+
+	RegisterLanguage(&LanguagePack{
+		Code: "fr",
+		Name: "French",
+		Abbrevs: newAbbrevSet(
+			[]string{"m", "mme", "mlle", "mm", "dr", "pr", "cf", "env", "fig", "vol", "éd", "réf"},
+			[]string{"etc"},
+			[]string{"p.ex"}),
+		Quotes: map[string]string{
+			"\u00ab": "\u00bb", // « »
+			"\u2039": "\u203a", // ‹ ›
+			"\u201c": "\u201d", // “ ”
+			"\"":     "\"",
+		},
+		Spaced: true,
+	})
+}
+
+// SetLanguage makes the iterator follow the conventions of the given
+// language pack.  Abbreviations set explicitly take precedence over
+// those of the pack.
+func (si *SentenceIterator) SetLanguage(p *LanguagePack) {
+	si.lang = p
+}
+
+// tokenType answers the type of the given token for sentence
+// assembly: language-specific terminators are treated as `TokTerm`.
+func (si *SentenceIterator) tokenType(t *TextToken) TokenType {
+	if si.lang != nil && si.lang.Terminators != nil {
+		if _, ok := si.lang.Terminators[t.text]; ok {
+			return TokTerm
+		}
+	}
+	return t.ttype
+}
+
+// abbreviations answers the abbreviation set in effect.
+func (si *SentenceIterator) abbreviations() *AbbrevSet {
+	switch {
+	case si.abbrevs != nil:
+		return si.abbrevs
+	case si.lang != nil && si.lang.Abbrevs != nil:
+		return si.lang.Abbrevs
+	}
+	return DefaultAbbrevs()
+}
+
+// pairs answers the quotation conventions in effect.
+func (si *SentenceIterator) pairs() map[string]string {
+	if si.lang != nil && si.lang.Quotes != nil {
+		return si.lang.Quotes
+	}
+	return quotePairs
+}
+
+// spaced answers if sentences of the text are separated by space.
+func (si *SentenceIterator) spaced() bool {
+	return si.lang == nil || si.lang.Spaced
+}
+
+// isOrdinal answers if the full stop at the given index, preceded by
+// the token at index `pidx`, marks an ordinal number.
+func (si *SentenceIterator) isOrdinal(idx, pidx int) bool {
+	if si.lang == nil || !si.lang.OrdinalFullStop || si.toks[idx].ttype != TokMayBeTerm || pidx != idx-1 {
+		return false
+	}
+	for _, r := range si.toks[pidx].text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isClosing answers if the given token closes a group or a quotation.
+func isClosing(t *TextToken) bool {
+	for _, r := range t.text {
+		return unicode.Is(unicode.Pe, r) || unicode.Is(unicode.Pf, r)
+	}
+	return false
+}
+
+// startsGroupAbbrev answers if the token at the given index, which
+// precedes a full stop, begins a multi-token abbreviation spelt with
+// space: `z. B.`, `p. ex.`.  It always answers `false` in the absence
+// of a language pack.
+func (si *SentenceIterator) startsGroupAbbrev(pidx int, a *AbbrevSet) bool {
+	if si.lang == nil {
+		return false
+	}
+
+	prev := strings.ToLower(si.toks[pidx].text)
+	for last, grp := range a.Group {
+		l := len(grp)
+		if grp[l-1] != prev {
+			continue
+		}
+		parts := make([]string, 0, l)
+		for i := l - 2; i >= 0; i-- {
+			parts = append(parts, grp[i])
+		}
+		parts = append(parts, last)
+
+		k, ok := pidx+1, true
+		for _, p := range parts {
+			k = si.nextNonSpaceToken(k)
+			if k == -1 || strings.ToLower(si.toks[k].text) != p {
+				ok = false
+				break
+			}
+			k = si.nextNonSpaceToken(k)
+			if k == -1 || si.toks[k].ttype != TokMayBeTerm {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// withLanguage answers a setup of sentence iterators following the
+// given language pack, with the given options.
+func withLanguage(lang *LanguagePack, o SentenceOptions) func(si *SentenceIterator) {
+	return func(si *SentenceIterator) {
+		si.SetLanguage(lang)
+		si.SetOptions(o)
+	}
+}
+
+func checkSentences(t *testing.T, code string, exp, obs []string) {
+	if len(obs) != len(exp) {
+		t.Fatalf("%s : expected sentence count : %d, observed : %d : %q", code, len(exp), len(obs), obs)
+	}
+	for i := range exp {
+		if obs[i] != exp[i] {
+			t.Errorf("%s : expected sentence : %q, observed : %q", code, exp[i], obs[i])
+		}
+	}
+}
+
+//
+
+func TestLanguage001(t *testing.T) {
+	exp := []string{"ar", "de", "en", "fr", "hi", "ja", "te", "zh"}
+	for _, code := range exp[1:] {
+		if Language(code) == nil {
+			t.Errorf("Expected a language pack for : %s", code)
+		}
+	}
+	if Language("xx") != nil {
+		t.Errorf("Expected no language pack for : xx")
+	}
+
+	RegisterLanguage(&LanguagePack{Code: "ar", Name: "Arabic", Starts: ScriptStarts, Spaced: true})
+	defer delete(languages, "ar")
+	codes := LanguageCodes()
+	if len(codes) != len(exp) {
+		t.Fatalf("Expected language count : %d, observed : %d", len(exp), len(codes))
+	}
+	for i := range exp {
+		if codes[i] != exp[i] {
+			t.Errorf("Expected language : %s, observed : %s", exp[i], codes[i])
+		}
+	}
+
+	obs := sentenceTexts("هل هو نقي؟ نعم۔ تم التقطير؟", withLanguage(Language("ar"), SentenceOptions{}))
+	checkSentences(t, "ar", []string{"هل هو نقي؟", "نعم۔", "تم التقطير؟"}, obs)
+}
+
+//
+
+func TestLanguageTelugu001(t *testing.T) {
+	in := "ఇది మొదటి వాక్యం. ఇది రెండవది! డా. రావు 3.5 గ్రాములు వాడారు."
+
+	if obs := sentenceTexts(in, nil); len(obs) != 1 {
+		t.Errorf("Expected sentence count without a language pack : 1, observed : %d", len(obs))
+	}
+	obs := sentenceTexts(in, withLanguage(Language("te"), SentenceOptions{}))
+	checkSentences(t, "te", []string{"ఇది మొదటి వాక్యం.", "ఇది రెండవది!", "డా. రావు 3.5 గ్రాములు వాడారు."}, obs)
+}
+
+//
+
+func TestLanguageHindi001(t *testing.T) {
+	in := "यह पहला वाक्य है। यह दूसरा है| डॉ. शर्मा आए॥"
+
+	obs := sentenceTexts(in, withLanguage(Language("hi"), SentenceOptions{}))
+	checkSentences(t, "hi", []string{"यह पहला वाक्य है।", "यह दूसरा है|", "डॉ. शर्मा आए॥"}, obs)
+}
+
+//
+
+func TestLanguageChinese001(t *testing.T) {
+	in := "苯是无色液体。它易燃！他说：“小心。”然后离开了（约3.5小时）。"
+
+	if obs := sentenceTexts(in, nil); len(obs) != 2 {
+		t.Errorf("Expected sentence count without a language pack : 2, observed : %d : %q", len(obs), obs)
+	}
+	exp := []string{"苯是无色液体。", "它易燃！", "他说：“小心。”", "然后离开了（约3.5小时）。"}
+	checkSentences(t, "zh", exp, sentenceTexts(in, withLanguage(Language("zh"), SentenceOptions{})))
+	checkSentences(t, "zh", exp, sentenceTexts(in, withLanguage(Language("zh"), SentenceOptions{QuoteAware: true})))
+}
+
+//
+
+func TestLanguageJapanese001(t *testing.T) {
+	in := "ベンゼンを加えた。彼は「終わり」と言った｡次へ進む（約２時間）。"
+
+	obs := sentenceTexts(in, withLanguage(Language("ja"), SentenceOptions{QuoteAware: true}))
+	checkSentences(t, "ja", []string{"ベンゼンを加えた。", "彼は「終わり」と言った｡", "次へ進む（約２時間）。"}, obs)
+
+	in = "彼は「止まれ。今だ。」と言った。『はい。』トルエンを加えた。"
+	obs = sentenceTexts(in, withLanguage(Language("ja"), SentenceOptions{QuoteAware: true}))
+	checkSentences(t, "ja", []string{"彼は「止まれ。今だ。」と言った。", "『はい。』トルエンを加えた。"}, obs)
+}
+
+//
+
+func TestLanguageGerman001(t *testing.T) {
+	in := "Die Reaktion lief am 3. Oktober ab. Das Produkt, z. B. Benzol, wurde bzw. wird destilliert. " +
+		"Er sagte: „Fertig.“ Dann rief er: »Halt. Sofort.« Danach war Ruhe."
+
+	obs := sentenceTexts(in, withLanguage(Language("en"), SentenceOptions{QuoteAware: true}))
+	if len(obs) != 9 {
+		t.Errorf("Expected sentence count with the English pack : 9, observed : %d : %q", len(obs), obs)
+	}
+
+	doc, _ := NewDocument("German001")
+	doc.SetLanguage(Language("de"))
+	doc.SetSentenceOptions(SentenceOptions{QuoteAware: true})
+	doc.SetInput("P", in)
+	doc.Tokenize()
+	doc.AssembleSentences()
+	obs = nil
+	for _, s := range doc.SectionSentences("P") {
+		obs = append(obs, in[s.Begin():s.End()+1])
+	}
+	checkSentences(t, "de", []string{
+		"Die Reaktion lief am 3. Oktober ab.",
+		"Das Produkt, z. B. Benzol, wurde bzw. wird destilliert.",
+		"Er sagte: „Fertig.“",
+		"Dann rief er: »Halt. Sofort.«",
+		"Danach war Ruhe.",
+	}, obs)
+}
+
+//
+
+func TestLanguageFrench001(t *testing.T) {
+	in := "M. Dupont a dit : « Arrêtez. Tout de suite. » Puis il est parti. " +
+		"Voir p. ex. Fig. 2 etc. C'est l'acide fini."
+
+	obs := sentenceTexts(in, withLanguage(Language("fr"), SentenceOptions{QuoteAware: true}))
+	checkSentences(t, "fr", []string{
+		"M. Dupont a dit : « Arrêtez. Tout de suite. »",
+		"Puis il est parti.",
+		"Voir p. ex. Fig. 2 etc.",
+		"C'est l'acide fini.",
+	}, obs)
+}
+
+//
+
+func TestLanguage002(t *testing.T) {
+	// Language packs may be registered while others are looked up.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		code := fmt.Sprintf("x%d", i)
+		go func() {
+			defer wg.Done()
+			RegisterLanguage(&LanguagePack{Code: code, Name: "Test", Spaced: true})
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if Language("en") == nil {
+					t.Errorf("Expected a language pack for : en")
+				}
+				LanguageCodes()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 8; i++ {
+		code := fmt.Sprintf("x%d", i)
+		if Language(code) == nil {
+			t.Errorf("Expected a language pack for : %s", code)
+		}
+		delete(languages, code)
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BlockType represents the types of layout blocks that can be
+// detected in input text.
+type BlockType byte
+
+// List of layout block types.
+const (
+	BlockParagraph BlockType = iota
+	BlockHeading             // `2. Notes`, `Working with Hazardous Chemicals`
+	BlockStep                // Procedure step heading: `A. 5-(1-...)-dione (1)`
+	BlockListItem            // `1. ...`, `(a) ...`, `• ...`
+	BlockTable               // `Table 1 ...` up to a blank line
+)
+
+// BtDescriptions helps in printing block types.
+var BtDescriptions = map[BlockType]string{
+	BlockParagraph: "BlockParagraph",
+	BlockHeading:   "BlockHeading",
+	BlockStep:      "BlockStep",
+	BlockListItem:  "BlockListItem",
+	BlockTable:     "BlockTable",
+}
+
+// blockRanks determine nesting: a block nests inside the nearest
+// preceding block of a lower rank.
+var blockRanks = map[BlockType]int{
+	BlockHeading:   1,
+	BlockStep:      2,
+	BlockParagraph: 3,
+	BlockListItem:  3,
+	BlockTable:     3,
+}
+
+// Block represents a logical unit of layout in the input text of a
+// section: a paragraph, heading, procedure step, list item or table.
+//
+// It holds its offsets in the input text, its label (`A`, `2`, `a`,
+// `Table 1`), if any, and its position in the hierarchy of blocks.
+// Paragraphs, list items and tables nest under the preceding step or
+// heading; steps nest under the preceding heading.
+type Block struct {
+	btype    BlockType
+	begin    int
+	end      int
+	label    string
+	level    int
+	parent   *Block
+	children []*Block
+}
+
+func (b *Block) Type() BlockType {
+	return b.btype
+}
+
+func (b *Block) Begin() int {
+	return b.begin
+}
+
+func (b *Block) End() int {
+	return b.end
+}
+
+func (b *Block) Label() string {
+	return b.label
+}
+
+func (b *Block) Level() int {
+	return b.level
+}
+
+func (b *Block) Parent() *Block {
+	return b.parent
+}
+
+func (b *Block) Children() []*Block {
+	return b.children
+}
+
+var (
+	stepRe       = regexp.MustCompile(`^(?:Step\s+(\d+|[A-Z])[.:]|([A-Z])\.)\s+\S`)
+	stepTitleRe  = regexp.MustCompile(`\(\d+[a-z]?\)(?:\.\d+(?:[,–-]\d+)*)?`)
+	stepSentRe   = regexp.MustCompile(`[.!?]\s+\p{Lu}`)
+	numberedRe   = regexp.MustCompile(`^(\d+)[.)]\s+\S`)
+	listItemRe   = regexp.MustCompile(`^(?:\(([a-z0-9]{1,3})\)|([a-z])[.)]|([•◦▪‣*–-]))\s+\S`)
+	tableRe      = regexp.MustCompile(`^(Table\s+\d+[A-Za-z]?)\b`)
+	maxHeadWords = 10
+	maxHeadLen   = 80
+	minWrapLen   = 40 // Shorter lines are not wrapped, but complete
+)
+
+// layoutLine is a line of input, with the offsets of its first and
+// last non-space characters.
+type layoutLine struct {
+	text   string
+	begin  int
+	end    int
+	blank  bool
+	indent bool
+}
+
+// DetectLayout infers the layout of the input text of the given
+// section: paragraphs (separated by blank lines or indentation, or
+// ending with terminators or short lines), headings, procedure steps,
+// enumerated list items and tables.  It answers the top-level blocks.
+//
+// Sentence assembly is made to begin a new sentence with every block,
+// and headings and step titles are protected against sentence breaks.
+// Should the sentences of the section have been assembled already,
+// they are re-assembled.
+func (d *Document) DetectLayout(sec string) ([]*Block, error) {
+	inp, ok := d.input[sec]
+	if !ok {
+		return nil, fmt.Errorf("Unknown section : %s", sec)
+	}
+
+	var blocks []*Block
+	var cur *Block // Open paragraph or table, if any
+	prevLen := 0
+	for _, ln := range layoutLines(inp) {
+		wrapped := prevLen >= minWrapLen
+		prevLen = len(ln.text)
+		if ln.blank {
+			cur = nil
+			continue
+		}
+
+		if cur != nil && !ln.indent && !startsBlock(ln.text) &&
+			(wrapped || cur.btype == BlockTable) {
+			cur.end = ln.end
+			if cur.btype == BlockParagraph && endsParagraph(ln.text) {
+				cur = nil
+			}
+			continue
+		}
+
+		cur = nil
+		for _, b := range classifyLine(ln) {
+			blocks = append(blocks, b)
+			if (b.btype == BlockParagraph && !endsParagraph(inp[b.begin:b.end+1])) ||
+				b.btype == BlockTable {
+				cur = b
+			}
+		}
+	}
+
+	var roots []*Block
+	var stack []*Block
+	d.breaks[sec] = d.breaks[sec][:0]
+	d.unprotectLayout(sec)
+	for _, b := range blocks {
+		for len(stack) > 0 && blockRanks[stack[len(stack)-1].btype] >= blockRanks[b.btype] {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, b)
+		} else {
+			p := stack[len(stack)-1]
+			b.parent = p
+			b.level = p.level + 1
+			p.children = append(p.children, b)
+		}
+		stack = append(stack, b)
+
+		d.breaks[sec] = append(d.breaks[sec], b.begin)
+		if b.btype == BlockHeading || b.btype == BlockStep {
+			d.protect(sec, b.begin, b.end)
+		}
+	}
+
+	d.layout[sec] = roots
+	if _, ok := d.sents[sec]; ok {
+		d.assembleSection(sec)
+	}
+	return roots, nil
+}
+
+// unprotectLayout removes the protections of the headings and step
+// titles of the layout detected earlier in the given section, if any.
+func (d *Document) unprotectLayout(sec string) {
+	old := make(map[span]bool)
+	var walk func(bs []*Block)
+	walk = func(bs []*Block) {
+		for _, b := range bs {
+			if b.btype == BlockHeading || b.btype == BlockStep {
+				old[span{b.begin, b.end}] = true
+			}
+			walk(b.children)
+		}
+	}
+	walk(d.layout[sec])
+
+	var prots []span
+	for _, p := range d.prots[sec] {
+		if !old[p] {
+			prots = append(prots, p)
+		}
+	}
+	d.prots[sec] = prots
+}
+
+// SectionLayout answers the top-level layout blocks detected in the
+// given section.
+func (d *Document) SectionLayout(sec string) []*Block {
+	if v, ok := d.layout[sec]; ok {
+		return v
+	}
+
+	return nil
+}
+
+// layoutLines splits the given input into lines.
+func layoutLines(inp string) []layoutLine {
+	var res []layoutLine
+	off := 0
+	for _, raw := range strings.SplitAfter(inp, "\n") {
+		text := strings.TrimRightFunc(raw, unicode.IsSpace)
+		trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+		ln := layoutLine{text: trimmed, blank: trimmed == ""}
+		if !ln.blank {
+			ln.begin = off + len(text) - len(trimmed)
+			ln.end = off + len(text) - 1
+			ln.indent = ln.begin > off
+		}
+		res = append(res, ln)
+		off += len(raw)
+	}
+	return res
+}
+
+// classifyLine answers the blocks that begin on the given line.  A
+// step title is followed by the paragraph on the same line, if any.
+func classifyLine(ln layoutLine) []*Block {
+	text := ln.text
+
+	if m := stepRe.FindStringSubmatch(text); m != nil {
+		label := m[1] + m[2]
+		hend := stepTitleEnd(text, strings.IndexAny(text, ".:")+1)
+		step := &Block{btype: BlockStep, begin: ln.begin, end: ln.begin + hend - 1, label: label}
+		rest := strings.TrimLeftFunc(text[hend:], unicode.IsSpace)
+		if rest == "" {
+			step.end = ln.end
+			return []*Block{step}
+		}
+		para := &Block{btype: BlockParagraph, begin: ln.begin + len(text) - len(rest), end: ln.end}
+		return []*Block{step, para}
+	}
+
+	if m := tableRe.FindStringSubmatch(text); m != nil {
+		return []*Block{{btype: BlockTable, begin: ln.begin, end: ln.end, label: m[1]}}
+	}
+
+	if m := numberedRe.FindStringSubmatch(text); m != nil {
+		rest := strings.TrimLeftFunc(text[len(m[1])+1:], unicode.IsSpace)
+		if isHeadingText(rest) {
+			return []*Block{{btype: BlockHeading, begin: ln.begin, end: ln.end, label: m[1]}}
+		}
+		return []*Block{{btype: BlockListItem, begin: ln.begin, end: ln.end, label: m[1]}}
+	}
+
+	if m := listItemRe.FindStringSubmatch(text); m != nil {
+		return []*Block{{btype: BlockListItem, begin: ln.begin, end: ln.end, label: m[1] + m[2] + m[3]}}
+	}
+
+	if isHeadingText(text) {
+		return []*Block{{btype: BlockHeading, begin: ln.begin, end: ln.end}}
+	}
+
+	return []*Block{{btype: BlockParagraph, begin: ln.begin, end: ln.end}}
+}
+
+// stepTitleEnd answers the length of the title of a procedure step
+// that begins the given line, whose label is of the given length: up
+// to its compound label and any glued citations, or up to the end of
+// its first sentence after the label.
+func stepTitleEnd(text string, lend int) int {
+	if loc := stepTitleRe.FindStringIndex(text); loc != nil {
+		return loc[1]
+	}
+	if loc := stepSentRe.FindStringIndex(text[lend:]); loc != nil {
+		return lend + loc[0] + 1
+	}
+	return len(text)
+}
+
+// startsBlock answers if the given line begins a new block by its
+// form alone.
+func startsBlock(text string) bool {
+	return stepRe.MatchString(text) || tableRe.MatchString(text) ||
+		numberedRe.MatchString(text) || listItemRe.MatchString(text)
+}
+
+// endsParagraph answers if the given text ends with a terminator,
+// suggesting that the next line begins a new paragraph.
+func endsParagraph(text string) bool {
+	r, _ := utf8.DecodeLastRuneInString(text)
+	switch RuneType(r) {
+	case TokTerm, TokMayBeTerm:
+		return true
+	}
+	return r == ':' || r == ']' || r == ')'
+}
+
+// isHeadingText answers if the given line is short, begins with an
+// uppercase letter or digit, and has no sentence punctuation.
+func isHeadingText(text string) bool {
+	if len(text) > maxHeadLen || len(strings.Fields(text)) > maxHeadWords {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(text)
+	if !unicode.IsUpper(r) && !unicode.IsDigit(r) {
+		return false
+	}
+	return !strings.ContainsAny(text, ".,;!?")
+}
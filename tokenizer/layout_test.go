@@ -0,0 +1,125 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"testing"
+)
+
+func TestLayout001(t *testing.T) {
+	in := "1. Procedure\n" +
+		"A. 5-(1-Methylethylidene)-2,2-dimethyl-1,3-dioxane-4,6-dione (1) A flask is charged\n" +
+		"with acetone and purged with nitrogen. It is stirred.\n" +
+		"\n" +
+		"  The mixture is then cooled.\n" +
+		"B. Workup\n" +
+		"(a) Filter the solid.\n" +
+		"(b) Dry it under vacuum.\n" +
+		"\n" +
+		"Table 1 Yields\n" +
+		"Run 1 90%\n" +
+		"Run 2 85%\n"
+	doc, _ := NewDocument("Layout001")
+	doc.SetInput("P", in)
+	doc.Tokenize()
+	doc.AssembleSentences()
+
+	roots, err := doc.DetectLayout("P")
+	if err != nil {
+		t.Fatalf("Failed to detect layout : %s", err.Error())
+	}
+	if len(roots) != 1 || roots[0].Type() != BlockHeading || roots[0].Label() != "1" {
+		t.Fatalf("Expected a single root heading, observed : %d", len(roots))
+	}
+
+	exp := []struct {
+		btype    BlockType
+		label    string
+		text     string
+		children int
+	}{
+		{BlockStep, "A", "A. 5-(1-Methylethylidene)-2,2-dimethyl-1,3-dioxane-4,6-dione (1)", 2},
+		{BlockStep, "B", "B. Workup", 3},
+	}
+	steps := roots[0].Children()
+	if len(steps) != len(exp) {
+		t.Fatalf("Expected step count : %d, observed : %d", len(exp), len(steps))
+	}
+	for i, e := range exp {
+		b := steps[i]
+		if b.Type() != e.btype || b.Label() != e.label || in[b.Begin():b.End()+1] != e.text ||
+			len(b.Children()) != e.children || b.Level() != 1 || b.Parent() != roots[0] {
+			t.Errorf("Expected : %v, observed : %s %s %s %d", e, BtDescriptions[b.Type()], b.Label(),
+				in[b.Begin():b.End()+1], len(b.Children()))
+		}
+	}
+
+	para := steps[0].Children()[0]
+	if para.Type() != BlockParagraph || in[para.Begin():para.End()+1] !=
+		"A flask is charged\nwith acetone and purged with nitrogen. It is stirred." {
+		t.Errorf("Unexpected paragraph : %s", in[para.Begin():para.End()+1])
+	}
+	items := steps[1].Children()
+	if items[0].Type() != BlockListItem || items[0].Label() != "a" ||
+		items[2].Type() != BlockTable || items[2].Label() != "Table 1" ||
+		in[items[2].Begin():items[2].End()+1] != "Table 1 Yields\nRun 1 90%\nRun 2 85%" {
+		t.Errorf("Unexpected list items or table")
+	}
+
+	expSents := []string{
+		"1. Procedure",
+		"A. 5-1-Methylethylidene-2,2-dimethyl-1,3-dioxane-4,6-dione 1",
+		"A flask is charged\nwith acetone and purged with nitrogen.",
+		"It is stirred.",
+		"The mixture is then cooled.",
+		"B. Workup",
+	}
+	sents := doc.SectionSentences("P")
+	if len(sents) < len(expSents) {
+		t.Fatalf("Expected at least %d sentences, observed : %d", len(expSents), len(sents))
+	}
+	for i, e := range expSents {
+		if sents[i].Text() != e {
+			t.Errorf("Expected sentence : %q, observed : %q", e, sents[i].Text())
+		}
+	}
+}
+
+//
+
+func TestLayout002(t *testing.T) {
+	in := "Step 1. Preparation of the ester. The acid is dissolved in ethanol.\n" +
+		"Step 2: Workup\n"
+	doc, _ := NewDocument("Layout002")
+	doc.SetInput("P", in)
+	doc.Tokenize()
+	doc.AssembleSentences()
+
+	roots, err := doc.DetectLayout("P")
+	if err != nil {
+		t.Fatalf("Failed to detect layout : %s", err.Error())
+	}
+	exp := []string{"Step 1. Preparation of the ester.", "Step 2: Workup"}
+	if len(roots) != len(exp) {
+		t.Fatalf("Expected block count : %d, observed : %d", len(exp), len(roots))
+	}
+	for i, e := range exp {
+		if b := roots[i]; b.Type() != BlockStep || in[b.Begin():b.End()+1] != e {
+			t.Errorf("Expected step title : %q, observed : %q", e, in[b.Begin():b.End()+1])
+		}
+	}
+	if s := doc.SectionSentences("P")[0]; s.Text() != exp[0] {
+		t.Errorf("Expected sentence : %q, observed : %q", exp[0], s.Text())
+	}
+
+	// Detecting the layout again replaces its protections.
+	doc.SetInput("P", "Step 3: Drying\nThe solid is dried.\n")
+	doc.Tokenize()
+	doc.DetectLayout("P")
+	if ps := doc.prots["P"]; len(ps) != 1 || ps[0] != (span{0, 13}) {
+		t.Errorf("Expected the protection of the new step title only, observed : %v", ps)
+	}
+}
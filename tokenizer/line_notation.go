@@ -0,0 +1,507 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Line notations encode chemical structures as single strings of
+// printable characters.  The tokenizer splits them into a large
+// number of tiny tokens -- parentheses, brackets, digits, symbols --
+// and any periods in them may be taken to be sentence terminators.
+// The functions in this file detect SMILES and InChI strings in a
+// tokenized input, and merge each into a single token.
+
+// Word classes assigned to recognised line notations.
+const (
+	ClassSMILES = "SMILES"
+	ClassInChI  = "INCHI"
+)
+
+// elements lists the symbols of all the elements, for validating
+// bracket atoms in SMILES and formulae in InChI.
+var elements = map[string]struct{}{}
+
+func init() {
+	syms := "H He Li Be B C N O F Ne Na Mg Al Si P S Cl Ar K Ca Sc Ti V Cr Mn Fe " +
+		"Co Ni Cu Zn Ga Ge As Se Br Kr Rb Sr Y Zr Nb Mo Tc Ru Rh Pd Ag Cd In Sn " +
+		"Sb Te I Xe Cs Ba La Ce Pr Nd Pm Sm Eu Gd Tb Dy Ho Er Tm Yb Lu Hf Ta W " +
+		"Re Os Ir Pt Au Hg Tl Pb Bi Po At Rn Fr Ra Ac Th Pa U Np Pu Am Cm Bk Cf " +
+		"Es Fm Md No Lr Rf Db Sg Bh Hs Mt Ds Rg Cn Nh Fl Mc Lv Ts Og D T"
+	for _, s := range strings.Fields(syms) {
+		elements[s] = struct{}{}
+	}
+}
+
+// aromaticBracketSymbols lists the aromatic symbols permitted inside
+// SMILES bracket atoms.
+var aromaticBracketSymbols = map[string]struct{}{
+	"b": {}, "c": {}, "n": {}, "o": {}, "p": {}, "s": {},
+	"se": {}, "as": {}, "te": {},
+}
+
+// ValidateSMILES checks the given text against a lightweight SMILES
+// grammar: atoms of the organic subset, bracket atoms, bonds,
+// balanced and non-empty branches, paired ring closures and
+// dot-separated components.  It does not check valences or
+// aromaticity.
+//
+// It answers `nil` if the text is acceptable, or an error describing
+// the first problem found.
+func ValidateSMILES(s string) error {
+	_, err := parseSMILES(s)
+	return err
+}
+
+// parseSMILES validates the given SMILES, and answers the number of
+// atoms in it.
+func parseSMILES(s string) (int, error) {
+	if s == "" {
+		return 0, fmt.Errorf("Empty SMILES")
+	}
+
+	type branch struct {
+		hasAtom bool
+	}
+	var branches []branch
+	rings := make(map[int]int) // Ring number -> opening atom, or -1
+	atoms := 0
+	hasAtom := false // Is there an atom to bond from?
+	bond := false    // Is a bond pending?
+	n := len(s)
+
+	for i := 0; i < n; {
+		c := s[i]
+		switch {
+		case c == '[':
+			j := strings.IndexByte(s[i:], ']')
+			if j == -1 {
+				return 0, fmt.Errorf("Unterminated bracket atom at %d", i)
+			}
+			if err := validateBracketAtom(s[i+1 : i+j]); err != nil {
+				return 0, fmt.Errorf("%s at %d", err.Error(), i)
+			}
+			i += j + 1
+
+		case c == '*':
+			i++
+
+		case c == 'C' && i+1 < n && s[i+1] == 'l',
+			c == 'B' && i+1 < n && s[i+1] == 'r':
+			i += 2
+
+		case strings.IndexByte("BCNOPSFIbcnops", c) >= 0:
+			i++
+
+		case strings.IndexByte("-=#$:/\\", c) >= 0:
+			if !hasAtom || bond {
+				return 0, fmt.Errorf("Misplaced bond '%c' at %d", c, i)
+			}
+			bond = true
+			i++
+			continue
+
+		case c == '(':
+			if !hasAtom || bond {
+				return 0, fmt.Errorf("Misplaced branch at %d", i)
+			}
+			branches = append(branches, branch{})
+			i++
+			continue
+
+		case c == ')':
+			l := len(branches)
+			if l == 0 {
+				return 0, fmt.Errorf("Unbalanced ')' at %d", i)
+			}
+			if !branches[l-1].hasAtom || bond {
+				return 0, fmt.Errorf("Empty or incomplete branch at %d", i)
+			}
+			branches = branches[:l-1]
+			i++
+			continue
+
+		case c >= '0' && c <= '9', c == '%':
+			if !hasAtom {
+				return 0, fmt.Errorf("Misplaced ring closure at %d", i)
+			}
+			rn := int(c - '0')
+			if c == '%' {
+				if i+2 >= n || !isASCIIDigits(s[i+1:i+3]) {
+					return 0, fmt.Errorf("Malformed ring closure at %d", i)
+				}
+				rn = 10*int(s[i+1]-'0') + int(s[i+2]-'0')
+				i += 2
+			}
+			if a, ok := rings[rn]; ok && a >= 0 {
+				if a == atoms {
+					return 0, fmt.Errorf("Ring %d closes on its opening atom at %d", rn, i)
+				}
+				rings[rn] = -1
+			} else {
+				rings[rn] = atoms
+			}
+			bond = false
+			i++
+			continue
+
+		case c == '.':
+			if !hasAtom || bond || len(branches) > 0 {
+				return 0, fmt.Errorf("Misplaced '.' at %d", i)
+			}
+			hasAtom = false
+			i++
+			continue
+
+		default:
+			return 0, fmt.Errorf("Unexpected character '%c' at %d", c, i)
+		}
+
+		// An atom was consumed.
+		atoms++
+		hasAtom = true
+		bond = false
+		if l := len(branches); l > 0 {
+			branches[l-1].hasAtom = true
+		}
+	}
+
+	switch {
+	case atoms == 0:
+		return 0, fmt.Errorf("No atoms")
+	case bond || !hasAtom:
+		return 0, fmt.Errorf("Incomplete SMILES")
+	case len(branches) > 0:
+		return 0, fmt.Errorf("Unbalanced '('")
+	}
+	for rn, a := range rings {
+		if a >= 0 {
+			return 0, fmt.Errorf("Unclosed ring %d", rn)
+		}
+	}
+
+	return atoms, nil
+}
+
+// validateBracketAtom checks the contents of a SMILES bracket atom:
+// isotope, symbol, chirality, hydrogen count, charge and class.
+func validateBracketAtom(s string) error {
+	i, n := 0, len(s)
+	for i < n && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+
+	switch {
+	case i < n && s[i] == '*':
+		i++
+	case i+1 < n && isLowerASCII(s[i]) && isLowerASCII(s[i+1]):
+		if _, ok := aromaticBracketSymbols[s[i:i+2]]; !ok {
+			return fmt.Errorf("Unknown aromatic symbol '%s'", s[i:i+2])
+		}
+		i += 2
+	case i < n && isLowerASCII(s[i]):
+		if _, ok := aromaticBracketSymbols[s[i:i+1]]; !ok {
+			return fmt.Errorf("Unknown aromatic symbol '%s'", s[i:i+1])
+		}
+		i++
+	case i < n && s[i] >= 'A' && s[i] <= 'Z':
+		j := i + 1
+		if j < n && isLowerASCII(s[j]) {
+			if _, ok := elements[s[i:j+1]]; ok {
+				j++
+			}
+		}
+		if _, ok := elements[s[i:j]]; !ok {
+			return fmt.Errorf("Unknown element '%s'", s[i:j])
+		}
+		i = j
+	default:
+		return fmt.Errorf("Missing element in bracket atom")
+	}
+
+	if i < n && s[i] == '@' {
+		i++
+		for i < n && (s[i] == '@' || (s[i] >= 'A' && s[i] <= 'Z' && s[i] != 'H') || (s[i] >= '0' && s[i] <= '9')) {
+			i++
+		}
+	}
+	if i < n && s[i] == 'H' {
+		i++
+		for i < n && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+	}
+	for i < n && (s[i] == '+' || s[i] == '-') {
+		i++
+		for i < n && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+	}
+	if i < n && s[i] == ':' {
+		i++
+		if i == n || !isASCIIDigits(s[i:]) {
+			return fmt.Errorf("Malformed atom class")
+		}
+		i = n
+	}
+	if i != n {
+		return fmt.Errorf("Unexpected '%s' in bracket atom", s[i:])
+	}
+
+	return nil
+}
+
+// ValidateInChI checks the given text for the structure of an InChI
+// string: the `InChI=` prefix, a version, a formula layer and
+// subsequent layers with known prefixes, with balanced parentheses
+// throughout and no trailing punctuation.
+//
+// It answers `nil` if the text is acceptable, or an error describing
+// the first problem found.
+func ValidateInChI(s string) error {
+	if !strings.HasPrefix(s, "InChI=") {
+		return fmt.Errorf("Missing 'InChI=' prefix")
+	}
+	layers := strings.Split(s[len("InChI="):], "/")
+	if len(layers) < 2 {
+		return fmt.Errorf("Missing formula layer")
+	}
+
+	switch layers[0] {
+	case "1", "1S", "1B", "1T":
+	default:
+		return fmt.Errorf("Unknown InChI version '%s'", layers[0])
+	}
+	if err := validateInChIFormula(layers[1]); err != nil {
+		return err
+	}
+
+	for _, l := range layers[2:] {
+		if l == "" {
+			return fmt.Errorf("Empty layer")
+		}
+		if strings.IndexByte("bcfhimpqrst", l[0]) == -1 {
+			return fmt.Errorf("Unknown layer prefix '%c'", l[0])
+		}
+	}
+
+	switch c := s[len(s)-1]; {
+	case c == ')', c == '+', c == '-', c >= '0' && c <= '9',
+		c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+	default:
+		return fmt.Errorf("Unexpected trailing '%c'", c)
+	}
+
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("Unbalanced ')' at %d", i)
+			}
+		case c <= ' ' || c > '~':
+			return fmt.Errorf("Unexpected character at %d", i)
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("Unbalanced '('")
+	}
+
+	return nil
+}
+
+// validateInChIFormula checks the formula layer of an InChI: one or
+// more dot-separated components, each with an optional multiplier
+// and a sequence of element symbols with optional counts.
+func validateInChIFormula(f string) error {
+	if f == "" {
+		return fmt.Errorf("Empty formula layer")
+	}
+
+	for _, comp := range strings.Split(f, ".") {
+		i, n := 0, len(comp)
+		for i < n && comp[i] >= '0' && comp[i] <= '9' {
+			i++
+		}
+		if i == n {
+			return fmt.Errorf("Empty formula component")
+		}
+		for i < n {
+			if comp[i] < 'A' || comp[i] > 'Z' {
+				return fmt.Errorf("Malformed formula '%s'", comp)
+			}
+			j := i + 1
+			if j < n && isLowerASCII(comp[j]) {
+				j++
+			}
+			if _, ok := elements[comp[i:j]]; !ok {
+				return fmt.Errorf("Unknown element '%s'", comp[i:j])
+			}
+			for j < n && comp[j] >= '0' && comp[j] <= '9' {
+				j++
+			}
+			i = j
+		}
+	}
+
+	return nil
+}
+
+// lineNotationClass answers the class of the line notation that the
+// given text represents, if any.
+//
+// Since short words like `CO` or `I` are valid SMILES too, a SMILES
+// candidate must also include carbon or a bracket atom, at least one
+// of the characters that do not occur in ordinary words, and at least
+// two atoms.
+func lineNotationClass(s string) (string, bool) {
+	if strings.HasPrefix(s, "InChI=") {
+		if ValidateInChI(s) == nil {
+			return ClassInChI, true
+		}
+		return "", false
+	}
+
+	if !strings.ContainsAny(s, "()[]=#@/\\%0123456789") ||
+		!strings.ContainsAny(s, "Cc[") {
+		return "", false
+	}
+	if n, err := parseSMILES(s); err == nil && n > 1 {
+		return ClassSMILES, true
+	}
+	return "", false
+}
+
+// MergeLineNotations scans the given tokens of the given input text
+// for SMILES and InChI strings, and replaces the tokens of each
+// recognised string with a single token of type `TokNotation`.
+//
+// A candidate is a maximal run of non-space tokens.  Enclosing
+// parentheses and quotes, and trailing punctuation are excluded from
+// the candidate, if necessary.  The answered slice is a new one; the
+// input slice is not modified.
+func MergeLineNotations(input string, toks []*TextToken) []*TextToken {
+	var res []*TextToken
+	l := len(toks)
+	for i := 0; i < l; {
+		if toks[i].Type() == TokSpace {
+			res = append(res, toks[i])
+			i++
+			continue
+		}
+
+		j := i
+		for j+1 < l && toks[j+1].Type() != TokSpace {
+			j++
+		}
+
+		b, e, ok := findLineNotation(input, toks, i, j)
+		if !ok {
+			res = append(res, toks[i:j+1]...)
+			i = j + 1
+			continue
+		}
+
+		res = append(res, toks[i:b]...)
+		tb, te := toks[b].Begin(), toks[e].End()
+		res = append(res, &TextToken{input[tb : te+1], tb, te, TokNotation})
+		res = append(res, toks[e+1:j+1]...)
+		i = j + 1
+	}
+
+	return res
+}
+
+// findLineNotation tries to find a line notation in the run of tokens
+// from index `b` through index `e`, trimming wrapping punctuation
+// progressively.
+func findLineNotation(input string, toks []*TextToken, b, e int) (int, int, bool) {
+	if b == e {
+		return -1, -1, false
+	}
+
+	for b <= e {
+		s := input[toks[b].Begin() : toks[e].End()+1]
+		if _, ok := lineNotationClass(s); ok {
+			return b, e, true
+		}
+
+		bt, et := toks[b].Type(), toks[e].Type()
+		switch {
+		case (bt == TokParenOpen && et == TokParenClose) ||
+			(bt == TokBracketOpen && et == TokBracketClose):
+			b++
+			e--
+		case isQuoteType(bt):
+			b++
+		case isQuoteType(et), et == TokPause, et == TokMayBeTerm, et == TokTerm,
+			et == TokParenClose, et == TokBracketClose:
+			e--
+		case bt == TokParenOpen, bt == TokBracketOpen:
+			b++
+		default:
+			return -1, -1, false
+		}
+	}
+
+	return -1, -1, false
+}
+
+// RecognizeLineNotations detects SMILES and InChI strings in the
+// tokens of the given section, and merges each into a single token.
+// It also records a `TokWord` word for each, with its class set to
+// one of `ClassSMILES` and `ClassInChI`.
+//
+// The section must have been tokenized already.  Should its sentences
+// have been assembled already, they are re-assembled.  Notations are
+// merged again around later edits of the section; see `Edit`.
+func (d *Document) RecognizeLineNotations(sec string) ([]*Word, error) {
+	toks, ok := d.tokens[sec]
+	if !ok {
+		return nil, fmt.Errorf("Unknown section : %s", sec)
+	}
+
+	inp := d.input[sec]
+	toks = MergeLineNotations(inp, toks)
+	d.tokens[sec] = toks
+
+	d.notes[sec] = true
+
+	var words []*Word
+	for _, t := range toks {
+		if t.Type() == TokNotation {
+			words = append(words, d.notationWord(sec, t))
+		}
+	}
+
+	if _, ok := d.sents[sec]; ok {
+		d.assembleSection(sec)
+	}
+	return words, nil
+}
+
+// notationWord answers the word of the given notation token of the
+// given section, creating it if necessary.
+func (d *Document) notationWord(sec string, t *TextToken) *Word {
+	cls, _ := lineNotationClass(t.Text())
+	w := d.wordAt(sec, t.Text(), t.Begin(), t.End())
+	w.token.ttype = TokWord
+	w.class = cls
+	return w
+}
+
+func isQuoteType(tt TokenType) bool {
+	return tt == TokSquote || tt == TokDquote || tt == TokIniQuote || tt == TokFinQuote
+}
+
+func isLowerASCII(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
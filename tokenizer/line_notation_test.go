@@ -0,0 +1,132 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"testing"
+)
+
+func TestValidateSMILES001(t *testing.T) {
+	valid := []string{
+		"CC(=O)Oc1ccccc1C(=O)O",
+		"C1CCCCC1",
+		"[Na+].[Cl-]",
+		"N[C@@H](C)C(=O)O",
+		"c1ccc2c(c1)[nH]c1ccccc12",
+		"C/C=C/C",
+		"O=C=O",
+		"C%10CCCCC%10",
+		"[13CH4]",
+	}
+	for _, s := range valid {
+		if err := ValidateSMILES(s); err != nil {
+			t.Errorf("Expected valid SMILES : %s, observed error : %s", s, err.Error())
+		}
+	}
+
+	invalid := []string{
+		"CC(=O",
+		"CC)O",
+		"C()C",
+		"C1CCCC",
+		"C==C",
+		"(C)C",
+		"C.",
+		"[Xx]",
+		"NaCl",
+		"C11",
+	}
+	for _, s := range invalid {
+		if ValidateSMILES(s) == nil {
+			t.Errorf("Expected invalid SMILES : %s", s)
+		}
+	}
+}
+
+//
+
+func TestValidateInChI001(t *testing.T) {
+	valid := []string{
+		"InChI=1S/C9H8O4/c1-6(10)13-8-5-3-2-4-7(8)9(11)12/h2-5H,1H3,(H,11,12)",
+		"InChI=1S/2ClH.Ca/h2*1H;/q;+2/p-2",
+		"InChI=1S/CH4/h1H4",
+	}
+	for _, s := range valid {
+		if err := ValidateInChI(s); err != nil {
+			t.Errorf("Expected valid InChI : %s, observed error : %s", s, err.Error())
+		}
+	}
+
+	invalid := []string{
+		"InChI=2S/CH4/h1H4",
+		"InChI=1S/Xy4",
+		"InChI=1S/CH4/z1H4",
+		"InChI=1S/C9H8O4/c1-6(10",
+		"InChl=1S/CH4",
+		"InChI=1S/CH4/h1H4.",
+	}
+	for _, s := range invalid {
+		if ValidateInChI(s) == nil {
+			t.Errorf("Expected invalid InChI : %s", s)
+		}
+	}
+}
+
+//
+
+func TestRecognizeLineNotations001(t *testing.T) {
+	in := "Aspirin (CC(=O)Oc1ccccc1C(=O)O) is dissolved in brine, i.e. [Na+].[Cl-] in water. " +
+		"Its InChI is InChI=1S/C9H8O4/c1-6(10)13-8-5-3-2-4-7(8)9(11)12/h2-5H,1H3,(H,11,12). " +
+		"The salt TiCl4 (IV) is not a line notation."
+	doc, _ := NewDocument("LineNotation001")
+	doc.SetInput("P", in)
+	doc.Tokenize()
+	doc.AssembleSentences()
+
+	words, err := doc.RecognizeLineNotations("P")
+	if err != nil {
+		t.Fatalf("Failed to recognise line notations : %s", err.Error())
+	}
+
+	exp := []struct {
+		text  string
+		class string
+	}{
+		{"CC(=O)Oc1ccccc1C(=O)O", ClassSMILES},
+		{"[Na+].[Cl-]", ClassSMILES},
+		{"InChI=1S/C9H8O4/c1-6(10)13-8-5-3-2-4-7(8)9(11)12/h2-5H,1H3,(H,11,12)", ClassInChI},
+	}
+	if len(words) != len(exp) {
+		t.Fatalf("Expected line notation count : %d, observed : %d", len(exp), len(words))
+	}
+	for i, e := range exp {
+		w := words[i]
+		if w.Text() != e.text || w.Class() != e.class {
+			t.Errorf("Expected : %s %s, observed : %s %s", e.text, e.class, w.Text(), w.Class())
+		}
+		if in[w.Begin():w.End()+1] != w.Text() {
+			t.Errorf("Word span mismatch for %s : %d:%d", w.Text(), w.Begin(), w.End())
+		}
+	}
+
+	n := 0
+	for _, tok := range doc.SectionTokens("P") {
+		if tok.Type() == TokNotation {
+			n++
+		}
+	}
+	if n != len(exp) {
+		t.Errorf("Expected notation token count : %d, observed : %d", len(exp), n)
+	}
+
+	c, _ := doc.SectionSentenceCount("P")
+	if c != 3 {
+		for _, s := range doc.SectionSentences("P") {
+			t.Logf("%d:%d %s", s.Begin(), s.End(), s.Text())
+		}
+		t.Fatalf("Expected sentence count : 3, observed : %d", c)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultSectionSeparator is the text that separates consecutive
+// sections in the full text of a document, unless changed.
+const DefaultSectionSeparator = "\n\n"
+
+// SetSectionSeparator changes the text that separates consecutive
+// sections in the full text of the document.
+func (d *Document) SetSectionSeparator(sep string) {
+	d.sep = sep
+}
+
+// SectionSeparator answers the text that separates consecutive
+// sections in the full text of the document.
+func (d *Document) SectionSeparator() string {
+	return d.sep
+}
+
+// FullText answers the full text of the document: the texts of all its
+// sections concatenated in document order, separated by the section
+// separator.
+//
+// Global offsets are offsets into this text.
+func (d *Document) FullText() string {
+	texts := make([]string, len(d.secs))
+	for i, sec := range d.secs {
+		texts[i] = d.input[sec]
+	}
+	return strings.Join(texts, d.sep)
+}
+
+// SectionOffset answers the offset of the beginning of the given
+// section in the full text of the document.
+func (d *Document) SectionOffset(sec string) (int, error) {
+	off := 0
+	for _, s := range d.secs {
+		if s == sec {
+			return off, nil
+		}
+		off += len(d.input[s]) + len(d.sep)
+	}
+
+	return -1, fmt.Errorf("Unknown section : %s", sec)
+}
+
+// GlobalOffset converts the given offset in the given section into
+// the corresponding offset in the full text of the document.
+func (d *Document) GlobalOffset(sec string, off int) (int, error) {
+	base, err := d.SectionOffset(sec)
+	if err != nil {
+		return -1, err
+	}
+	if off < 0 || off >= len(d.input[sec]) {
+		return -1, fmt.Errorf("Offset out of range of section %s : %d", sec, off)
+	}
+
+	return base + off, nil
+}
+
+// LocalOffset converts the given offset in the full text of the
+// document into the name of the section it falls in, and the
+// corresponding offset in that section.  Offsets that fall in section
+// separators have no local equivalents.
+func (d *Document) LocalOffset(g int) (string, int, error) {
+	if g < 0 {
+		return "", -1, fmt.Errorf("Negative offset : %d", g)
+	}
+
+	off := 0
+	for _, sec := range d.secs {
+		l := len(d.input[sec])
+		if g < off+l {
+			return sec, g - off, nil
+		}
+		off += l
+		if g < off+len(d.sep) {
+			return "", -1, fmt.Errorf("Offset in section separator : %d", g)
+		}
+		off += len(d.sep)
+	}
+
+	return "", -1, fmt.Errorf("Offset beyond end of document : %d", g)
+}
+
+// GlobalSpan answers the global offsets of the beginning and the end
+// of the given token, sentence or word of the given section.
+func (d *Document) GlobalSpan(sec string, t Token) (int, int, error) {
+	return d.globalSpan(sec, t.Begin(), t.End())
+}
+
+// AnnotationGlobalSpan answers the global offsets of the beginning and
+// the end of the text of the given annotation.
+func (d *Document) AnnotationGlobalSpan(a *Annotation) (int, int, error) {
+	return d.globalSpan(a.Section, a.Begin, a.End)
+}
+
+// globalSpan converts the given local offsets of the given section
+// into global offsets.
+func (d *Document) globalSpan(sec string, b, e int) (int, int, error) {
+	gb, err := d.GlobalOffset(sec, b)
+	if err != nil {
+		return -1, -1, err
+	}
+	ge, err := d.GlobalOffset(sec, e)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	return gb, ge, nil
+}
+
+// LocalSpan converts the given global offsets into the name of the
+// section that contains them, and the corresponding local offsets.
+// Both offsets must fall in the same section.
+func (d *Document) LocalSpan(gb, ge int) (string, int, int, error) {
+	sec, b, err := d.LocalOffset(gb)
+	if err != nil {
+		return "", -1, -1, err
+	}
+	sec2, e, err := d.LocalOffset(ge)
+	if err != nil {
+		return "", -1, -1, err
+	}
+	if sec2 != sec {
+		return "", -1, -1, fmt.Errorf("Span crosses sections %s and %s : %d:%d", sec, sec2, gb, ge)
+	}
+
+	return sec, b, e, nil
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"testing"
+)
+
+func TestGlobalOffsets001(t *testing.T) {
+	doc, _ := NewDocument("Global001")
+	doc.SetInput("T", "Title text")
+	doc.SetInput("A", "First sentence. Second one.")
+	doc.SetSectionSeparator(" | ")
+	doc.Tokenize()
+	doc.AssembleSentences()
+
+	full := doc.FullText()
+	if full != "Title text | First sentence. Second one." {
+		t.Fatalf("Unexpected full text : %q", full)
+	}
+
+	for _, sec := range doc.Sections() {
+		inp, _ := doc.Input(sec)
+		for _, tok := range doc.SectionTokens(sec) {
+			gb, ge, err := doc.GlobalSpan(sec, tok)
+			if err != nil || full[gb:ge+1] != tok.Text() {
+				t.Errorf("Global span mismatch for token %q : %d:%d", tok.Text(), gb, ge)
+			}
+			s, b, e, err := doc.LocalSpan(gb, ge)
+			if err != nil || s != sec || b != tok.Begin() || e != tok.End() {
+				t.Errorf("Local span mismatch for token %q : %s %d:%d", tok.Text(), s, b, e)
+			}
+		}
+		for _, sent := range doc.SectionSentences(sec) {
+			gb, ge, _ := doc.GlobalSpan(sec, sent)
+			if full[gb:ge+1] != inp[sent.Begin():sent.End()+1] {
+				t.Errorf("Global span mismatch for sentence %q : %d:%d", sent.Text(), gb, ge)
+			}
+		}
+	}
+
+	a := &Annotation{DocumentID: "Global001", Section: "A", Begin: 16, End: 21, Entity: "Second"}
+	if gb, ge, err := doc.AnnotationGlobalSpan(a); err != nil || full[gb:ge+1] != "Second" {
+		t.Errorf("Global span mismatch for annotation : %d:%d", gb, ge)
+	}
+
+	for _, g := range []int{-1, 10, 12, len(full)} {
+		if _, _, err := doc.LocalOffset(g); err == nil {
+			t.Errorf("Expected an error for offset : %d", g)
+		}
+	}
+	if _, _, _, err := doc.LocalSpan(0, 14); err == nil {
+		t.Errorf("Expected an error for a span crossing sections")
+	}
+	if _, err := doc.GlobalOffset("T", 10); err == nil {
+		t.Errorf("Expected an error for an offset beyond its section")
+	}
+}
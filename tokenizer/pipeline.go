@@ -0,0 +1,346 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of the standard layers of information held by a document.
+// Processors declare the layers they need and the layers they
+// produce, using these or their own names.
+const (
+	LayerInput       = "input"
+	LayerTokens      = "tokens"
+	LayerSentences   = "sentences"
+	LayerWords       = "words"
+	LayerIdentifiers = "identifiers"
+	LayerNotations   = "notations"
+	LayerReferences  = "references"
+	LayerLayout      = "layout"
+	LayerCompounds   = "compounds"
+)
+
+// Processor represents one stage of processing of a document:
+// normalisation, tokenization, sentence assembly, tagging, extraction,
+// etc.
+//
+// A processor declares the layers of the document that it needs as
+// inputs, and those that it produces as outputs.  A pipeline uses
+// these to order its stages.
+type Processor interface {
+	Name() string
+	Inputs() []string
+	Outputs() []string
+	Process(d *Document) error
+}
+
+// funcProcessor adapts a function to the `Processor` interface.
+type funcProcessor struct {
+	name    string
+	inputs  []string
+	outputs []string
+	fn      func(d *Document) error
+}
+
+func (p *funcProcessor) Name() string {
+	return p.name
+}
+
+func (p *funcProcessor) Inputs() []string {
+	return p.inputs
+}
+
+func (p *funcProcessor) Outputs() []string {
+	return p.outputs
+}
+
+func (p *funcProcessor) Process(d *Document) error {
+	return p.fn(d)
+}
+
+// NewProcessorFunc creates a processor with the given name and
+// layers, that processes documents using the given function.
+func NewProcessorFunc(name string, inputs, outputs []string, fn func(d *Document) error) Processor {
+	return &funcProcessor{name, inputs, outputs, fn}
+}
+
+// sectionProcessor answers a processor that applies the given
+// function to each section of the document, in turn.
+func sectionProcessor(name string, inputs, outputs []string, fn func(d *Document, sec string) error) Processor {
+	return NewProcessorFunc(name, inputs, outputs, func(d *Document) error {
+		for _, sec := range d.Sections() {
+			if err := fn(d, sec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// processorMu guards the registry of processors, which may be
+// extended while corpus workers build pipelines.
+var processorMu sync.RWMutex
+
+// processorRegistry holds the factories of the processors available
+// by name, for building pipelines from configuration.
+var processorRegistry = map[string]func() Processor{
+	"tokenize": func() Processor {
+		return NewProcessorFunc("tokenize", []string{LayerInput}, []string{LayerTokens},
+			func(d *Document) error {
+				d.Tokenize()
+				return nil
+			})
+	},
+	"sentences": func() Processor {
+		return NewProcessorFunc("sentences", []string{LayerTokens}, []string{LayerSentences},
+			func(d *Document) error {
+				d.AssembleSentences()
+				return nil
+			})
+	},
+	"words": func() Processor {
+		// Words of other processors are retained; hence, it follows them.
+		return NewProcessorFunc("words", []string{LayerTokens, LayerWords}, []string{LayerWords},
+			func(d *Document) error {
+				d.AssembleWords()
+				return nil
+			})
+	},
+	"identifiers": func() Processor {
+		return sectionProcessor("identifiers", []string{LayerTokens}, []string{LayerIdentifiers, LayerWords},
+			func(d *Document, sec string) error {
+				_, err := d.RecognizeIdentifiers(sec)
+				return err
+			})
+	},
+	"line-notations": func() Processor {
+		return sectionProcessor("line-notations", []string{LayerTokens}, []string{LayerNotations, LayerTokens, LayerSentences, LayerWords},
+			func(d *Document, sec string) error {
+				_, err := d.RecognizeLineNotations(sec)
+				return err
+			})
+	},
+	"references": func() Processor {
+		return sectionProcessor("references", []string{LayerTokens}, []string{LayerReferences, LayerWords},
+			func(d *Document, sec string) error {
+				_, err := d.RecognizeReferences(sec)
+				return err
+			})
+	},
+	"layout": func() Processor {
+		return sectionProcessor("layout", []string{LayerInput}, []string{LayerLayout},
+			func(d *Document, sec string) error {
+				_, err := d.DetectLayout(sec)
+				return err
+			})
+	},
+	"compound-labels": func() Processor {
+		return NewProcessorFunc("compound-labels", []string{LayerTokens}, []string{LayerCompounds},
+			func(d *Document) error {
+				_, err := d.ResolveCompoundLabels()
+				return err
+			})
+	},
+}
+
+// RegisterProcessor makes processors created by the given factory
+// available under the given name, for building pipelines from
+// configuration.  A registered name cannot be re-used.  It is safe
+// for concurrent use.
+func RegisterProcessor(name string, factory func() Processor) error {
+	if name == "" || factory == nil {
+		return fmt.Errorf("Empty processor name or factory given")
+	}
+
+	processorMu.Lock()
+	defer processorMu.Unlock()
+	if _, ok := processorRegistry[name]; ok {
+		return fmt.Errorf("Processor already registered : %s", name)
+	}
+
+	processorRegistry[name] = factory
+	return nil
+}
+
+// NewProcessor creates a processor of the given registered name.
+func NewProcessor(name string) (Processor, error) {
+	processorMu.RLock()
+	f, ok := processorRegistry[name]
+	processorMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("Unknown processor : %s", name)
+	}
+
+	return f(), nil
+}
+
+// ProcessorNames answers the names of the registered processors, in
+// alphabetical order.
+func ProcessorNames() []string {
+	processorMu.RLock()
+	defer processorMu.RUnlock()
+	names := make([]string, 0, len(processorRegistry))
+	for name := range processorRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StageStat records the outcome of running one stage of a pipeline
+// on a document.
+type StageStat struct {
+	Name    string
+	Elapsed time.Duration
+	Err     error
+	Skipped bool // Because an input layer failed
+}
+
+// Pipeline represents an ordered sequence of processors.
+//
+// The stages are ordered such that every processor runs after all
+// the processors producing the layers that it needs.  Processors that
+// do not depend on one another retain their given order.
+type Pipeline struct {
+	stages []Processor
+}
+
+// NewPipeline creates a pipeline of the given processors, ordering
+// them by their dependencies.  It answers an error if the processors
+// have conflicting names, or depend on one another cyclically.
+func NewPipeline(procs ...Processor) (*Pipeline, error) {
+	l := len(procs)
+	seen := make(map[string]bool, l)
+	producers := make(map[string][]int)
+	for i, p := range procs {
+		if seen[p.Name()] {
+			return nil, fmt.Errorf("Duplicate processor : %s", p.Name())
+		}
+		seen[p.Name()] = true
+		for _, o := range p.Outputs() {
+			producers[o] = append(producers[o], i)
+		}
+	}
+
+	// deps[i] lists the stages that stage i must follow.
+	deps := make([]map[int]bool, l)
+	for i, p := range procs {
+		deps[i] = make(map[int]bool)
+		for _, in := range p.Inputs() {
+			for _, j := range producers[in] {
+				if j != i {
+					deps[i][j] = true
+				}
+			}
+		}
+	}
+
+	pl := &Pipeline{}
+	done := make([]bool, l)
+	for len(pl.stages) < l {
+		next := -1
+		for i := 0; i < l && next == -1; i++ {
+			if done[i] {
+				continue
+			}
+			ready := true
+			for j := range deps[i] {
+				if !done[j] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+			}
+		}
+		if next == -1 {
+			var names []string
+			for i, p := range procs {
+				if !done[i] {
+					names = append(names, p.Name())
+				}
+			}
+			return nil, fmt.Errorf("Cyclic dependency among processors : %s", strings.Join(names, ", "))
+		}
+		done[next] = true
+		pl.stages = append(pl.stages, procs[next])
+	}
+
+	return pl, nil
+}
+
+// NewPipelineFromNames creates a pipeline of the processors
+// registered under the given names.
+func NewPipelineFromNames(names ...string) (*Pipeline, error) {
+	procs := make([]Processor, 0, len(names))
+	for _, name := range names {
+		p, err := NewProcessor(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		procs = append(procs, p)
+	}
+
+	return NewPipeline(procs...)
+}
+
+// Stages answers the names of the stages of this pipeline, in the
+// order in which they run.
+func (pl *Pipeline) Stages() []string {
+	names := make([]string, len(pl.stages))
+	for i, p := range pl.stages {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+// Run processes the given document through the stages of this
+// pipeline, in order.
+//
+// A stage that fails does not stop the pipeline.  However, stages
+// that need any layer produced by a failed stage are skipped.  It
+// answers the statistics of all stages, and an error listing the
+// stages that failed, if any.
+func (pl *Pipeline) Run(d *Document) ([]StageStat, error) {
+	stats := make([]StageStat, 0, len(pl.stages))
+	failed := make(map[string]bool)
+	var errs []string
+
+	for _, p := range pl.stages {
+		st := StageStat{Name: p.Name()}
+		for _, in := range p.Inputs() {
+			if failed[in] {
+				st.Skipped = true
+				st.Err = fmt.Errorf("Input layer unavailable : %s", in)
+				break
+			}
+		}
+
+		if !st.Skipped {
+			t0 := time.Now()
+			st.Err = p.Process(d)
+			st.Elapsed = time.Since(t0)
+		}
+		if st.Err != nil {
+			for _, o := range p.Outputs() {
+				failed[o] = true
+			}
+			errs = append(errs, fmt.Sprintf("%s (%s)", p.Name(), st.Err.Error()))
+		}
+		stats = append(stats, st)
+	}
+
+	if len(errs) > 0 {
+		return stats, fmt.Errorf("Pipeline stages failed : %s", strings.Join(errs, "; "))
+	}
+	return stats, nil
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPipeline001(t *testing.T) {
+	pl, err := NewPipelineFromNames("identifiers", "sentences", "references", "tokenize")
+	if err != nil {
+		t.Fatalf("Failed to create pipeline : %s", err.Error())
+	}
+	exp := "tokenize identifiers sentences references"
+	if obs := strings.Join(pl.Stages(), " "); obs != exp {
+		t.Fatalf("Expected stages : %s, observed : %s", exp, obs)
+	}
+
+	doc, _ := NewDocument("Pipeline001")
+	doc.SetInput("P", "The solvent (CAS 7732-18-5) was added (Note 2). It was stirred.")
+	stats, err := pl.Run(doc)
+	if err != nil {
+		t.Fatalf("Failed to run pipeline : %s", err.Error())
+	}
+	if len(stats) != 4 {
+		t.Fatalf("Expected stage count : 4, observed : %d", len(stats))
+	}
+	for _, st := range stats {
+		if st.Err != nil || st.Skipped || st.Elapsed < 0 {
+			t.Errorf("Unexpected stage outcome : %v", st)
+		}
+	}
+
+	if c, _ := doc.SectionSentenceCount("P"); c != 2 {
+		t.Errorf("Expected sentence count : 2, observed : %d", c)
+	}
+	if c := len(doc.SectionIdentifiers("P")); c != 1 {
+		t.Errorf("Expected identifier count : 1, observed : %d", c)
+	}
+	if c := len(doc.SectionReferences("P")); c != 1 {
+		t.Errorf("Expected reference count : 1, observed : %d", c)
+	}
+
+	// Merging line notations replaces tokens, before sentences and
+	// words are built of them.
+	pl, _ = NewPipelineFromNames("sentences", "identifiers", "line-notations", "tokenize")
+	exp = "tokenize line-notations sentences identifiers"
+	if obs := strings.Join(pl.Stages(), " "); obs != exp {
+		t.Errorf("Expected stages : %s, observed : %s", exp, obs)
+	}
+}
+
+//
+
+func TestPipeline002(t *testing.T) {
+	if _, err := NewPipelineFromNames("tokenize", "no-such-stage"); err == nil {
+		t.Errorf("Expected an error for an unknown processor")
+	}
+	if _, err := NewPipelineFromNames("tokenize", "tokenize"); err == nil {
+		t.Errorf("Expected an error for a duplicate processor")
+	}
+
+	a := NewProcessorFunc("a", []string{"y"}, []string{"x"}, nil)
+	b := NewProcessorFunc("b", []string{"x"}, []string{"y"}, nil)
+	if _, err := NewPipeline(a, b); err == nil {
+		t.Errorf("Expected an error for a cyclic dependency")
+	}
+
+	if err := RegisterProcessor("sentences", nil); err == nil {
+		t.Errorf("Expected an error for a re-used name")
+	}
+}
+
+//
+
+func TestPipeline003(t *testing.T) {
+	norm := NewProcessorFunc("normalize", nil, []string{LayerInput}, func(d *Document) error {
+		return fmt.Errorf("Normalisation failed")
+	})
+	tok, _ := NewProcessor("tokenize")
+	other := NewProcessorFunc("other", []string{LayerWords}, nil, func(d *Document) error {
+		return nil
+	})
+	pl, err := NewPipeline(tok, other, norm)
+	if err != nil {
+		t.Fatalf("Failed to create pipeline : %s", err.Error())
+	}
+
+	doc, _ := NewDocument("Pipeline003")
+	doc.SetInput("P", "Some text.")
+	stats, err := pl.Run(doc)
+	if err == nil {
+		t.Fatalf("Expected an error from a failed stage")
+	}
+	exp := []struct {
+		name    string
+		failed  bool
+		skipped bool
+	}{
+		{"other", false, false},
+		{"normalize", true, false},
+		{"tokenize", true, true},
+	}
+	for i, e := range exp {
+		st := stats[i]
+		if st.Name != e.name || (st.Err != nil) != e.failed || st.Skipped != e.skipped {
+			t.Errorf("Expected : %v, observed : %v", e, st)
+		}
+	}
+	if doc.SectionTokens("P") != nil {
+		t.Errorf("Expected no tokens from a skipped stage")
+	}
+}
+
+//
+
+func TestPipeline004(t *testing.T) {
+	// Processors may be registered while others build pipelines.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("concurrent-%d", i)
+			if err := RegisterProcessor(name, func() Processor {
+				return NewProcessorFunc(name, nil, nil, nil)
+			}); err != nil {
+				t.Errorf("Unable to register processor : %s", err.Error())
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := NewProcessor("tokenize"); err != nil {
+					t.Errorf("Unable to create processor : %s", err.Error())
+				}
+				ProcessorNames()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := NewProcessor("concurrent-7"); err != nil {
+		t.Errorf("Expected a registered processor : %s", err.Error())
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RefType represents the kinds of references to other text that can
+// be recognised.
+type RefType byte
+
+// List of recognised reference types.
+const (
+	RefUnknown  RefType = iota
+	RefNote             // `(Note 1)`, `(Notes 2 and 3)`
+	RefCitation         // `.2,3` glued after a sentence, `[4-6]`
+	RefPatent           // `U.S. Pat. No. 4,123,456`
+)
+
+// RefDescriptions helps in printing reference types.  The descriptions
+// are also used as the classes of the corresponding words.
+var RefDescriptions = map[RefType]string{
+	RefUnknown:  "UNKNOWN",
+	RefNote:     "NOTE_REF",
+	RefCitation: "CITATION",
+	RefPatent:   "PATENT_REF",
+}
+
+// NoteBody represents the text of a numbered note in a section of a
+// document.
+type NoteBody struct {
+	Section string
+	Number  int
+	Begin   int
+	End     int
+	Text    string
+}
+
+// Reference represents a note reference, citation or patent reference
+// recognised in a section of a document.
+//
+// It holds the word that spans the reference in the input text, and
+// its normalised value.  Note references and citations also hold the
+// numbers they refer to.  Note references are linked to the bodies of
+// the notes, when those are available in the document.
+type Reference struct {
+	word    *Word
+	rtype   RefType
+	value   string
+	numbers []int
+	notes   []*NoteBody
+}
+
+func (r *Reference) Word() *Word {
+	return r.word
+}
+
+func (r *Reference) Type() RefType {
+	return r.rtype
+}
+
+func (r *Reference) Value() string {
+	return r.value
+}
+
+func (r *Reference) Numbers() []int {
+	return r.numbers
+}
+
+func (r *Reference) Notes() []*NoteBody {
+	return r.notes
+}
+
+var (
+	noteRefRe = regexp.MustCompile(`\((?:Notes?|notes?)\s+(\d+(?:\s*(?:,\s*and|,|and|-|–|to)\s*\d+)*)\)`)
+	patentRe  = regexp.MustCompile(`\b(?:U\.\s?S\.|US)\s*(?:Pat(?:ent)?\.?\s+)?(?:(?:Application\s+)?(?:Publication\s+)?Nos?\.\s*)?` +
+		`(\d{1,2},\d{3},\d{3}|\d{7,8}|RE\s?\d{2},\d{3}|\d{4}/\d{7})` +
+		`|\b(?:WO|EP|JP|DE|GB|FR|CN)\s?(\d{4}/\d{5,6}|\d\s\d{3}\s\d{3}|\d{7,9})`)
+	numListRe   = regexp.MustCompile(`\d+|,\s*and|,|and|-|–|to`)
+	notesHeadRe = regexp.MustCompile(`(?i)^\s*(?:\d+\.\s*)?notes\s*$`)
+	noteBodyRe  = regexp.MustCompile(`^\s*(\d+)\.\s+\S`)
+)
+
+// RecognizeReferences detects note references, citations and patent
+// references in the tokens of the given section.
+//
+// Each recognised reference is recorded as a `TokWord` word, with its
+// class set to the description of its type.  Its text is protected
+// against sentence breaks, and trailing citations are kept with the
+// sentences they follow.  Note references are linked to the bodies of
+// the corresponding notes, should those be found in this or another
+// section.
+//
+// The section must have been tokenized already.  Should its sentences
+// have been assembled already, they are re-assembled.
+func (d *Document) RecognizeReferences(sec string) ([]*Reference, error) {
+	toks, ok := d.tokens[sec]
+	if !ok {
+		return nil, fmt.Errorf("Unknown section : %s", sec)
+	}
+	inp := d.input[sec]
+	notes := d.noteBodies()
+
+	var refs []*Reference
+	add := func(b, e int, rt RefType, value string, nums []int) {
+		if _, _, err := tokenSpan(toks, b, e); err != nil {
+			return
+		}
+		for _, r := range refs {
+			if b <= r.word.End() && e >= r.word.Begin() {
+				return
+			}
+		}
+
+		w := d.wordAt(sec, inp[b:e+1], b, e)
+		w.token.ttype = TokWord
+		w.class = RefDescriptions[rt]
+		r := &Reference{word: w, rtype: rt, value: value, numbers: nums}
+		if rt == RefNote {
+			for _, n := range nums {
+				if nb, ok := notes[n]; ok {
+					r.notes = append(r.notes, nb)
+				}
+			}
+		}
+		refs = append(refs, r)
+	}
+
+	for _, m := range patentRe.FindAllStringSubmatchIndex(inp, -1) {
+		nb, ne := m[2], m[3]
+		if nb == -1 {
+			nb, ne = m[4], m[5]
+		}
+		value := strings.Map(func(r rune) rune {
+			if r == ',' || r == ' ' {
+				return -1
+			}
+			return r
+		}, inp[nb:ne])
+		add(m[0], m[1]-1, RefPatent, value, nil)
+	}
+
+	for _, m := range noteRefRe.FindAllStringSubmatchIndex(inp, -1) {
+		nums := parseNumberList(inp[m[2]:m[3]])
+		add(m[0], m[1]-1, RefNote, joinNumbers(nums), nums)
+	}
+
+	l := len(toks)
+	for i := 0; i < l; i++ {
+		if b, e, ok := trailingCitation(toks, i); ok {
+			nums := parseNumberList(inp[toks[b].Begin() : toks[e].End()+1])
+			add(toks[b].Begin(), toks[e].End(), RefCitation, joinNumbers(nums), nums)
+			i = e
+			continue
+		}
+		if e, ok := bracketCitation(inp, toks, i); ok {
+			nums := parseNumberList(inp[toks[i+1].Begin() : toks[e-1].End()+1])
+			add(toks[i].Begin(), toks[e].End(), RefCitation, joinNumbers(nums), nums)
+			i = e
+		}
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].word.Begin() < refs[j].word.Begin()
+	})
+	d.refs[sec] = refs
+	for _, r := range refs {
+		d.protect(sec, r.word.Begin(), r.word.End())
+	}
+	if _, ok := d.sents[sec]; ok {
+		d.assembleSection(sec)
+	}
+	return refs, nil
+}
+
+// SectionReferences answers the references recognised in the given
+// section.
+func (d *Document) SectionReferences(sec string) []*Reference {
+	if v, ok := d.refs[sec]; ok {
+		return v
+	}
+
+	return nil
+}
+
+// noteBodies collects the numbered notes in the document.
+//
+// All numbered lines of a section whose name mentions notes are taken
+// to be notes.  In other sections, numbered lines that follow a
+// `Notes` heading are taken to be notes.
+func (d *Document) noteBodies() map[int]*NoteBody {
+	res := make(map[int]*NoteBody)
+	for _, sec := range d.Sections() {
+		inp := d.input[sec]
+		inNotes := strings.Contains(strings.ToLower(sec), "note")
+		off := 0
+		for _, line := range strings.SplitAfter(inp, "\n") {
+			text := strings.TrimRight(line, "\r\n")
+			switch {
+			case notesHeadRe.MatchString(text):
+				inNotes = true
+			case inNotes:
+				if m := noteBodyRe.FindStringSubmatch(text); m != nil {
+					n, _ := strconv.Atoi(m[1])
+					if _, ok := res[n]; !ok && text != "" {
+						res[n] = &NoteBody{sec, n, off, off + len(text) - 1, text}
+					}
+				} else if strings.TrimSpace(text) != "" && !strings.Contains(strings.ToLower(sec), "note") {
+					inNotes = false
+				}
+			}
+			off += len(line)
+		}
+	}
+	return res
+}
+
+// citingNouns lists the lowercase words that number figures, tables
+// and the like.  A number glued to them, as in `Fig.1` and `Table.2`,
+// is not a citation.
+var citingNouns = map[string]struct{}{
+	"fig": {}, "figs": {}, "figure": {}, "figures": {},
+	"table": {}, "tables": {}, "tab": {}, "scheme": {}, "schemes": {},
+	"eq": {}, "eqn": {}, "eqs": {}, "equation": {},
+	"ex": {}, "example": {}, "entry": {}, "ref": {}, "refs": {},
+	"no": {}, "nos": {}, "step": {}, "compound": {},
+}
+
+// trailingCitation detects citation numbers glued to the sentence
+// terminator or closing parenthesis at the given index: `.2,3 The`.
+// It answers the indices of the first and last tokens of the numbers.
+//
+// The numbers must end the text, or be followed by space and a token
+// that is not lowercase.  Numbers glued to words such as `Fig.`, and
+// to parenthesised groups that are themselves attached to words --
+// the subscripts of `Pd(OAc)2` and `Ca(OH)2` -- are not citations.
+func trailingCitation(toks []*TextToken, i int) (int, int, bool) {
+	l := len(toks)
+	t := toks[i]
+	if t.Type() != TokMayBeTerm && t.Type() != TokTerm && t.Type() != TokParenClose {
+		return -1, -1, false
+	}
+	if i == 0 || i+1 >= l || isASCIIDigits(toks[i-1].Text()) || toks[i-1].Type() == TokSpace {
+		return -1, -1, false
+	}
+	if t.Type() == TokParenClose {
+		if o := matchingParenOpen(toks, i); o > 0 && toks[o-1].Type() != TokSpace && !isOpening(toks[o-1]) {
+			return -1, -1, false
+		}
+	} else if _, ok := citingNouns[strings.ToLower(toks[i-1].Text())]; ok {
+		return -1, -1, false
+	}
+
+	b := i + 1
+	if !isCitationNumber(toks[b].Text()) {
+		return -1, -1, false
+	}
+	e := b
+	for e+2 < l && (toks[e+1].Text() == "," || isHyphen(toks[e+1])) && isCitationNumber(toks[e+2].Text()) {
+		e += 2
+	}
+	if e+1 < l {
+		if toks[e+1].Type() != TokSpace {
+			return -1, -1, false
+		}
+		if j := skipSpaces(toks, e+1); j < l && unicode.IsLower(firstRune(toks[j])) {
+			return -1, -1, false
+		}
+	}
+	return b, e, true
+}
+
+// matchingParenOpen answers the index of the opening parenthesis that
+// the closing one at the given index matches, or -1.
+func matchingParenOpen(toks []*TextToken, i int) int {
+	depth := 0
+	for j := i; j >= 0; j-- {
+		switch toks[j].Type() {
+		case TokParenClose:
+			depth++
+		case TokParenOpen:
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// isOpening answers if the given token opens a group of any kind.
+func isOpening(t *TextToken) bool {
+	switch t.Type() {
+	case TokParenOpen, TokBracketOpen, TokBraceOpen:
+		return true
+	}
+	return false
+}
+
+// bracketCitation detects bracketed citation numbers beginning at the
+// given index: `[4]`, `[2,3]`, `[4-6]`.  Brackets that follow chemical
+// names are compound labels, and are not citations.  It answers the
+// index of the closing bracket.
+func bracketCitation(inp string, toks []*TextToken, i int) (int, bool) {
+	l := len(toks)
+	if toks[i].Type() != TokBracketOpen || i+2 >= l || !isCitationNumber(toks[i+1].Text()) {
+		return -1, false
+	}
+	if i >= 2 && toks[i-1].Type() == TokSpace {
+		if _, _, ok := chemicalNameBefore(inp, toks, i-2); ok {
+			return -1, false
+		}
+	}
+
+	e := i + 2
+	for e < l && (toks[e].Text() == "," || isHyphen(toks[e])) {
+		j := skipSpaces(toks, e+1)
+		if j >= l || !isCitationNumber(toks[j].Text()) {
+			return -1, false
+		}
+		e = j + 1
+	}
+	if e >= l || toks[e].Type() != TokBracketClose {
+		return -1, false
+	}
+	return e, true
+}
+
+// isCitationNumber answers if the given text is a plausible citation
+// number.
+func isCitationNumber(s string) bool {
+	return isASCIIDigits(s) && len(s) <= 3
+}
+
+// parseNumberList parses lists such as `2 and 3`, `11, 12, and 14`
+// and `4-6`, expanding ranges.
+func parseNumberList(s string) []int {
+	var res []int
+	rng := false
+	for _, p := range numListRe.FindAllString(s, -1) {
+		n, err := strconv.Atoi(p)
+		switch {
+		case err != nil:
+			rng = p == "-" || p == "–" || p == "to"
+		case rng && len(res) > 0 && n > res[len(res)-1] && n-res[len(res)-1] <= 100:
+			for k := res[len(res)-1] + 1; k <= n; k++ {
+				res = append(res, k)
+			}
+			rng = false
+		default:
+			res = append(res, n)
+			rng = false
+		}
+	}
+	return res
+}
+
+// joinNumbers answers the given numbers as a comma-separated list.
+func joinNumbers(nums []int) string {
+	ss := make([]string, len(nums))
+	for i, n := range nums {
+		ss[i] = strconv.Itoa(n)
+	}
+	return strings.Join(ss, ",")
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"testing"
+)
+
+func TestReferences001(t *testing.T) {
+	doc, _ := NewDocument("References001")
+	doc.SetInput("Body", "The process of U.S. Pat. No. 4,123,456 is improved. The yield was high.2,3 "+
+		"It was repeated (Notes 1 and 2). Similar results were reported [4-6].")
+	doc.SetInput("Notes", "1. The reaction was repeated thrice.\n2. Yields are averaged.")
+	doc.Tokenize()
+	doc.AssembleSentences()
+
+	refs, err := doc.RecognizeReferences("Body")
+	if err != nil {
+		t.Fatalf("Failed to recognise references : %s", err.Error())
+	}
+
+	exp := []struct {
+		rtype RefType
+		text  string
+		value string
+		notes int
+	}{
+		{RefPatent, "U.S. Pat. No. 4,123,456", "4123456", 0},
+		{RefCitation, "2,3", "2,3", 0},
+		{RefNote, "(Notes 1 and 2)", "1,2", 2},
+		{RefCitation, "[4-6]", "4,5,6", 0},
+	}
+	if len(refs) != len(exp) {
+		t.Fatalf("Expected reference count : %d, observed : %d", len(exp), len(refs))
+	}
+	for i, e := range exp {
+		r := refs[i]
+		if r.Type() != e.rtype || r.Word().Text() != e.text || r.Value() != e.value || len(r.Notes()) != e.notes {
+			t.Errorf("Expected : %v, observed : %s %s %s %d", e, RefDescriptions[r.Type()], r.Word().Text(), r.Value(), len(r.Notes()))
+		}
+		if r.Word().Class() != RefDescriptions[e.rtype] {
+			t.Errorf("Unexpected word class : %s", r.Word().Class())
+		}
+	}
+	if n := refs[2].Notes()[1]; n.Section != "Notes" || n.Number != 2 || n.Text != "2. Yields are averaged." {
+		t.Errorf("Unexpected note body : %v", *n)
+	}
+
+	sents := doc.SectionSentences("Body")
+	expSents := []string{
+		"The process of U.S. Pat. No. 4,123,456 is improved.",
+		"The yield was high.2,3",
+		"It was repeated Notes 1 and 2.",
+		"Similar results were reported 4-6.",
+	}
+	if len(sents) != len(expSents) {
+		for _, s := range sents {
+			t.Logf("%d:%d %s", s.Begin(), s.End(), s.Text())
+		}
+		t.Fatalf("Expected sentence count : %d, observed : %d", len(expSents), len(sents))
+	}
+	for i, s := range sents {
+		if s.Text() != expSents[i] {
+			t.Errorf("Expected sentence : %s, observed : %s", expSents[i], s.Text())
+		}
+	}
+}
+
+//
+
+func TestProtect001(t *testing.T) {
+	in := "See Fig. A. B. 12 for details. Next sentence."
+	ti := NewTextTokenIterator(in)
+	var toks []*TextToken
+	for err := ti.MoveNext(); err == nil; err = ti.MoveNext() {
+		toks = append(toks, ti.Item())
+	}
+
+	si := NewSentenceIterator(toks)
+	if err := si.Protect(9, 16); err != nil {
+		t.Fatalf("Failed to protect a span : %s", err.Error())
+	}
+	if err := si.Protect(5, 16); err == nil {
+		t.Errorf("Expected an error for offsets not matching tokens")
+	}
+
+	var sents []*Sentence
+	for err := si.MoveNext(); err == nil; err = si.MoveNext() {
+		sents = append(sents, si.Item())
+	}
+	if len(sents) != 2 || sents[0].End() != 29 {
+		for _, s := range sents {
+			t.Logf("%d:%d %s", s.Begin(), s.End(), s.Text())
+		}
+		t.Fatalf("Expected 2 sentences, with the first ending at 29")
+	}
+}
+
+//
+
+func TestReferences002(t *testing.T) {
+	cases := []struct {
+		in  string
+		exp int
+	}{
+		{"Pd(OAc)2 was added.", 0},
+		{"The catalyst was Pd(OAc)2 Then it was filtered.", 0},
+		{"The base was Ca(OH)2 The mixture was stirred.", 0},
+		{"See Fig.1 The yield was high.", 0},
+		{"Table.2 shows the yields.", 0},
+		{"The yield was high.2 Then it was filtered.", 1},
+		{"The method is known (see the review).3 It was used.", 1},
+		{"The yield was high.2,3", 1},
+	}
+	for _, c := range cases {
+		doc, _ := NewDocument("References002")
+		doc.SetInput("Body", c.in)
+		doc.Tokenize()
+		refs, err := doc.RecognizeReferences("Body")
+		if err != nil {
+			t.Fatalf("Failed to recognise references : %s", err.Error())
+		}
+		if len(refs) != c.exp {
+			t.Errorf("Expected reference count in %q : %d, observed : %d", c.in, c.exp, len(refs))
+		}
+	}
+}
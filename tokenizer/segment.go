@@ -0,0 +1,287 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"bufio"
+	_ "embed" // For the bundled lexicon
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenIterator is the interface common to iterators that retrieve
+// consecutive text tokens from an input text.
+type TokenIterator interface {
+	MoveNext() error
+	Item() *TextToken
+}
+
+// Segmenter subdivides runs of text that are not separated by space
+// into words.
+type Segmenter interface {
+	// Segment answers the lengths in bytes of the consecutive words of
+	// the given text.  They add up to the length of the text.
+	Segment(text string) []int
+}
+
+// isCJK answers if the given rune belongs to a script that does not
+// separate words with space: Han, Hiragana or Katakana.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || r == '\u30fc' // Prolonged sound mark
+}
+
+// needsSegmentation answers if the given token is a run of text that
+// includes CJK runes.
+func needsSegmentation(t *TextToken) bool {
+	if t.ttype != TokMayBeWord {
+		return false
+	}
+	for _, r := range t.text {
+		if isCJK(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// joined answers if the two given tokens are adjacent parts of a run
+// of text.
+func joined(a, b *TextToken) bool {
+	return a.ttype == TokMayBeWord && b.ttype == TokMayBeWord && a.end+1 == b.begin
+}
+
+// SegmentingIterator subdivides the CJK runs among the tokens of an
+// underlying iterator into words, using a segmenter.  Other tokens
+// are passed through unchanged.
+type SegmentingIterator struct {
+	ti    TokenIterator
+	sg    Segmenter
+	queue []*TextToken
+	ct    *TextToken
+}
+
+// NewSegmentingIterator creates and initialises an iterator that
+// segments the tokens of the given iterator.
+func NewSegmentingIterator(ti TokenIterator, sg Segmenter) *SegmentingIterator {
+	si := &SegmentingIterator{}
+	si.ti = ti
+	si.sg = sg
+	return si
+}
+
+// Item answers the current token.  This has no side effects, and can
+// be invoked any number of times.
+func (si *SegmentingIterator) Item() *TextToken {
+	return si.ct
+}
+
+// MoveNext answers the next word of the current CJK run, if any, or
+// else the next token of the underlying iterator.
+//
+// The return value is either `nil` (more tokens may be available) or
+// `io.EOF` (no more tokens).
+func (si *SegmentingIterator) MoveNext() error {
+	if len(si.queue) > 0 {
+		si.ct = si.queue[0]
+		si.queue = si.queue[1:]
+		return nil
+	}
+
+	if err := si.ti.MoveNext(); err != nil {
+		return err
+	}
+	t := si.ti.Item()
+	if !needsSegmentation(t) {
+		si.ct = t
+		return nil
+	}
+
+	b := t.begin
+	for _, n := range si.sg.Segment(t.text) {
+		si.queue = append(si.queue, &TextToken{t.text[b-t.begin : b-t.begin+n], b, b + n - 1, TokMayBeWord})
+		b += n
+	}
+	si.ct = si.queue[0]
+	si.queue = si.queue[1:]
+	return nil
+}
+
+//go:embed data/cjk_lexicon.txt
+var cjkLexicon string
+
+// Lexicon holds words with their relative frequencies, for
+// segmentation.
+type Lexicon struct {
+	freqs  map[string]float64
+	total  float64
+	maxLen int // In runes
+}
+
+// NewLexicon creates and initialises an empty lexicon.
+func NewLexicon() *Lexicon {
+	return &Lexicon{freqs: make(map[string]float64)}
+}
+
+// Add records the given word with the given relative frequency,
+// adding to any that it already has.
+func (lx *Lexicon) Add(word string, freq float64) {
+	if word == "" || freq <= 0 {
+		return
+	}
+	lx.freqs[word] += freq
+	lx.total += freq
+	if n := utf8.RuneCountInString(word); n > lx.maxLen {
+		lx.maxLen = n
+	}
+}
+
+// Len answers the number of words in the lexicon.
+func (lx *Lexicon) Len() int {
+	return len(lx.freqs)
+}
+
+// Contains answers if the lexicon has the given word.
+func (lx *Lexicon) Contains(word string) bool {
+	_, ok := lx.freqs[word]
+	return ok
+}
+
+// ReadLexicon reads a lexicon from the given reader.  Each line holds a
+// word and, optionally, its relative frequency, separated by space.
+// Empty lines and those beginning with `#` are ignored.
+func ReadLexicon(r io.Reader) (*Lexicon, error) {
+	lx := NewLexicon()
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fs := strings.Fields(line)
+		freq := 1.0
+		if len(fs) > 1 {
+			f, err := strconv.ParseFloat(fs[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid frequency at line %d : %s", n, fs[1])
+			}
+			freq = f
+		}
+		lx.Add(fs[0], freq)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return lx, nil
+}
+
+// DefaultLexicon answers a new copy of the bundled lexicon of common
+// Chinese and Japanese words, with an emphasis on chemical literature.
+func DefaultLexicon() *Lexicon {
+	lx, err := ReadLexicon(strings.NewReader(cjkLexicon))
+	if err != nil {
+		panic(err)
+	}
+	return lx
+}
+
+// LexiconSegmenter segments text into the sequence of words of the
+// least total cost, over the lattice of all words of its lexicon that
+// occur in the text.
+//
+// The cost of a word is the negative logarithm of its probability.
+// Runes not covered by the lexicon form words on their own, at a high
+// cost.  Runs of Katakana are also candidate words at that cost, to
+// keep transliterated names whole.  Runs of runes outside the CJK
+// scripts are always kept whole.
+type LexiconSegmenter struct {
+	lex *Lexicon
+}
+
+// NewLexiconSegmenter creates and initialises a segmenter that uses
+// the given lexicon.
+func NewLexiconSegmenter(lx *Lexicon) *LexiconSegmenter {
+	return &LexiconSegmenter{lx}
+}
+
+// cost answers the cost of the given word, and if the word is known.
+func (ls *LexiconSegmenter) cost(word string) (float64, bool) {
+	f, ok := ls.lex.freqs[word]
+	if !ok {
+		return 0, false
+	}
+	return math.Log(ls.lex.total / f), true
+}
+
+// unknownCost answers the cost of a word not in the lexicon.
+func (ls *LexiconSegmenter) unknownCost() float64 {
+	return math.Log(ls.lex.total+1) + 10
+}
+
+// runEnd answers the index of the end of the run of runes beginning at
+// index `i` that satisfy the given predicate.
+func runEnd(rs []rune, i int, pred func(rune) bool) int {
+	j := i
+	for j < len(rs) && pred(rs[j]) {
+		j++
+	}
+	return j
+}
+
+// Segment answers the lengths in bytes of the consecutive words of the
+// given text.
+func (ls *LexiconSegmenter) Segment(text string) []int {
+	rs := []rune(text)
+	l := len(rs)
+	best := make([]float64, l+1)
+	back := make([]int, l+1)
+	for i := 1; i <= l; i++ {
+		best[i] = math.Inf(1)
+	}
+
+	unk := ls.unknownCost()
+	katakana := func(r rune) bool { return unicode.Is(unicode.Katakana, r) || r == '\u30fc' }
+	other := func(r rune) bool { return !isCJK(r) }
+	relax := func(i, j int, c float64) {
+		if best[i]+c < best[j] {
+			best[j] = best[i] + c
+			back[j] = i
+		}
+	}
+
+	for i := 0; i < l; i++ {
+		if math.IsInf(best[i], 1) {
+			continue
+		}
+		if other(rs[i]) {
+			relax(i, runEnd(rs, i, other), 0)
+			continue
+		}
+
+		relax(i, i+1, unk)
+		if katakana(rs[i]) {
+			relax(i, runEnd(rs, i, katakana), unk)
+		}
+		for n := 1; n <= ls.lex.maxLen && i+n <= l; n++ {
+			if c, ok := ls.cost(string(rs[i : i+n])); ok {
+				relax(i, i+n, c)
+			}
+		}
+	}
+
+	var res []int
+	for j := l; j > 0; j = back[j] {
+		res = append(res, len(string(rs[back[j]:j])))
+	}
+	for a, b := 0, len(res)-1; a < b; a, b = a+1, b-1 {
+		res[a], res[b] = res[b], res[a]
+	}
+	return res
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func segmentedTokens(t *testing.T, in string) []string {
+	doc, _ := NewDocument("Segment")
+	doc.SetSegmenter(NewLexiconSegmenter(DefaultLexicon()))
+	doc.SetInput("P", in)
+	doc.Tokenize()
+
+	var res []string
+	for _, tok := range doc.SectionTokens("P") {
+		if in[tok.Begin():tok.End()+1] != tok.Text() {
+			t.Errorf("Token offsets mismatch : %q at %d:%d", tok.Text(), tok.Begin(), tok.End())
+		}
+		res = append(res, tok.Text())
+	}
+	return res
+}
+
+func TestSegment001(t *testing.T) {
+	cases := []struct {
+		in  string
+		exp string
+	}{
+		{"将甲苯加入乙醇溶液中搅拌2小时。", "将|甲苯|加入|乙醇|溶液|中|搅拌|2|小时|。"},
+		{"用乙酸乙酯萃取，得到白色固体。", "用|乙酸乙酯|萃取|，|得到|白色|固体|。"},
+		{"トルエンを加えて撹拌した。", "トルエン|を|加え|て|撹拌|した|。"},
+		{"反応液をNaOHで洗浄した", "反応液|を|NaOH|で|洗浄|した"},
+	}
+	for _, c := range cases {
+		obs := strings.Join(segmentedTokens(t, c.in), "|")
+		if obs != c.exp {
+			t.Errorf("Expected segmentation : %s, observed : %s", c.exp, obs)
+		}
+	}
+
+	ti := NewTextTokenIterator("甲苯和乙醇")
+	n := 0
+	for err := ti.MoveNext(); err == nil; err = ti.MoveNext() {
+		n++
+	}
+	if n != 1 {
+		t.Errorf("Expected unsegmented token count : 1, observed : %d", n)
+	}
+}
+
+//
+
+func TestSegment002(t *testing.T) {
+	lx, err := ReadLexicon(strings.NewReader("# Test\n甲 1\n甲乙 5\n乙丙\n"))
+	if err != nil {
+		t.Fatalf("Unable to read lexicon : %s", err.Error())
+	}
+	if lx.Len() != 3 || !lx.Contains("乙丙") {
+		t.Errorf("Expected lexicon size : 3, observed : %d", lx.Len())
+	}
+
+	ls := NewLexiconSegmenter(lx)
+	lens := ls.Segment("甲乙丙")
+	if len(lens) != 2 || lens[0] != 3 || lens[1] != 6 {
+		t.Errorf("Expected segment lengths : [3 6], observed : %v", lens)
+	}
+
+	if _, err := ReadLexicon(strings.NewReader("甲 x\n")); err == nil {
+		t.Errorf("Expected an error for an invalid frequency")
+	}
+}
+
+//
+
+func TestSegment003(t *testing.T) {
+	inp := strings.Repeat("将甲苯加入乙醇溶液中搅拌2小时。用乙酸乙酯萃取，得到白色固体。", 10)
+	inserts := []string{"", "乙", "苯", "溶液", "。", " ", "A", "2"}
+
+	doc, _ := NewDocument("Segment003")
+	doc.SetSegmenter(NewLexiconSegmenter(DefaultLexicon()))
+	doc.SetLanguage(Language("zh"))
+	doc.SetInput("P", inp)
+	doc.Tokenize()
+	doc.AssembleSentences()
+
+	rnd := rand.New(rand.NewSource(7))
+	for n := 0; n < 200; n++ {
+		cur, _ := doc.Input("P")
+		rs := []rune(cur)
+		ri := rnd.Intn(len(rs))
+		rd := rnd.Intn(3)
+		if ri+rd > len(rs) {
+			rd = len(rs) - ri
+		}
+		off := len(string(rs[:ri]))
+		del := len(string(rs[ri : ri+rd]))
+		ins := inserts[rnd.Intn(len(inserts))]
+		if _, err := doc.Edit("P", off, del, ins); err != nil {
+			t.Fatalf("Failed to apply edit %d : %s", n, err.Error())
+		}
+
+		ref, _ := NewDocument("Segment003-ref")
+		ref.SetSegmenter(NewLexiconSegmenter(DefaultLexicon()))
+		ref.SetLanguage(Language("zh"))
+		nin, _ := doc.Input("P")
+		ref.SetInput("P", nin)
+		ref.Tokenize()
+		ref.AssembleSentences()
+
+		toks, rtoks := doc.SectionTokens("P"), ref.SectionTokens("P")
+		if len(toks) != len(rtoks) {
+			t.Fatalf("Edit %d : expected token count : %d, observed : %d", n, len(rtoks), len(toks))
+		}
+		for i := range toks {
+			if *toks[i] != *rtoks[i] {
+				t.Fatalf("Edit %d : expected token : %v, observed : %v", n, *rtoks[i], *toks[i])
+			}
+		}
+		if len(doc.SectionSentences("P")) != len(ref.SectionSentences("P")) {
+			t.Fatalf("Edit %d : sentence count mismatch", n)
+		}
+	}
+}
@@ -6,7 +6,9 @@
 package tokenizer
 
 import (
+	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"unicode"
 )
@@ -16,9 +18,13 @@ import (
 // It holds information about its text, its offsets and its
 // constituent text tokens.
 type Sentence struct {
-	token   TextToken // Actual text and its properties
-	bTokIdx int       // Index of beginning token of this sentence
-	eTokIdx int       // Index of ending token of this sentence
+	token    TextToken // Actual text and its properties
+	bTokIdx  int       // Index of beginning token of this sentence
+	eTokIdx  int       // Index of ending token of this sentence
+	trace    []BoundaryDecision
+	groups   []*Group
+	quotes   []*Group
+	warnings []GroupWarning
 }
 
 // newSentence creates and initialises a sentence with its text and
@@ -79,6 +85,18 @@ type SentenceIterator struct {
 	inMayBeTerm bool
 	inTermSpc   bool
 	grpStack    []groupIndex
+	qStack      []*Group // Open quotations
+	qRoots      []*Group // Quotations of the current sentence
+	qLost       []*Group // Abandoned quotations
+	qWarns      []GroupWarning
+	abbrevs     *AbbrevSet
+	lang        *LanguagePack
+	starts      StartClassifier
+	termType    TokenType // Type of the last terminator
+	opts        SentenceOptions
+	trace       []BoundaryDecision // Decisions for the current sentence
+	prots       map[int]int        // Protected spans : beginning -> ending token index
+	breaks      map[int]struct{}   // Indices of tokens that must begin sentences
 }
 
 // NewSentenceIterator creates and initialises a sentence iterator
@@ -98,6 +116,76 @@ func NewTechnicalSentenceIterator(toks []*TextToken) *SentenceIterator {
 	return si
 }
 
+// Protect marks the text between the given offsets (both inclusive)
+// as indivisible: no sentence ends inside it.  The offsets must be
+// those of the beginning and ending tokens of the text, respectively.
+//
+// Should the text begin right after a sentence terminator, or should
+// it be parenthesised and follow a terminator, it is attached to the
+// sentence ending there.  This suits trailing citations and notes.
+func (si *SentenceIterator) Protect(begin, end int) error {
+	bidx, eidx, err := spanIndices(si.toks, begin, end)
+	if err != nil {
+		return err
+	}
+
+	if si.prots == nil {
+		si.prots = make(map[int]int)
+	}
+	si.prots[bidx] = eidx
+	return nil
+}
+
+// BreakBefore forces a sentence to begin with the token at the given
+// offset, regardless of the preceding text.  This suits headings and
+// other text that does not end with a terminator.
+func (si *SentenceIterator) BreakBefore(begin int) error {
+	idx, err := tokenIndex(si.toks, begin)
+	if err != nil {
+		return err
+	}
+
+	if si.breaks == nil {
+		si.breaks = make(map[int]struct{})
+	}
+	si.breaks[idx] = struct{}{}
+	return nil
+}
+
+// spanIndices answers the indices of the tokens that begin and end at
+// the given offsets, respectively.
+func spanIndices(toks []*TextToken, begin, end int) (int, int, error) {
+	l := len(toks)
+	bidx := sort.Search(l, func(i int) bool { return toks[i].Begin() >= begin })
+	eidx := sort.Search(l, func(i int) bool { return toks[i].End() >= end })
+	if bidx == l || eidx == l || toks[bidx].Begin() != begin || toks[eidx].End() != end {
+		return -1, -1, fmt.Errorf("Offsets do not match tokens : %d:%d", begin, end)
+	}
+	return bidx, eidx, nil
+}
+
+// tokenIndex answers the index of the token that begins at the given
+// offset.
+func tokenIndex(toks []*TextToken, begin int) (int, error) {
+	l := len(toks)
+	idx := sort.Search(l, func(i int) bool { return toks[i].Begin() >= begin })
+	if idx == l || toks[idx].Begin() != begin {
+		return -1, fmt.Errorf("Offset does not match a token : %d", begin)
+	}
+	return idx, nil
+}
+
+// seek makes the iterator continue from the token at the given index.
+func (si *SentenceIterator) seek(idx int) {
+	si.idx = idx
+}
+
+// SetAbbreviations makes the iterator consult the given abbreviation
+// set, rather than the package-level tables.
+func (si *SentenceIterator) SetAbbreviations(a *AbbrevSet) {
+	si.abbrevs = a
+}
+
 // Item answers the current sentence.  This has no side effects, and
 // can be invoked any number of times.
 func (si *SentenceIterator) Item() *Sentence {
@@ -126,6 +214,17 @@ func (si *SentenceIterator) MoveNext() error {
 		si.cs = newSentence(si.buf,
 			si.toks[begin].Begin(), si.toks[eend].End(),
 			begin, eend)
+		si.cs.trace = si.trace
+		si.trace = nil
+		if si.opts.GroupAware {
+			si.cs.groups, si.cs.warnings = buildGroups(si.toks, begin, eend)
+			si.grpStack = si.grpStack[:0]
+		}
+		if si.opts.QuoteAware {
+			var ws []GroupWarning
+			si.cs.quotes, ws = si.endQuotes(eend)
+			si.cs.warnings = mergeWarnings(si.cs.warnings, ws)
+		}
 		si.buf = ""
 		si.inTerm = false
 		si.inTermSpc = false
@@ -135,7 +234,37 @@ func (si *SentenceIterator) MoveNext() error {
 	for end < size {
 		t := si.toks[end]
 
-		switch t.ttype {
+		if _, ok := si.breaks[end]; ok && end > begin {
+			if si.forcedBreak(begin, end) {
+				si.note(end, RuleForcedBreak, true)
+				commonProc(false)
+				si.idx = end
+				return nil
+			}
+			begin = end
+		}
+
+		if pe, ok := si.prots[end]; ok {
+			if si.protectedSpan(end, pe) {
+				commonProc(false)
+				si.idx = end
+				return nil
+			}
+			end = pe + 1
+			continue
+		}
+
+		if si.opts.QuoteAware && si.isQuote(end) {
+			if si.quoteToken(end) {
+				commonProc(false)
+				si.idx = end
+				return nil
+			}
+			end++
+			continue
+		}
+
+		switch si.tokenType(t) {
 		case TokSpace:
 			{
 				switch {
@@ -162,37 +291,62 @@ func (si *SentenceIterator) MoveNext() error {
 				pidx := si.prevNonSpaceToken(end)
 				if pidx != -1 {
 					prevt := si.toks[pidx]
-					if prevt.ttype == TokSymbol || prevt.ttype == TokPunct {
+					if (prevt.ttype == TokSymbol || prevt.ttype == TokPunct) &&
+						(si.spaced() || si.tokenType(t) != TokTerm) {
 						si.inTerm = false
 						si.inMayBeTerm = true
+						si.note(end, RuleSymbolBefore, false)
 					} else {
 						prev := strings.ToLower(prevt.text)
-						if _, ok := NonTermAbbrevs[prev]; ok {
+						abbrevs := si.abbreviations()
+						if si.isOrdinal(end, pidx) {
 							si.inTerm = false
 							si.inMayBeTerm = false
-						} else if _, ok := MayBeTermAbbrevs[prev]; ok {
+							si.note(end, RuleOrdinalNumber, false)
+						} else if _, ok := abbrevs.NonTerm[prev]; ok {
+							si.inTerm = false
+							si.inMayBeTerm = false
+							si.note(end, RuleNonTermAbbrev, false)
+						} else if _, ok := abbrevs.MayBeTerm[prev]; ok {
 							si.inTerm = false
 							si.inMayBeTerm = true
-						} else if grp, ok := MayBeTermGroupAbbrevs[prev]; ok {
+							si.note(end, RuleMayBeTermAbbrev, false)
+						} else if grp, ok := abbrevs.Group[prev]; ok {
 							si.handleGroupAbbrevs(pidx, grp)
+							if si.inTerm {
+								si.note(end, RuleTerminator, false)
+							} else {
+								si.note(end, RuleGroupAbbrev, false)
+							}
+						} else if si.startsGroupAbbrev(pidx, abbrevs) {
+							si.inTerm = false
+							si.inMayBeTerm = false
+							si.note(end, RuleGroupAbbrev, false)
 						} else {
 							si.inTerm = true
 							si.inMayBeTerm = false
+							si.note(end, RuleTerminator, false)
 						}
 					}
 				} else {
 					si.inTerm = false
 					si.inMayBeTerm = false
+					si.note(end, RuleNoPrecedingText, false)
 				}
 				si.inTermSpc = false
 				si.buf += t.text
 				si.idxTerm = end
+				si.termType = si.tokenType(t)
 				end++
 			}
 
 		case TokParenOpen, TokBracketOpen, TokBraceOpen:
-			si.grpStack = append(si.grpStack, groupIndex{end, t.ttype})
+			if (si.inTerm || si.inTermSpc) && si.hold(end) {
+				si.resume(end)
+			}
+			si.pushGroup(end)
 			if si.inTerm || si.inTermSpc {
+				si.note(end, RuleGroupOpen, true)
 				commonProc(false)
 				si.idx = end
 				return nil
@@ -201,22 +355,10 @@ func (si *SentenceIterator) MoveNext() error {
 
 		case TokParenClose, TokBracketClose, TokBraceClose:
 			{
-				if len(si.grpStack) > 0 {
-					lsta := si.grpStack[len(si.grpStack)-1]
-					switch t.ttype {
-					case TokParenClose:
-						if lsta.tokType == TokParenOpen {
-							si.grpStack = si.grpStack[:len(si.grpStack)-1]
-						}
-					case TokBracketClose:
-						if lsta.tokType == TokBracketOpen {
-							si.grpStack = si.grpStack[:len(si.grpStack)-1]
-						}
-					case TokBraceClose:
-						if lsta.tokType == TokBraceOpen {
-							si.grpStack = si.grpStack[:len(si.grpStack)-1]
-						}
-					}
+				si.popGroup(end)
+				if si.inTerm && si.opts.GroupAware {
+					// The group closes the sentence too.
+					si.idxTerm = end
 				}
 
 				if si.inTerm || si.inTermSpc {
@@ -231,6 +373,7 @@ func (si *SentenceIterator) MoveNext() error {
 								break
 							}
 							if unicode.IsUpper(r) {
+								si.note(end, RuleUppercaseFollow, true)
 								commonProc(false)
 								si.idx = end + 1
 								return nil
@@ -248,9 +391,35 @@ func (si *SentenceIterator) MoveNext() error {
 					{
 						if t.ttype == TokSquote || t.ttype == TokDquote ||
 							t.ttype == TokFinQuote {
-							commonProc(true)
-							si.idx = end + 1
-							return nil
+							if !si.hold(end) {
+								si.note(end, RuleQuoteClose, true)
+								if si.spaced() {
+									commonProc(true)
+								} else {
+									si.idxTerm = end
+									commonProc(false)
+								}
+								si.idx = end + 1
+								return nil
+							}
+						} else if !si.spaced() && si.termType == TokTerm {
+							if isClosing(t) {
+								si.note(end, RuleQuoteClose, false)
+								si.buf += t.text
+								si.idxTerm = end
+								end++
+								continue
+							}
+							if si.quoteContinues(end) {
+								si.note(end, RuleNoSpaceFollow, false)
+							} else if !si.hold(end) {
+								si.note(end, RuleUnspacedFollow, true)
+								commonProc(false)
+								si.idx = end
+								return nil
+							}
+						} else {
+							si.note(end, RuleNoSpaceFollow, false)
 						}
 					}
 
@@ -260,11 +429,22 @@ func (si *SentenceIterator) MoveNext() error {
 						for _, r = range t.text {
 							break
 						}
-						if unicode.IsUpper(r) || t.ttype == TokSquote ||
+						if si.startsSentence(end) || t.ttype == TokSquote ||
 							t.ttype == TokDquote || t.ttype == TokIniQuote {
-							commonProc(false)
-							si.idx = end
-							return nil
+							if !si.hold(end) {
+								if unicode.IsUpper(r) {
+									si.note(end, RuleUppercaseFollow, true)
+								} else if si.startsSentence(end) {
+									si.note(end, RuleStartFollow, true)
+								} else {
+									si.note(end, RuleQuoteFollow, true)
+								}
+								commonProc(false)
+								si.idx = end
+								return nil
+							}
+						} else {
+							si.note(end, RuleLowercaseFollow, false)
 						}
 						if end > si.idxTerm {
 							for i := si.idxTerm + 1; i < end; i++ {
@@ -294,6 +474,7 @@ func (si *SentenceIterator) MoveNext() error {
 			}
 		}
 		if !skip {
+			si.note(end, RuleEndOfInput, true)
 			commonProc(true)
 			si.idx = end
 			return nil
@@ -303,6 +484,115 @@ func (si *SentenceIterator) MoveNext() error {
 	return io.EOF
 }
 
+// pushGroup records the opening grouping token at the given index.
+func (si *SentenceIterator) pushGroup(idx int) {
+	si.grpStack = append(si.grpStack, groupIndex{idx, si.toks[idx].ttype})
+}
+
+// popGroup matches the closing grouping token at the given index
+// against the innermost open group, if any.
+func (si *SentenceIterator) popGroup(idx int) {
+	if len(si.grpStack) == 0 {
+		return
+	}
+
+	lsta := si.grpStack[len(si.grpStack)-1]
+	switch si.toks[idx].ttype {
+	case TokParenClose:
+		if lsta.tokType == TokParenOpen {
+			si.grpStack = si.grpStack[:len(si.grpStack)-1]
+		}
+	case TokBracketClose:
+		if lsta.tokType == TokBracketOpen {
+			si.grpStack = si.grpStack[:len(si.grpStack)-1]
+		}
+	case TokBraceClose:
+		if lsta.tokType == TokBraceOpen {
+			si.grpStack = si.grpStack[:len(si.grpStack)-1]
+		}
+	}
+}
+
+// forcedBreak prepares the state for ending the current sentence
+// before the token at index `end`.  It answers `false` if the
+// sentence has no text to end, after discarding any pending spaces.
+func (si *SentenceIterator) forcedBreak(begin, end int) bool {
+	if si.inTerm || si.inTermSpc {
+		return true
+	}
+
+	eidx := si.prevNonSpaceToken(end)
+	if eidx < begin {
+		si.buf = ""
+		return false
+	}
+	si.buf = strings.TrimRightFunc(si.buf, unicode.IsSpace)
+	si.idxTerm = eidx
+	return true
+}
+
+// protectedSpan consumes the protected span of tokens from index `b`
+// through index `e`.
+//
+// A span that follows a sentence terminator immediately, or that is
+// parenthesised, is attached to the sentence ending at that
+// terminator.  Any other span is treated as a single word.  It
+// answers `true` if the current sentence should end before the span.
+// Its decisions at candidate boundaries are recorded in trace mode.
+func (si *SentenceIterator) protectedSpan(b, e int) bool {
+	attach := false
+	switch {
+	case si.inTerm:
+		attach = true
+		si.note(b, RuleProtectedAttach, false)
+
+	case si.inTermSpc:
+		switch si.toks[b].ttype {
+		case TokParenOpen, TokBracketOpen, TokBraceOpen:
+			attach = true
+			si.note(b, RuleProtectedAttach, false)
+		default:
+			if si.startsSentence(b) || isQuoteType(si.toks[b].ttype) {
+				if !si.hold(b) {
+					si.note(b, RuleProtectedSplit, true)
+					return true
+				}
+			} else {
+				si.note(b, RuleLowercaseFollow, false)
+			}
+		}
+	}
+
+	if si.inTermSpc || attach {
+		for i := si.idxTerm + 1; i < b; i++ {
+			si.buf += si.toks[i].text
+		}
+	}
+
+	for i := b; i <= e; i++ {
+		t := si.toks[i]
+		switch t.ttype {
+		case TokParenOpen, TokBracketOpen, TokBraceOpen:
+			si.pushGroup(i)
+		case TokParenClose, TokBracketClose, TokBraceClose:
+			si.popGroup(i)
+		default:
+			si.buf += t.text
+		}
+	}
+
+	if attach {
+		si.idxTerm = e
+		si.inTerm = false
+		si.inTermSpc = true
+	} else {
+		si.inTerm = false
+		si.inTermSpc = false
+		si.inMayBeTerm = false
+	}
+	return false
+}
+
 // nextNonSpaceToken answers the index of the first token after that
 // at the given index that represents a non-space token.  If none such
 // exists, it answers -1.
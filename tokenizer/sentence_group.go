@@ -0,0 +1,235 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"sort"
+)
+
+// DefaultMaxGroupSpan is the number of tokens beyond its opening token
+// that a group may span, before group-aware sentence assembly gives up
+// on it being closed.
+const DefaultMaxGroupSpan = 100
+
+// Group represents a parenthesised, bracketed or braced span of text
+// within a sentence.
+//
+// It holds the offsets and the indices of its opening and closing
+// tokens.  A group left unclosed at the end of its sentence ends with
+// the last token of the sentence.  Groups nest.
+type Group struct {
+	gtype    TokenType // Type of the opening token
+	begin    int
+	end      int
+	bTokIdx  int
+	eTokIdx  int
+	closed   bool
+	children []*Group
+}
+
+func (g *Group) Type() TokenType {
+	return g.gtype
+}
+
+func (g *Group) Begin() int {
+	return g.begin
+}
+
+func (g *Group) End() int {
+	return g.end
+}
+
+func (g *Group) BeginToken() int {
+	return g.bTokIdx
+}
+
+func (g *Group) EndToken() int {
+	return g.eTokIdx
+}
+
+func (g *Group) Closed() bool {
+	return g.closed
+}
+
+func (g *Group) Children() []*Group {
+	return g.children
+}
+
+// GroupWarningKind represents the kinds of unbalanced grouping tokens.
+type GroupWarningKind byte
+
+// List of group warning kinds.
+const (
+	GroupUnclosed       GroupWarningKind = iota // Opening token without closing one
+	GroupUnmatchedClose                         // Closing token without opening one
+	QuoteUnclosed                               // Opening quotation mark without closing one
+	QuoteUnmatchedClose                         // Closing quotation mark without opening one
+)
+
+// GwDescriptions helps in printing group warning kinds.
+var GwDescriptions = map[GroupWarningKind]string{
+	GroupUnclosed:       "UNCLOSED_GROUP",
+	GroupUnmatchedClose: "UNMATCHED_CLOSE",
+	QuoteUnclosed:       "UNCLOSED_QUOTE",
+	QuoteUnmatchedClose: "UNMATCHED_CLOSE_QUOTE",
+}
+
+// GroupWarning records an unbalanced grouping token or quotation mark
+// in a sentence.
+type GroupWarning struct {
+	Token int
+	Begin int
+	Text  string
+	Kind  GroupWarningKind
+}
+
+// Groups answers the top-level groups of this sentence, should it
+// have been assembled in group-aware mode.
+func (s *Sentence) Groups() []*Group {
+	return s.groups
+}
+
+// Warnings answers the unbalanced grouping tokens and quotation marks
+// of this sentence, should it have been assembled in group-aware or
+// quote-aware mode.
+func (s *Sentence) Warnings() []GroupWarning {
+	return s.warnings
+}
+
+// opens answers the opening token type matching the given closing
+// token type.
+func opens(tt TokenType) TokenType {
+	switch tt {
+	case TokParenClose:
+		return TokParenOpen
+	case TokBracketClose:
+		return TokBracketOpen
+	case TokBraceClose:
+		return TokBraceOpen
+	}
+	return TokOther
+}
+
+// buildGroups answers the nested groups of the tokens from index `b`
+// through index `e`, together with any unbalanced grouping tokens.
+//
+// A closing token matches the innermost open group of its kind; any
+// groups open inside that are left unclosed, ending before it.
+func buildGroups(toks []*TextToken, b, e int) ([]*Group, []GroupWarning) {
+	var roots []*Group
+	var stack []*Group
+	var warns []GroupWarning
+	add := func(g *Group) {
+		if len(stack) == 0 {
+			roots = append(roots, g)
+		} else {
+			p := stack[len(stack)-1]
+			p.children = append(p.children, g)
+		}
+	}
+	unclosed := func(g *Group, e int) {
+		g.end = toks[e].end
+		g.eTokIdx = e
+		warns = append(warns, GroupWarning{g.bTokIdx, g.begin, toks[g.bTokIdx].text, GroupUnclosed})
+	}
+
+	for i := b; i <= e; i++ {
+		t := toks[i]
+		switch t.ttype {
+		case TokParenOpen, TokBracketOpen, TokBraceOpen:
+			g := &Group{gtype: t.ttype, begin: t.begin, bTokIdx: i}
+			add(g)
+			stack = append(stack, g)
+
+		case TokParenClose, TokBracketClose, TokBraceClose:
+			k := len(stack) - 1
+			for k >= 0 && stack[k].gtype != opens(t.ttype) {
+				k--
+			}
+			if k < 0 {
+				warns = append(warns, GroupWarning{i, t.begin, t.text, GroupUnmatchedClose})
+				continue
+			}
+			for _, g := range stack[k+1:] {
+				unclosed(g, i-1)
+			}
+			g := stack[k]
+			g.end, g.eTokIdx, g.closed = t.end, i, true
+			stack = stack[:k]
+		}
+	}
+	for _, g := range stack {
+		unclosed(g, e)
+	}
+
+	return roots, warns
+}
+
+// holdForGroup answers if the sentence should not end before the
+// token at the given index, because a group is open.  Groups that
+// have spanned too many tokens are abandoned.  It always answers
+// `false` outside group-aware mode.
+func (si *SentenceIterator) holdForGroup(idx int) bool {
+	if !si.opts.GroupAware || len(si.grpStack) == 0 {
+		return false
+	}
+
+	max := si.opts.MaxGroupSpan
+	if max <= 0 {
+		max = DefaultMaxGroupSpan
+	}
+	if idx-si.grpStack[len(si.grpStack)-1].tokIndex > max {
+		si.grpStack = si.grpStack[:0]
+		return false
+	}
+
+	si.note(idx, RuleInsideGroup, false)
+	return true
+}
+
+// resume continues the current sentence past a terminator, as though
+// the terminator had not been one.
+func (si *SentenceIterator) resume(idx int) {
+	if si.inTermSpc {
+		for i := si.idxTerm + 1; i < idx; i++ {
+			si.buf += si.toks[i].text
+		}
+	}
+	si.inTerm = false
+	si.inTermSpc = false
+	si.inMayBeTerm = false
+}
+
+// shiftGroups answers copies of the given groups, with their offsets
+// and token indices shifted by the given amounts.
+func shiftGroups(gs []*Group, delta, tokDelta int) []*Group {
+	if gs == nil {
+		return nil
+	}
+
+	res := make([]*Group, 0, len(gs))
+	for _, g := range gs {
+		ng := *g
+		ng.begin += delta
+		ng.end += delta
+		ng.bTokIdx += tokDelta
+		ng.eTokIdx += tokDelta
+		ng.children = shiftGroups(g.children, delta, tokDelta)
+		res = append(res, &ng)
+	}
+	return res
+}
+
+// mergeWarnings answers the given warnings together, in token order.
+func mergeWarnings(a, b []GroupWarning) []GroupWarning {
+	if len(b) == 0 {
+		return a
+	}
+
+	res := append(a, b...)
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Token < res[j].Token })
+	return res
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"testing"
+)
+
+// withOptions answers a setup of sentence iterators with the given
+// options.
+func withOptions(o SentenceOptions) func(si *SentenceIterator) {
+	return func(si *SentenceIterator) {
+		si.SetOptions(o)
+	}
+}
+
+//
+
+func TestSentenceGroup001(t *testing.T) {
+	in := "The product (see Table 2 [ref 5]. It was pure) was dried. Then it was stored."
+
+	sents := iterSentences(in, nil)
+	if len(sents) != 3 {
+		t.Fatalf("Expected sentence count : 3, observed : %d", len(sents))
+	}
+	if sents[0].Groups() != nil {
+		t.Errorf("Expected no groups outside group-aware mode")
+	}
+
+	sents = iterSentences(in, withOptions(SentenceOptions{GroupAware: true, Trace: true}))
+	if len(sents) != 2 {
+		t.Fatalf("Expected sentence count : 2, observed : %d", len(sents))
+	}
+	if sents[1].Begin() != 58 {
+		t.Errorf("Expected second sentence to begin at : 58, observed : %d", sents[1].Begin())
+	}
+
+	gs := sents[0].Groups()
+	if len(gs) != 1 {
+		t.Fatalf("Expected group count : 1, observed : %d", len(gs))
+	}
+	g := gs[0]
+	if g.Type() != TokParenOpen || !g.Closed() || g.Begin() != 12 || g.End() != 45 {
+		t.Errorf("Unexpected group : %v %v %d:%d", TtDescriptions[g.Type()], g.Closed(), g.Begin(), g.End())
+	}
+	if len(g.Children()) != 1 {
+		t.Fatalf("Expected nested group count : 1, observed : %d", len(g.Children()))
+	}
+	c := g.Children()[0]
+	if c.Type() != TokBracketOpen || c.Begin() != 25 || c.End() != 31 {
+		t.Errorf("Unexpected nested group : %v %d:%d", TtDescriptions[c.Type()], c.Begin(), c.End())
+	}
+	if len(sents[0].Warnings()) != 0 {
+		t.Errorf("Expected no warnings, observed : %v", sents[0].Warnings())
+	}
+
+	held := false
+	for _, bd := range sents[0].Trace() {
+		if bd.Rule == RuleInsideGroup && bd.Text == "It" && !bd.Split {
+			held = true
+		}
+	}
+	if !held {
+		t.Errorf("Expected an INSIDE_GROUP decision at `It` : %v", sents[0].Trace())
+	}
+}
+
+//
+
+func TestSentenceGroup002(t *testing.T) {
+	in := "It was heated (to reflux. The solid formed. Water was added."
+
+	sents := iterSentences(in, withOptions(SentenceOptions{GroupAware: true}))
+	if len(sents) != 1 {
+		t.Fatalf("Expected sentence count : 1, observed : %d", len(sents))
+	}
+	ws := sents[0].Warnings()
+	if len(ws) != 1 || ws[0].Kind != GroupUnclosed || ws[0].Begin != 14 {
+		t.Fatalf("Expected one unclosed group warning at : 14, observed : %v", ws)
+	}
+	if g := sents[0].Groups()[0]; g.Closed() || g.EndToken() != sents[0].EndToken() {
+		t.Errorf("Expected the unclosed group to end with the sentence")
+	}
+
+	sents = iterSentences(in, withOptions(SentenceOptions{GroupAware: true, MaxGroupSpan: 5}))
+	if len(sents) != 3 {
+		t.Fatalf("Expected sentence count : 3, observed : %d", len(sents))
+	}
+	if ws := sents[0].Warnings(); len(ws) != 1 || ws[0].Kind != GroupUnclosed {
+		t.Errorf("Expected one unclosed group warning, observed : %v", ws)
+	}
+	if ws := sents[1].Warnings(); len(ws) != 0 {
+		t.Errorf("Expected no warnings, observed : %v", ws)
+	}
+}
+
+//
+
+func TestSentenceGroup003(t *testing.T) {
+	in := "A) The first step. B) The second (final) step. It was dried (overnight.) Then it was stored."
+
+	sents := iterSentences(in, withOptions(SentenceOptions{GroupAware: true}))
+	if len(sents) != 4 {
+		t.Fatalf("Expected sentence count : 4, observed : %d", len(sents))
+	}
+	for i := 0; i < 2; i++ {
+		ws := sents[i].Warnings()
+		if len(ws) != 1 || ws[0].Kind != GroupUnmatchedClose || ws[0].Text != ")" {
+			t.Errorf("Sentence %d : expected one unmatched close warning, observed : %v", i, ws)
+		}
+	}
+	if gs := sents[1].Groups(); len(gs) != 1 || !gs[0].Closed() {
+		t.Errorf("Expected one closed group in the second sentence")
+	}
+	if sents[2].End() != 71 {
+		t.Errorf("Expected third sentence to end at : 71, observed : %d", sents[2].End())
+	}
+}
@@ -0,0 +1,571 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"bufio"
+	_ "embed" // For the bundled model
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/RxnWeaver/RxnMiner/internal/perceptron"
+)
+
+// SentenceAssembler is the interface common to iterators that assemble
+// consecutive sentences from text tokens.
+type SentenceAssembler interface {
+	MoveNext() error
+	Item() *Sentence
+}
+
+// Classes of candidate sentence boundaries.
+const (
+	classSplit    = "split"
+	classContinue = "continue"
+)
+
+// SentenceModel is a trainable classifier of candidate sentence
+// boundaries: runs of terminators, with any closing quotation marks
+// that immediately follow them.
+//
+// It is an averaged perceptron over features of the tokens around the
+// candidate: their types, case, lengths, digits and membership in the
+// abbreviation set in effect.
+type SentenceModel struct {
+	pm *perceptron.Model
+}
+
+// SentenceExample is a text with the offsets of its reference
+// sentences, for training and evaluating sentence models.  Offsets are
+// inclusive `[begin, end]` pairs.
+type SentenceExample struct {
+	Text      string
+	Sentences [][2]int
+}
+
+// TrainSentenceModel trains a new model over the given examples, for
+// the given number of iterations.  Candidate boundaries are labelled
+// by the ends of the reference sentences that fall in them.  A nil
+// abbreviation set is treated as `DefaultAbbrevs()`.
+//
+// Examples are visited in a pseudo-random order that is the same for
+// every run, so that training is reproducible.
+func TrainSentenceModel(exs []SentenceExample, a *AbbrevSet, iters int) *SentenceModel {
+	if a == nil {
+		a = DefaultAbbrevs()
+	}
+
+	type instance struct {
+		feats []string
+		class string
+	}
+	var insts []instance
+	for _, ex := range exs {
+		toks := textTokens(ex.Text)
+		ends := make(map[int]struct{}, len(ex.Sentences))
+		for _, s := range ex.Sentences {
+			ends[s[1]] = struct{}{}
+		}
+
+		for b := 0; b < len(toks); b++ {
+			if !isCandidateStart(toks, b) {
+				continue
+			}
+			e := candidateEnd(toks, b)
+			class := classContinue
+			for off := toks[b].begin; off <= toks[e].end; off++ {
+				if _, ok := ends[off]; ok {
+					class = classSplit
+					break
+				}
+			}
+			insts = append(insts, instance{boundaryFeatures(toks, b, e, a), class})
+			b = e
+		}
+	}
+
+	pm := perceptron.New([]string{classContinue, classSplit})
+	rnd := rand.New(rand.NewSource(1))
+	for it := 0; it < iters; it++ {
+		for _, k := range rnd.Perm(len(insts)) {
+			in := insts[k]
+			pm.Update(in.class, pm.Predict(in.feats), in.feats)
+			pm.Tick()
+		}
+	}
+	pm.Average()
+
+	return &SentenceModel{pm}
+}
+
+// Evaluate assembles the sentences of the given examples with this
+// model, and compares the ends of those that are not the last of their
+// texts with the reference.  It answers the counts of true positive,
+// false positive and false negative sentence ends.  A nil abbreviation
+// set is treated as `DefaultAbbrevs()`.
+func (sm *SentenceModel) Evaluate(exs []SentenceExample, a *AbbrevSet) (int, int, int) {
+	tp, fp, fn := 0, 0, 0
+	for _, ex := range exs {
+		ref := make(map[int]struct{}, len(ex.Sentences))
+		for i, s := range ex.Sentences {
+			if i < len(ex.Sentences)-1 {
+				ref[s[1]] = struct{}{}
+			}
+		}
+
+		toks := textTokens(ex.Text)
+		si := NewModelSentenceIterator(toks, sm)
+		si.SetAbbreviations(a)
+		var ends []int
+		for err := si.MoveNext(); err == nil; err = si.MoveNext() {
+			ends = append(ends, si.Item().End())
+		}
+		for i, e := range ends {
+			if i == len(ends)-1 {
+				break
+			}
+			if _, ok := ref[e]; ok {
+				tp++
+				delete(ref, e)
+			} else {
+				fp++
+			}
+		}
+		fn += len(ref)
+	}
+	return tp, fp, fn
+}
+
+// Save writes the model to the given writer.
+func (sm *SentenceModel) Save(w io.Writer) error {
+	return sm.pm.Save(w)
+}
+
+//go:embed data/sentence_model.json
+var sentenceModel string
+
+// DefaultSentenceModel answers the bundled model, trained over the
+// patent test data of this package with the default abbreviations.
+func DefaultSentenceModel() *SentenceModel {
+	sm, err := LoadSentenceModel(strings.NewReader(sentenceModel))
+	if err != nil {
+		panic(err)
+	}
+	return sm
+}
+
+// LoadSentenceModel reads a model saved by `Save` from the given
+// reader.
+func LoadSentenceModel(r io.Reader) (*SentenceModel, error) {
+	pm, err := perceptron.Load(r)
+	if err != nil {
+		return nil, err
+	}
+	return &SentenceModel{pm}, nil
+}
+
+// splits answers if the model classifies the candidate boundary with
+// the given features as the end of a sentence.
+func (sm *SentenceModel) splits(feats []string) bool {
+	return sm.pm.Predict(feats) == classSplit
+}
+
+// ReadSentenceExamples reads texts and the offsets of their reference
+// sentences, in the format of the test data of this package.
+//
+// Each line of `texts` holds the identifier of a document followed by
+// the texts of its sections, separated by tabs.  Each line of `refs`
+// holds the identifier of a document followed by the sentences of its
+// sections, separated by tabs; each section lists comma-separated
+// `begin:end` offsets.  Every section becomes an example.
+func ReadSentenceExamples(texts, refs io.Reader) ([]SentenceExample, error) {
+	offs := make(map[string][]string)
+	sc := bufio.NewScanner(refs)
+	sc.Buffer(nil, 1<<24)
+	for sc.Scan() {
+		fs := strings.Split(sc.Text(), "\t")
+		offs[fs[0]] = fs[1:]
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	var res []SentenceExample
+	sc = bufio.NewScanner(texts)
+	sc.Buffer(nil, 1<<24)
+	for sc.Scan() {
+		fs := strings.Split(strings.TrimRight(sc.Text(), "\r"), "\t")
+		secs, ok := offs[fs[0]]
+		if !ok {
+			return nil, fmt.Errorf("No reference sentences for document : %s", fs[0])
+		}
+		if len(secs) != len(fs)-1 {
+			return nil, fmt.Errorf("Section count mismatch for document : %s", fs[0])
+		}
+
+		for i, text := range fs[1:] {
+			ex := SentenceExample{Text: text}
+			if secs[i] != "" {
+				for _, o := range strings.Split(secs[i], ",") {
+					be := strings.Split(o, ":")
+					if len(be) != 2 {
+						return nil, fmt.Errorf("Invalid offsets for document %s : %s", fs[0], o)
+					}
+					b, err1 := strconv.Atoi(be[0])
+					e, err2 := strconv.Atoi(be[1])
+					if err1 != nil || err2 != nil {
+						return nil, fmt.Errorf("Invalid offsets for document %s : %s", fs[0], o)
+					}
+					ex.Sentences = append(ex.Sentences, [2]int{b, e})
+				}
+			}
+			res = append(res, ex)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// textTokens answers the text tokens of the given text.
+func textTokens(text string) []*TextToken {
+	var toks []*TextToken
+	ti := NewTextTokenIterator(text)
+	for err := ti.MoveNext(); err == nil; err = ti.MoveNext() {
+		toks = append(toks, ti.Item())
+	}
+	return toks
+}
+
+// isTermType answers if the given token type terminates sentences.
+func isTermType(tt TokenType) bool {
+	return tt == TokTerm || tt == TokMayBeTerm
+}
+
+// isCandidateStart answers if the token at the given index begins a
+// candidate sentence boundary: a terminator not immediately preceded
+// by another.
+func isCandidateStart(toks []*TextToken, idx int) bool {
+	if !isTermType(toks[idx].ttype) {
+		return false
+	}
+	return idx == 0 || !isTermType(toks[idx-1].ttype)
+}
+
+// candidateEnd answers the index of the last token of the candidate
+// sentence boundary beginning at the given index.  It includes the
+// terminators and closing quotation marks that immediately follow.
+func candidateEnd(toks []*TextToken, idx int) int {
+	l := len(toks)
+	for idx+1 < l {
+		switch toks[idx+1].ttype {
+		case TokTerm, TokMayBeTerm, TokSquote, TokDquote, TokFinQuote:
+			idx++
+			continue
+		}
+		break
+	}
+	return idx
+}
+
+// shape answers a coarse description of the case of the given text.
+func shape(text string) string {
+	upper, lower, digit, other := 0, 0, 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		case unicode.IsDigit(r):
+			digit++
+		default:
+			other++
+		}
+	}
+
+	switch {
+	case digit > 0 && upper+lower+other == 0:
+		return "digits"
+	case digit > 0:
+		return "alnum"
+	case upper > 0 && lower == 0:
+		return "upper"
+	case upper > 0 && strings.IndexFunc(text, unicode.IsUpper) == 0:
+		return "title"
+	case upper > 0:
+		return "mixed"
+	case lower > 0:
+		return "lower"
+	case other > 0:
+		return "uncased"
+	}
+	return "none"
+}
+
+// lengthBucket answers the given length, capped for use in features.
+func lengthBucket(n int) string {
+	if n > 5 {
+		return "5+"
+	}
+	return strconv.Itoa(n)
+}
+
+// boundaryFeatures answers the features of the candidate sentence
+// boundary spanning the tokens from index `b` through index `e`.
+func boundaryFeatures(toks []*TextToken, b, e int, a *AbbrevSet) []string {
+	l := len(toks)
+	fs := make([]string, 0, 24)
+	add := func(name, val string) {
+		fs = append(fs, name+"="+val)
+	}
+
+	var run []string
+	for i := b; i <= e; i++ {
+		run = append(run, toks[i].text)
+	}
+	fs = append(fs, "bias")
+	add("run", strings.Join(run, ""))
+	add("term", toks[b].text)
+
+	// Preceding text.
+	p := b - 1
+	switch {
+	case p < 0:
+		add("prev", "none")
+	case toks[p].ttype == TokSpace:
+		add("prev", "space")
+	default:
+		pt := toks[p]
+		lw := strings.ToLower(pt.text)
+		add("ptype", TtDescriptions[pt.ttype])
+		add("pshape", shape(pt.text))
+		add("plen", lengthBucket(len([]rune(pt.text))))
+		if len([]rune(pt.text)) <= 6 {
+			add("pword", lw)
+		}
+		if _, ok := a.NonTerm[lw]; ok {
+			add("pabbrev", "nonterm")
+		}
+		if _, ok := a.MayBeTerm[lw]; ok {
+			add("pabbrev", "maybeterm")
+		}
+		if _, ok := a.Group[lw]; ok {
+			add("pabbrev", "group")
+		}
+		if p > 0 && toks[p-1].ttype == TokMayBeTerm {
+			add("pprev", "term") // `e.g.`, `U.S.`
+		}
+	}
+
+	// Following text.
+	n := e + 1
+	spaced := n < l && toks[n].ttype == TokSpace
+	for n < l && toks[n].ttype == TokSpace {
+		n++
+	}
+	add("space", strconv.FormatBool(spaced))
+	if n >= l {
+		add("next", "none")
+		return fs
+	}
+	nt := toks[n]
+	nshape := shape(nt.text)
+	add("ntype", TtDescriptions[nt.ttype])
+	add("nshape", nshape)
+	if len([]rune(nt.text)) <= 6 {
+		add("nword", strings.ToLower(nt.text))
+	}
+	add("space+nshape", strconv.FormatBool(spaced)+"|"+nshape)
+	add("term+nshape", toks[b].text+"|"+nshape)
+	if p >= 0 {
+		add("pshape+nshape", shape(toks[p].text)+"|"+nshape)
+	}
+	return fs
+}
+
+// ModelSentenceIterator assembles consecutive sentences from text
+// tokens, ending them where a sentence model classifies candidate
+// boundaries as such.  It is an alternative to the rule-based
+// `SentenceIterator`.
+//
+// It honours protected spans, forced breaks and trace mode.  It does
+// not track groups or quotations.
+type ModelSentenceIterator struct {
+	toks    []*TextToken
+	model   *SentenceModel
+	abbrevs *AbbrevSet
+	opts    SentenceOptions
+	trace   []BoundaryDecision
+	prots   map[int]int
+	breaks  map[int]struct{}
+	idx     int
+	cs      *Sentence
+}
+
+// NewModelSentenceIterator creates and initialises a sentence iterator
+// over the given text tokens, that consults the given model.
+func NewModelSentenceIterator(toks []*TextToken, m *SentenceModel) *ModelSentenceIterator {
+	si := &ModelSentenceIterator{}
+	si.toks = toks
+	si.model = m
+	return si
+}
+
+// SetAbbreviations makes the iterator consult the given abbreviation
+// set, rather than the package-level tables.  It should be the set
+// with which the model was trained.
+func (si *ModelSentenceIterator) SetAbbreviations(a *AbbrevSet) {
+	si.abbrevs = a
+}
+
+// SetOptions changes the optional behaviour of the iterator.  Only
+// trace mode applies.
+func (si *ModelSentenceIterator) SetOptions(o SentenceOptions) {
+	si.opts = o
+}
+
+// Protect marks the text between the given offsets (both inclusive)
+// as indivisible: no sentence ends inside it.  The offsets must be
+// those of the beginning and ending tokens of the text, respectively.
+func (si *ModelSentenceIterator) Protect(begin, end int) error {
+	bidx, eidx, err := spanIndices(si.toks, begin, end)
+	if err != nil {
+		return err
+	}
+
+	if si.prots == nil {
+		si.prots = make(map[int]int)
+	}
+	si.prots[bidx] = eidx
+	return nil
+}
+
+// BreakBefore forces a sentence to begin with the token at the given
+// offset, regardless of the preceding text.
+func (si *ModelSentenceIterator) BreakBefore(begin int) error {
+	idx, err := tokenIndex(si.toks, begin)
+	if err != nil {
+		return err
+	}
+
+	if si.breaks == nil {
+		si.breaks = make(map[int]struct{})
+	}
+	si.breaks[idx] = struct{}{}
+	return nil
+}
+
+// Item answers the current sentence.  This has no side effects, and
+// can be invoked any number of times.
+func (si *ModelSentenceIterator) Item() *Sentence {
+	return si.cs
+}
+
+// note records a boundary decision at the token with the given index,
+// in trace mode.
+func (si *ModelSentenceIterator) note(idx int, rule BoundaryRule, split bool) {
+	if !si.opts.Trace {
+		return
+	}
+
+	bd := BoundaryDecision{Token: idx, Rule: rule, Split: split}
+	if idx < len(si.toks) {
+		bd.Begin = si.toks[idx].begin
+		bd.Text = si.toks[idx].text
+	} else if idx > 0 {
+		bd.Begin = si.toks[idx-1].end + 1
+	}
+	si.trace = append(si.trace, bd)
+}
+
+// emit makes the tokens from index `b` through index `e` the current
+// sentence, and continues after them.
+func (si *ModelSentenceIterator) emit(b, e int) {
+	var sb strings.Builder
+	for i := b; i <= e; i++ {
+		sb.WriteString(si.toks[i].text)
+	}
+	si.cs = newSentence(sb.String(), si.toks[b].begin, si.toks[e].end, b, e)
+	si.cs.trace = si.trace
+	si.trace = nil
+	si.idx = e + 1
+}
+
+// MoveNext assembles the next sentence from the given input tokens.
+// Sentences begin with non-space tokens.
+//
+// The return value is either `nil` (more sentences may be available)
+// or `io.EOF` (no more sentences).
+func (si *ModelSentenceIterator) MoveNext() error {
+	size := len(si.toks)
+	begin := si.idx
+	for begin < size && si.toks[begin].ttype == TokSpace {
+		begin++
+	}
+	if begin >= size {
+		return io.EOF
+	}
+
+	a := si.abbrevs
+	if a == nil {
+		a = DefaultAbbrevs()
+	}
+
+	last := begin // Last non-space token
+	for i := begin; i < size; {
+		if _, ok := si.breaks[i]; ok && i > begin {
+			si.note(i, RuleForcedBreak, true)
+			si.emit(begin, last)
+			si.idx = i
+			return nil
+		}
+		if pe, ok := si.prots[i]; ok {
+			last = pe
+			i = pe + 1
+			continue
+		}
+
+		if isCandidateStart(si.toks, i) {
+			e := candidateEnd(si.toks, i)
+			split := si.model.splits(boundaryFeatures(si.toks, i, e, a))
+			si.note(i, RuleModel, split)
+			if split {
+				si.emit(begin, e)
+				return nil
+			}
+			last = e
+			i = e + 1
+			continue
+		}
+
+		if si.toks[i].ttype != TokSpace {
+			last = i
+		}
+		i++
+	}
+
+	si.note(size, RuleEndOfInput, true)
+	si.emit(begin, last)
+	si.idx = size
+	return nil
+}
+
+// seek makes the iterator continue from the token at the given index.
+func (si *ModelSentenceIterator) seek(idx int) {
+	si.idx = idx
+}
+
+// SetSentenceModel makes sentence assembly in this document consult
+// the given model, rather than rules.  A nil model restores the rules.
+func (d *Document) SetSentenceModel(m *SentenceModel) {
+	d.smodel = m
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"strings"
+	"testing"
+)
+
+// readPatent7k answers the examples of the patent test data.
+func readPatent7k(t *testing.T) []SentenceExample {
+	f1, err := os.Open("testdata/patent_7k_text.txt.gz")
+	if err != nil {
+		t.Fatalf("Unable to read file : %s", err.Error())
+	}
+	defer f1.Close()
+	gr, err := gzip.NewReader(f1)
+	if err != nil {
+		t.Fatalf("Unable to read file : %s", err.Error())
+	}
+	defer gr.Close()
+	f2, err := os.Open("testdata/patent_7k_ref.txt")
+	if err != nil {
+		t.Fatalf("Unable to read file : %s", err.Error())
+	}
+	defer f2.Close()
+
+	exs, err := ReadSentenceExamples(gr, f2)
+	if err != nil {
+		t.Fatalf("Unable to read examples : %s", err.Error())
+	}
+	return exs
+}
+
+// modelSentences answers the texts of the sentences of the given
+// input, assembled by the given model.
+func modelSentences(in string, m *SentenceModel) []string {
+	si := NewModelSentenceIterator(textTokens(in), m)
+	var res []string
+	for err := si.MoveNext(); err == nil; err = si.MoveNext() {
+		res = append(res, si.Item().Text())
+	}
+	return res
+}
+
+//
+
+func TestSentenceModel001(t *testing.T) {
+	exs := readPatent7k(t)
+	if len(exs) != 14000 {
+		t.Fatalf("Expected example count : %d, observed : %d", 14000, len(exs))
+	}
+
+	n := len(exs) * 4 / 5
+	m := TrainSentenceModel(exs[:n], nil, 5)
+	tp, fp, fn := m.Evaluate(exs[n:], nil)
+	prec, rec := float64(tp)/float64(tp+fp), float64(tp)/float64(tp+fn)
+	if prec < 0.99 || rec < 0.99 {
+		t.Errorf("Expected precision and recall of at least 0.99, observed : %.4f, %.4f", prec, rec)
+	}
+}
+
+//
+
+func TestSentenceModel002(t *testing.T) {
+	m := DefaultSentenceModel()
+	in := "Benzene was distilled, e.g. at 80 deg. It was cooled (see Fig. 2). Toluene was added to 5.0 mL of it. \"It works.\" Then it stopped."
+	exp := []string{
+		"Benzene was distilled, e.g. at 80 deg.",
+		"It was cooled (see Fig. 2).",
+		"Toluene was added to 5.0 mL of it.",
+		"\"It works.\"",
+		"Then it stopped.",
+	}
+	checkSentences(t, "model", exp, modelSentences(in, m))
+
+	var buf bytes.Buffer
+	if err := m.Save(&buf); err != nil {
+		t.Fatalf("Unable to save model : %s", err.Error())
+	}
+	m2, err := LoadSentenceModel(&buf)
+	if err != nil {
+		t.Fatalf("Unable to load model : %s", err.Error())
+	}
+	checkSentences(t, "reloaded model", exp, modelSentences(in, m2))
+
+	if _, err := LoadSentenceModel(strings.NewReader("{")); err == nil {
+		t.Errorf("Expected an error for an invalid model")
+	}
+}
+
+//
+
+func TestSentenceModel003(t *testing.T) {
+	in := "Procedure\nThe mixture was stirred. It was filtered (see Ref. 1. and Ref. 2.) and dried."
+	si := NewModelSentenceIterator(textTokens(in), DefaultSentenceModel())
+	si.SetOptions(SentenceOptions{Trace: true})
+	if err := si.BreakBefore(strings.Index(in, "The")); err != nil {
+		t.Fatalf("%v", err)
+	}
+	b := strings.Index(in, "(see")
+	if err := si.Protect(b, strings.Index(in, ")")); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := si.Protect(b+2, b+5); err == nil {
+		t.Errorf("Expected an error for offsets that do not match tokens")
+	}
+
+	exp := []string{
+		"Procedure",
+		"The mixture was stirred.",
+		"It was filtered (see Ref. 1. and Ref. 2.) and dried.",
+	}
+	var obs []string
+	var ss []*Sentence
+	for err := si.MoveNext(); err == nil; err = si.MoveNext() {
+		obs = append(obs, si.Item().Text())
+		ss = append(ss, si.Item())
+	}
+	checkSentences(t, "iterator", exp, obs)
+
+	if tr := ss[0].Trace(); len(tr) != 1 || tr[0].Rule != RuleForcedBreak {
+		t.Errorf("Expected the only decision : %s", BrDescriptions[RuleForcedBreak])
+	}
+	if tr := ss[1].Trace(); len(tr) != 1 || tr[0].Rule != RuleModel || !tr[0].Split {
+		t.Errorf("Expected the only decision : %s", BrDescriptions[RuleModel])
+	}
+}
+
+//
+
+func TestSentenceModel004(t *testing.T) {
+	inserts := []string{"", "x", ". ", "The ", "(", ")", " ", "\n\n", "Dr. Smith", "5.0", "-", "\""}
+	m := DefaultSentenceModel()
+	checkRandomEdits(t, func(d *Document) { d.SetSentenceModel(m) }, inserts)
+}
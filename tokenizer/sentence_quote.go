@@ -0,0 +1,311 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxQuoteSpan is the number of tokens beyond its opening token
+// that a quotation may span, before quote-aware sentence assembly
+// gives up on it being closed.
+const DefaultMaxQuoteSpan = 100
+
+// quotePairs maps opening quotation marks to their possible closing
+// ones.
+var quotePairs = map[string]string{
+	"\"":     "\"",
+	"'":      "'",
+	"\u201c": "\u201d",       // “ ”
+	"\u2018": "\u2019",       // ‘ ’
+	"\u201e": "\u201c\u201d", // „ “ (German) or ” (Polish, etc.)
+	"\u201a": "\u2018\u2019", // ‚ ‘ or ’
+	"\u00ab": "\u00bb",       // « »
+	"\u2039": "\u203a",       // ‹ ›
+	"\u300c": "\u300d",       // 「 」
+	"\u300e": "\u300f",       // 『 』
+	"\u301d": "\u301e\u301f", // 〝 〞 or 〟
+}
+
+// apostrophes is the set of marks that could be apostrophes.
+var apostrophes = map[string]struct{}{
+	"'":      {},
+	"\u2019": {}, // ’
+	"\u02bc": {}, // ʼ
+}
+
+// cjkClosers is the set of closing CJK quotation marks.
+var cjkClosers = map[string]struct{}{
+	"\u300d": {}, // 」
+	"\u300f": {}, // 』
+	"\u301e": {}, // 〞
+	"\u301f": {}, // 〟
+}
+
+// quoteRole represents the roles that a quotation mark can play.
+type quoteRole byte
+
+const (
+	roleApostrophe quoteRole = iota
+	roleOpen
+	roleClose
+)
+
+// Quotes answers the top-level quotations of this sentence, should it
+// have been assembled in quote-aware mode.  Quotations nest.
+func (s *Sentence) Quotes() []*Group {
+	return s.quotes
+}
+
+// isQuote answers if the token at the given index is a quotation mark
+// or an apostrophe.
+func (si *SentenceIterator) isQuote(idx int) bool {
+	t := si.toks[idx]
+	if isQuoteType(t.ttype) {
+		return true
+	}
+	if _, ok := si.pairs()[t.text]; ok {
+		return true
+	}
+	return si.isCloser(t.text)
+}
+
+// isCloser answers if the given mark closes quotations.
+func (si *SentenceIterator) isCloser(text string) bool {
+	for _, cls := range si.pairs() {
+		if strings.Contains(cls, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// closes answers the depth in the stack of open quotations of the
+// innermost quotation that the given mark closes, or -1 if none.
+func (si *SentenceIterator) closes(text string) int {
+	for k := len(si.qStack) - 1; k >= 0; k-- {
+		op := si.toks[si.qStack[k].bTokIdx].text
+		if strings.Contains(si.pairs()[op], text) {
+			return k
+		}
+	}
+	return -1
+}
+
+// isApostrophe answers if the mark at the given index is an
+// apostrophe rather than a quotation mark.
+//
+// A mark within a word (`isn't`, `Grignard's`) is an apostrophe.  So
+// is one that ends a word in `s`, or a number, unless it closes an
+// open quotation (`chemists'`, `5'`).
+func (si *SentenceIterator) isApostrophe(idx int) bool {
+	t := si.toks[idx]
+	if _, ok := apostrophes[t.text]; !ok || idx == 0 {
+		return false
+	}
+	prev := si.toks[idx-1]
+	if prev.ttype != TokMayBeWord {
+		return false
+	}
+	if idx+1 < len(si.toks) && si.toks[idx+1].ttype == TokMayBeWord {
+		return true
+	}
+	if si.closes(t.text) >= 0 {
+		return false
+	}
+
+	var r rune
+	for _, r = range prev.text {
+	}
+	return r == 's' || r == 'S' || ('0' <= r && r <= '9')
+}
+
+// quoteRole answers the role of the quotation mark at the given index.
+//
+// A mark that closes an open quotation does so.  Symmetric marks (`"`,
+// `'`) otherwise open quotations only at the beginning of input, or
+// after space, pauses, opening groups and opening quotations.
+func (si *SentenceIterator) quoteRole(idx int) quoteRole {
+	if si.isApostrophe(idx) {
+		return roleApostrophe
+	}
+
+	t := si.toks[idx]
+	if si.closes(t.text) >= 0 {
+		return roleClose
+	}
+	if cls, ok := si.pairs()[t.text]; ok {
+		if cls != t.text || idx == 0 {
+			return roleOpen
+		}
+		switch si.toks[idx-1].ttype {
+		case TokSpace, TokPause, TokParenOpen, TokBracketOpen, TokBraceOpen, TokIniQuote, TokPunct:
+			return roleOpen
+		}
+		if _, ok := si.pairs()[si.toks[idx-1].text]; ok {
+			return roleOpen
+		}
+		return roleClose
+	}
+	if si.isCloser(t.text) || t.ttype == TokFinQuote {
+		return roleClose
+	}
+	return roleOpen
+}
+
+// quoteToken consumes the quotation mark or apostrophe at the given
+// index, in quote-aware mode.  It answers `true` if the current
+// sentence should end before it.
+//
+// A quotation that closes right after a terminator ends the sentence
+// only if uppercase text follows, outside any open quotation or group.
+// This keeps `"Stop!" he said.` together.
+func (si *SentenceIterator) quoteToken(idx int) bool {
+	t := si.toks[idx]
+	switch si.quoteRole(idx) {
+	case roleOpen:
+		if si.inTerm || si.inTermSpc {
+			if !si.hold(idx) {
+				si.note(idx, RuleQuoteFollow, true)
+				return true
+			}
+		}
+		si.resume(idx)
+		si.openQuote(idx)
+
+	case roleClose:
+		si.closeQuote(idx)
+		if si.inTerm || si.inTermSpc {
+			// The quotation ends with the terminator, perhaps after space
+			// (`« Arrêtez. »`).
+			si.note(idx, RuleQuoteClose, false)
+			if si.inTermSpc {
+				for i := si.idxTerm + 1; i < idx; i++ {
+					si.buf += si.toks[i].text
+				}
+			}
+			si.buf += t.text
+			si.idxTerm = idx
+			si.inTerm = true
+			si.inTermSpc = false
+			return false
+		}
+		si.resume(idx)
+
+	default:
+		if si.inTerm {
+			si.note(idx, RuleNoSpaceFollow, false)
+		} else if si.inTermSpc {
+			si.note(idx, RuleLowercaseFollow, false)
+		}
+		si.resume(idx)
+	}
+
+	si.buf += t.text
+	return false
+}
+
+// quoteContinues answers if the text at the given index continues the
+// sentence of a CJK quotation closed right before it, in an unspaced
+// language.  Kana or ideographs following the closing mark without
+// space are part of the same sentence: `彼は「止まれ。」と言った。`.
+func (si *SentenceIterator) quoteContinues(idx int) bool {
+	if idx == 0 {
+		return false
+	}
+	if _, ok := cjkClosers[si.toks[idx-1].text]; !ok {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(si.toks[idx].text)
+	return unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han)
+}
+
+// openQuote records the opening quotation mark at the given index.
+func (si *SentenceIterator) openQuote(idx int) {
+	t := si.toks[idx]
+	q := &Group{gtype: t.ttype, begin: t.begin, bTokIdx: idx}
+	if l := len(si.qStack); l > 0 {
+		p := si.qStack[l-1]
+		p.children = append(p.children, q)
+	} else {
+		si.qRoots = append(si.qRoots, q)
+	}
+	si.qStack = append(si.qStack, q)
+}
+
+// closeQuote matches the closing quotation mark at the given index
+// against the innermost quotation that it can close.  Quotations open
+// inside that are left unclosed.
+func (si *SentenceIterator) closeQuote(idx int) {
+	t := si.toks[idx]
+	k := si.closes(t.text)
+	if k < 0 {
+		si.qWarns = append(si.qWarns, GroupWarning{idx, t.begin, t.text, QuoteUnmatchedClose})
+		return
+	}
+
+	for _, q := range si.qStack[k+1:] {
+		si.unclosedQuote(q, idx-1)
+	}
+	q := si.qStack[k]
+	q.end, q.eTokIdx, q.closed = t.end, idx, true
+	si.qStack = si.qStack[:k]
+}
+
+// unclosedQuote ends the given quotation with the token at the given
+// index, and records it as unclosed.
+func (si *SentenceIterator) unclosedQuote(q *Group, idx int) {
+	q.end = si.toks[idx].end
+	q.eTokIdx = idx
+	si.qWarns = append(si.qWarns, GroupWarning{q.bTokIdx, q.begin, si.toks[q.bTokIdx].text, QuoteUnclosed})
+}
+
+// endQuotes ends the quotations of the current sentence, which ends
+// with the token at the given index, and answers them with any
+// warnings.  It resets the quotation state for the next sentence.
+func (si *SentenceIterator) endQuotes(idx int) ([]*Group, []GroupWarning) {
+	for _, q := range si.qLost {
+		si.unclosedQuote(q, idx)
+	}
+	for _, q := range si.qStack {
+		si.unclosedQuote(q, idx)
+	}
+
+	qs, ws := si.qRoots, si.qWarns
+	si.qRoots, si.qStack, si.qLost, si.qWarns = nil, nil, nil, nil
+	return qs, ws
+}
+
+// holdForQuote answers if the sentence should not end before the
+// token at the given index, because a quotation is open.  Quotations
+// that have spanned too many tokens are abandoned.  It always answers
+// `false` outside quote-aware mode.
+func (si *SentenceIterator) holdForQuote(idx int) bool {
+	if !si.opts.QuoteAware || len(si.qStack) == 0 {
+		return false
+	}
+
+	max := si.opts.MaxQuoteSpan
+	if max <= 0 {
+		max = DefaultMaxQuoteSpan
+	}
+	if idx-si.qStack[len(si.qStack)-1].bTokIdx > max {
+		si.qLost = append(si.qLost, si.qStack...)
+		si.qStack = nil
+		return false
+	}
+
+	si.note(idx, RuleInsideQuote, false)
+	return true
+}
+
+// hold answers if the sentence should not end before the token at the
+// given index, because a group or a quotation is open.
+func (si *SentenceIterator) hold(idx int) bool {
+	return si.holdForGroup(idx) || si.holdForQuote(idx)
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"testing"
+)
+
+func TestSentenceQuote001(t *testing.T) {
+	in := `He said, "Stop. Do it now." Then he left. "Why?" she asked. "Go," he said.`
+
+	sents := iterSentences(in, nil)
+	if len(sents) != 6 {
+		t.Fatalf("Expected sentence count : 6, observed : %d", len(sents))
+	}
+
+	sents = iterSentences(in, withOptions(SentenceOptions{QuoteAware: true}))
+	exp := []string{
+		`He said, "Stop. Do it now."`,
+		`Then he left.`,
+		`"Why?" she asked.`,
+		`"Go," he said.`,
+	}
+	if len(sents) != len(exp) {
+		t.Fatalf("Expected sentence count : %d, observed : %d", len(exp), len(sents))
+	}
+	for i, s := range sents {
+		if s.Text() != exp[i] || in[s.Begin():s.End()+1] != exp[i] {
+			t.Errorf("Expected sentence : %q, observed : %q", exp[i], s.Text())
+		}
+		if q := len(s.Quotes()); (i == 1 && q != 0) || (i != 1 && (q != 1 || !s.Quotes()[0].Closed())) {
+			t.Errorf("Unexpected quotations in : %q", s.Text())
+		}
+		if len(s.Warnings()) != 0 {
+			t.Errorf("Expected no warnings in : %q, observed : %v", s.Text(), s.Warnings())
+		}
+	}
+}
+
+//
+
+func TestSentenceQuote002(t *testing.T) {
+	in := "Grignard's reagent isn't stable. The chemists' flasks were dried at 5' intervals. It's 'pure' now."
+
+	sents := iterSentences(in, withOptions(SentenceOptions{QuoteAware: true}))
+	if len(sents) != 3 {
+		t.Fatalf("Expected sentence count : 3, observed : %d", len(sents))
+	}
+	for i := 0; i < 2; i++ {
+		if len(sents[i].Quotes()) != 0 || len(sents[i].Warnings()) != 0 {
+			t.Errorf("Expected only apostrophes in : %q", sents[i].Text())
+		}
+	}
+	qs := sents[2].Quotes()
+	if len(qs) != 1 || !qs[0].Closed() || in[qs[0].Begin():qs[0].End()+1] != "'pure'" {
+		t.Errorf("Expected the quotation : 'pure'")
+	}
+}
+
+//
+
+func TestSentenceQuote003(t *testing.T) {
+	in := `She said, "He told me 'Go. Now.' and left." Then there was silence. He wrote "done. It ended.`
+
+	sents := iterSentences(in, withOptions(SentenceOptions{QuoteAware: true, Trace: true}))
+	if len(sents) != 3 {
+		t.Fatalf("Expected sentence count : 3, observed : %d", len(sents))
+	}
+
+	qs := sents[0].Quotes()
+	if len(qs) != 1 || len(qs[0].Children()) != 1 {
+		t.Fatalf("Expected one quotation with one nested quotation")
+	}
+	if c := qs[0].Children()[0]; in[c.Begin():c.End()+1] != "'Go. Now.'" {
+		t.Errorf("Expected nested quotation : 'Go. Now.', observed : %s", in[c.Begin():c.End()+1])
+	}
+	held := 0
+	for _, bd := range sents[0].Trace() {
+		if bd.Rule == RuleInsideQuote {
+			held++
+		}
+	}
+	if held != 1 {
+		t.Errorf("Expected INSIDE_QUOTE decisions : 1, observed : %d", held)
+	}
+
+	ws := sents[2].Warnings()
+	if len(ws) != 1 || ws[0].Kind != QuoteUnclosed || ws[0].Text != `"` {
+		t.Errorf("Expected one unclosed quotation warning, observed : %v", ws)
+	}
+}
+
+//
+
+func TestSentenceQuote004(t *testing.T) {
+	in := "Он сказал: «Стой. Иди „сейчас“». Потом он ушёл. «Зачем?» — спросила она."
+
+	sents := iterSentences(in, withOptions(SentenceOptions{QuoteAware: true}))
+	exp := []string{
+		"Он сказал: «Стой. Иди „сейчас“».",
+		"Потом он ушёл.",
+		"«Зачем?» — спросила она.",
+	}
+	if len(sents) != len(exp) {
+		t.Fatalf("Expected sentence count : %d, observed : %d", len(exp), len(sents))
+	}
+	for i, s := range sents {
+		if in[s.Begin():s.End()+1] != exp[i] {
+			t.Errorf("Expected sentence : %q, observed : %q", exp[i], in[s.Begin():s.End()+1])
+		}
+	}
+
+	qs := sents[0].Quotes()
+	if len(qs) != 1 || !qs[0].Closed() || len(qs[0].Children()) != 1 || !qs[0].Children()[0].Closed() {
+		t.Errorf("Expected a closed quotation with a closed nested quotation")
+	}
+	if len(sents[0].Warnings()) != 0 {
+		t.Errorf("Expected no warnings, observed : %v", sents[0].Warnings())
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// StartClassifier decides if text following a sentence terminator and
+// space begins a new sentence.
+type StartClassifier interface {
+	// StartsSentence answers if the token at the given index can begin
+	// a sentence.
+	StartsSentence(toks []*TextToken, idx int) bool
+}
+
+// StartClassifierFunc adapts an ordinary function to the
+// `StartClassifier` interface.
+type StartClassifierFunc func(toks []*TextToken, idx int) bool
+
+// StartsSentence answers the result of the function.
+func (f StartClassifierFunc) StartsSentence(toks []*TextToken, idx int) bool {
+	return f(toks, idx)
+}
+
+// firstRune answers the first rune of the text of the given token.
+func firstRune(t *TextToken) rune {
+	for _, r := range t.text {
+		return r
+	}
+	return 0
+}
+
+// CasedStarts begins sentences with uppercase text only.  This is the
+// default.
+var CasedStarts StartClassifier = StartClassifierFunc(func(toks []*TextToken, idx int) bool {
+	return unicode.IsUpper(firstRune(toks[idx]))
+})
+
+// ScriptStarts begins sentences with uppercase text, numbers, and text
+// in scripts that do not distinguish case: Devanagari, Telugu, Han,
+// etc.
+var ScriptStarts StartClassifier = StartClassifierFunc(func(toks []*TextToken, idx int) bool {
+	r := firstRune(toks[idx])
+	return unicode.IsUpper(r) || unicode.IsNumber(r) || (unicode.IsLetter(r) && !unicode.IsLower(r))
+})
+
+// UncasedStarts begins sentences with any text beginning with a
+// letter or a number.
+var UncasedStarts StartClassifier = StartClassifierFunc(func(toks []*TextToken, idx int) bool {
+	r := firstRune(toks[idx])
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+})
+
+// chemPrefixes lists the lowercase prefixes of chemical names that
+// are joined to them by a hyphen: `tert-Butyl`, `n-BuLi`, `α-pinene`.
+var chemPrefixes = map[string]struct{}{
+	"n": {}, "i": {}, "s": {}, "t": {}, "o": {}, "m": {}, "p": {},
+	"d": {}, "l": {}, "dl": {}, "rac": {}, "meso": {},
+	"sec": {}, "tert": {}, "iso": {}, "neo": {},
+	"cis": {}, "trans": {}, "syn": {}, "anti": {}, "endo": {}, "exo": {},
+	"ortho": {}, "meta": {}, "para": {},
+	"\u03b1": {}, "\u03b2": {}, "\u03b3": {}, "\u03b4": {}, "\u03c9": {}, // α β γ δ ω
+}
+
+// chemUnits lists units of quantities that begin sentences in
+// experimental procedures: `5 g of ...`, `10 mL of ...`.
+var chemUnits = map[string]struct{}{
+	"g": {}, "mg": {}, "kg": {}, "\u00b5g": {}, // µg
+	"l": {}, "L": {}, "ml": {}, "mL": {}, "\u00b5l": {}, "\u00b5L": {}, // µl µL
+	"mol": {}, "mmol": {}, "\u00b5mol": {}, // µmol
+	"equiv": {}, "eq": {}, "drops": {}, "portions": {},
+}
+
+// chemicalStarts extends a base classifier with the sentence starts
+// peculiar to chemical text.
+type chemicalStarts struct {
+	base StartClassifier
+}
+
+// NewChemicalStartClassifier answers a classifier that begins
+// sentences wherever the given one does, and also with:
+//
+//   - lowercase chemical prefixes: `tert-Butyl`, `n-BuLi`, `p-TsOH`,
+//     `α-pinene`,
+//   - lowercase symbols with uppercase letters: `pH`, `pKa`, `mCPBA`,
+//   - locants: `2,3-Dichloro...`, `1H-Indole`, and
+//   - quantities: `5 g`, `10 mL`.
+//
+// A nil base classifier is treated as `CasedStarts`.
+func NewChemicalStartClassifier(base StartClassifier) StartClassifier {
+	if base == nil {
+		base = CasedStarts
+	}
+	return &chemicalStarts{base}
+}
+
+// StartsSentence answers if the token at the given index can begin a
+// sentence.
+func (cs *chemicalStarts) StartsSentence(toks []*TextToken, idx int) bool {
+	if cs.base.StartsSentence(toks, idx) {
+		return true
+	}
+
+	l := len(toks)
+	t := toks[idx]
+	text := func(i int) string {
+		if i < l {
+			return toks[i].text
+		}
+		return ""
+	}
+	word := func(i int) bool {
+		return i < l && (toks[i].ttype == TokMayBeWord || toks[i].ttype == TokParenOpen ||
+			toks[i].ttype == TokBracketOpen)
+	}
+
+	if _, ok := chemPrefixes[strings.ToLower(t.text)]; ok && text(idx+1) == "-" && word(idx+2) {
+		return true
+	}
+
+	rs := []rune(t.text)
+	if len(rs) > 1 && unicode.IsLower(rs[0]) && unicode.IsUpper(rs[1]) {
+		return true
+	}
+
+	if !isASCIIDigits(t.text) {
+		return false
+	}
+	j := idx + 1
+	for isASCIIDigits(text(j+1)) && (text(j) == "," || text(j) == "'") {
+		j += 2
+	}
+	if rs := []rune(text(j)); len(rs) == 1 && unicode.IsUpper(rs[0]) && toks[j].begin == toks[j-1].end+1 {
+		j++ // `1H-`, `2H-`
+	}
+	if text(j) == "-" && word(j+1) {
+		return true
+	}
+	if j == idx+1 && j+1 < l && toks[j].ttype == TokSpace {
+		_, ok := chemUnits[text(j+1)]
+		return ok
+	}
+	return false
+}
+
+// SetStartClassifier makes the iterator consult the given classifier,
+// rather than that of its language pack, at candidate sentence
+// boundaries.
+func (si *SentenceIterator) SetStartClassifier(c StartClassifier) {
+	si.starts = c
+}
+
+// startClassifier answers the sentence start classifier in effect.
+func (si *SentenceIterator) startClassifier() StartClassifier {
+	switch {
+	case si.starts != nil:
+		return si.starts
+	case si.lang != nil && si.lang.Starts != nil:
+		return si.lang.Starts
+	}
+	return CasedStarts
+}
+
+// startsSentence answers if the text of the token at the given index
+// can begin a sentence, after a terminator and space.
+func (si *SentenceIterator) startsSentence(idx int) bool {
+	return si.startClassifier().StartsSentence(si.toks, idx)
+}
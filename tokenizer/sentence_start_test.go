@@ -0,0 +1,118 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"testing"
+)
+
+// withStarts answers a setup of sentence iterators consulting the
+// given start classifier and abbreviations.
+func withStarts(c StartClassifier, a *AbbrevSet) func(si *SentenceIterator) {
+	return func(si *SentenceIterator) {
+		si.SetStartClassifier(c)
+		if a != nil {
+			si.SetAbbreviations(a)
+		}
+	}
+}
+
+//
+
+func TestSentenceStart001(t *testing.T) {
+	chem := NewChemicalStartClassifier(nil)
+	cases := []struct {
+		in  string
+		exp []string
+	}{
+		{"The ether was cooled. tert-Butyl bromide was added.", []string{"The ether was cooled.", "tert-Butyl bromide was added."}},
+		{"The flask was dried. n-BuLi was added dropwise.", []string{"The flask was dried.", "n-BuLi was added dropwise."}},
+		{"The ester was dissolved in toluene. p-TsOH was added.", []string{"The ester was dissolved in toluene.", "p-TsOH was added."}},
+		{"The oil was distilled. α-pinene was obtained.", []string{"The oil was distilled.", "α-pinene was obtained."}},
+		{"The mixture was filtered. 2,3-Dichloro-5,6-dicyanobenzoquinone was added.", []string{"The mixture was filtered.", "2,3-Dichloro-5,6-dicyanobenzoquinone was added."}},
+		{"The residue was dried. 1H-Indole was then added.", []string{"The residue was dried.", "1H-Indole was then added."}},
+		{"The solution was cooled. 10 mL of water was added.", []string{"The solution was cooled.", "10 mL of water was added."}},
+		{"The layers were separated. pH was adjusted to 7.", []string{"The layers were separated.", "pH was adjusted to 7."}},
+	}
+
+	for _, c := range cases {
+		checkSentences(t, c.in, c.exp, sentenceTexts(c.in, withStarts(chem, nil)))
+		if obs := sentenceTexts(c.in, nil); len(obs) != 1 {
+			t.Errorf("Expected sentence count without classifier : 1, observed : %d : %q", len(obs), obs)
+		}
+	}
+}
+
+//
+
+func TestSentenceStart002(t *testing.T) {
+	chem := NewChemicalStartClassifier(nil)
+	cases := []string{
+		"The yield was approx. 5 g of product.",
+		"It was heated to ca. 80 degrees.",
+		"See the data in Fig. 2 for details.",
+		"The sample was stored at 5 deg. in the dark.",
+		"The value was 3. and not more.",
+	}
+
+	for _, in := range cases {
+		if obs := sentenceTexts(in, withStarts(chem, ChemistryAbbrevs())); len(obs) != 1 {
+			t.Errorf("Expected sentence count : 1, observed : %d : %q", len(obs), obs)
+		}
+	}
+}
+
+//
+
+func TestSentenceStart003(t *testing.T) {
+	in := "నీరు వేడిగా ఉంది. 2 గ్రాములు కలపండి."
+	exp := []string{
+		"నీరు వేడిగా ఉంది.",
+		"2 గ్రాములు కలపండి.",
+	}
+	checkSentences(t, "ScriptStarts", exp, sentenceTexts(in, withStarts(ScriptStarts, nil)))
+	checkSentences(t, "UncasedStarts", exp, sentenceTexts(in, withStarts(UncasedStarts, nil)))
+	if obs := sentenceTexts(in, withStarts(CasedStarts, nil)); len(obs) != 1 {
+		t.Errorf("Expected sentence count : 1, observed : %d : %q", len(obs), obs)
+	}
+
+	in = "It was hot. it was cold."
+	if obs := sentenceTexts(in, withStarts(ScriptStarts, nil)); len(obs) != 1 {
+		t.Errorf("Expected sentence count : 1, observed : %d : %q", len(obs), obs)
+	}
+	if obs := sentenceTexts(in, withStarts(UncasedStarts, nil)); len(obs) != 2 {
+		t.Errorf("Expected sentence count : 2, observed : %d : %q", len(obs), obs)
+	}
+}
+
+//
+
+func TestSentenceStart004(t *testing.T) {
+	in := "The flask was dried. n-BuLi was added."
+	d, _ := NewDocument("start-004")
+	d.SetLanguage(Language("en"))
+	d.SetSentenceOptions(SentenceOptions{Trace: true})
+	d.SetStartClassifier(NewChemicalStartClassifier(nil))
+	if err := d.SetInput("body", in); err != nil {
+		t.Fatalf("%v", err)
+	}
+	d.Tokenize()
+	d.AssembleSentences()
+
+	ss := d.SectionSentences("body")
+	if len(ss) != 2 {
+		t.Fatalf("Expected sentence count : 2, observed : %d", len(ss))
+	}
+	found := false
+	for _, bd := range ss[0].Trace() {
+		if bd.Rule == RuleStartFollow && bd.Split {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a decision : %s", BrDescriptions[RuleStartFollow])
+	}
+}
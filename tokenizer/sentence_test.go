@@ -15,6 +15,30 @@ import (
 	"testing"
 )
 
+// iterSentences answers the sentences of the given input, assembled by
+// a sentence iterator prepared by the given setup, if any.
+func iterSentences(in string, setup func(si *SentenceIterator)) []*Sentence {
+	si := NewSentenceIterator(textTokens(in))
+	if setup != nil {
+		setup(si)
+	}
+	var res []*Sentence
+	for err := si.MoveNext(); err == nil; err = si.MoveNext() {
+		res = append(res, si.Item())
+	}
+	return res
+}
+
+// sentenceTexts answers the texts of the sentences of the given input,
+// assembled as by `iterSentences`.
+func sentenceTexts(in string, setup func(si *SentenceIterator)) []string {
+	var res []string
+	for _, s := range iterSentences(in, setup) {
+		res = append(res, in[s.Begin():s.End()+1])
+	}
+	return res
+}
+
 //
 
 func TestPatent7k(t *testing.T) {
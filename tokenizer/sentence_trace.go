@@ -0,0 +1,138 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"fmt"
+)
+
+// SentenceOptions control optional behaviour of sentence assembly.
+// The zero value gives the default behaviour.
+type SentenceOptions struct {
+	Trace bool // Record the boundary decisions of each sentence?
+
+	// GroupAware makes sentences not end inside open groups, unless
+	// they span more than `MaxGroupSpan` tokens (`DefaultMaxGroupSpan`
+	// if zero).  Sentences then also record their groups.
+	GroupAware   bool
+	MaxGroupSpan int
+
+	// QuoteAware pairs quotation marks, telling them apart from
+	// apostrophes, and makes sentences not end inside open quotations,
+	// unless they span more than `MaxQuoteSpan` tokens
+	// (`DefaultMaxQuoteSpan` if zero).  Sentences then also record
+	// their quotations.
+	QuoteAware   bool
+	MaxQuoteSpan int
+}
+
+// BoundaryRule represents the rules that sentence assembly applies
+// at candidate sentence boundaries.
+type BoundaryRule byte
+
+// List of boundary rules.
+const (
+	RuleTerminator      BoundaryRule = iota // Terminator after a word
+	RuleNoPrecedingText                     // Terminator at the beginning of input
+	RuleSymbolBefore                        // Symbol or punctuation before a full stop
+	RuleNonTermAbbrev                       // `NonTerm` abbreviation: `Fig.`, `Dr.`
+	RuleMayBeTermAbbrev                     // `MayBeTerm` abbreviation: `etc.`
+	RuleGroupAbbrev                         // Multi-token abbreviation: `i.e.`
+	RuleUppercaseFollow                     // Uppercase after terminator and space
+	RuleLowercaseFollow                     // Other text after terminator and space
+	RuleNoSpaceFollow                       // Text right after terminator: `3.5`
+	RuleQuoteFollow                         // Opening quote after terminator and space
+	RuleQuoteClose                          // Closing quote right after terminator
+	RuleGroupOpen                           // Opening group after terminator
+	RuleProtectedAttach                     // Protected span attached to preceding sentence
+	RuleForcedBreak                         // Forced break: heading, block, etc.
+	RuleEndOfInput                          // No more tokens
+	RuleInsideGroup                         // Candidate boundary inside an open group
+	RuleInsideQuote                         // Candidate boundary inside an open quotation
+	RuleOrdinalNumber                       // Full stop after an ordinal number: `3.`
+	RuleStartFollow                         // Other sentence start after terminator and space
+	RuleUnspacedFollow                      // Text right after terminator, in unspaced languages
+	RuleModel                               // Decision of a sentence model
+	RuleProtectedSplit                      // Protected span beginning a sentence after terminator and space
+)
+
+// BrDescriptions helps in printing boundary rules.
+var BrDescriptions = map[BoundaryRule]string{
+	RuleTerminator:      "TERMINATOR",
+	RuleNoPrecedingText: "NO_PRECEDING_TEXT",
+	RuleSymbolBefore:    "SYMBOL_BEFORE_PERIOD",
+	RuleNonTermAbbrev:   "NON_TERM_ABBREV",
+	RuleMayBeTermAbbrev: "MAY_BE_TERM_ABBREV",
+	RuleGroupAbbrev:     "GROUP_ABBREV",
+	RuleUppercaseFollow: "UPPERCASE_FOLLOW",
+	RuleLowercaseFollow: "LOWERCASE_FOLLOW",
+	RuleNoSpaceFollow:   "NO_SPACE_FOLLOW",
+	RuleQuoteFollow:     "QUOTE_FOLLOW",
+	RuleQuoteClose:      "QUOTE_CLOSE",
+	RuleGroupOpen:       "GROUP_OPEN",
+	RuleProtectedAttach: "PROTECTED_ATTACH",
+	RuleForcedBreak:     "FORCED_BREAK",
+	RuleEndOfInput:      "END_OF_INPUT",
+	RuleInsideGroup:     "INSIDE_GROUP",
+	RuleInsideQuote:     "INSIDE_QUOTE",
+	RuleOrdinalNumber:   "ORDINAL_NUMBER",
+	RuleStartFollow:     "START_FOLLOW",
+	RuleUnspacedFollow:  "UNSPACED_FOLLOW",
+	RuleModel:           "MODEL",
+	RuleProtectedSplit:  "PROTECTED_SPLIT",
+}
+
+// BoundaryDecision records one decision taken by sentence assembly at
+// a candidate sentence boundary.
+//
+// It holds the index, offset and text of the token that triggered the
+// rule, the depth of the group stack at that point, and whether the
+// sentence ended there.
+type BoundaryDecision struct {
+	Token int
+	Begin int
+	Text  string
+	Rule  BoundaryRule
+	Depth int
+	Split bool
+}
+
+func (bd BoundaryDecision) String() string {
+	dec := "continue"
+	if bd.Split {
+		dec = "split"
+	}
+	return fmt.Sprintf("@%d[%d] %q %s depth=%d -> %s", bd.Begin, bd.Token, bd.Text, BrDescriptions[bd.Rule], bd.Depth, dec)
+}
+
+// SetOptions changes the optional behaviour of the iterator.
+func (si *SentenceIterator) SetOptions(o SentenceOptions) {
+	si.opts = o
+}
+
+// note records a boundary decision at the token with the given index,
+// in trace mode.
+func (si *SentenceIterator) note(idx int, rule BoundaryRule, split bool) {
+	if !si.opts.Trace {
+		return
+	}
+
+	bd := BoundaryDecision{Token: idx, Rule: rule, Depth: len(si.grpStack), Split: split}
+	if idx < len(si.toks) {
+		bd.Begin = si.toks[idx].begin
+		bd.Text = si.toks[idx].text
+	} else if idx > 0 {
+		bd.Begin = si.toks[idx-1].end + 1
+	}
+	si.trace = append(si.trace, bd)
+}
+
+// Trace answers the boundary decisions taken while assembling this
+// sentence, should it have been assembled in trace mode.  The last
+// decision is the one that ended the sentence.
+func (s *Sentence) Trace() []BoundaryDecision {
+	return s.trace
+}
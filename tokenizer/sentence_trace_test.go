@@ -0,0 +1,120 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSentenceTrace001(t *testing.T) {
+	in := "See Fig. 3 for details. It works, e.g. at 5.5 bar. Then stop"
+	var toks []*TextToken
+	ti := NewTextTokenIterator(in)
+	for err := ti.MoveNext(); err == nil; err = ti.MoveNext() {
+		toks = append(toks, ti.Item())
+	}
+
+	si := NewSentenceIterator(toks)
+	si.SetOptions(SentenceOptions{Trace: true})
+	var sents []*Sentence
+	for err := si.MoveNext(); err == nil; err = si.MoveNext() {
+		sents = append(sents, si.Item())
+	}
+	if len(sents) != 3 {
+		t.Fatalf("Expected sentence count : 3, observed : %d", len(sents))
+	}
+
+	exp := [][]struct {
+		text  string
+		rule  BoundaryRule
+		split bool
+	}{
+		{{".", RuleNonTermAbbrev, false}, {".", RuleTerminator, false}, {"It", RuleUppercaseFollow, true}},
+		{{".", RuleTerminator, false}, {"g", RuleNoSpaceFollow, false}, {".", RuleGroupAbbrev, false},
+			{".", RuleTerminator, false},
+			{"5", RuleNoSpaceFollow, false}, {".", RuleTerminator, false}, {"Then", RuleUppercaseFollow, true}},
+		{{"", RuleEndOfInput, true}},
+	}
+	for i, es := range exp {
+		tr := sents[i].Trace()
+		if len(tr) != len(es) {
+			t.Fatalf("Sentence %d : expected decision count : %d, observed : %d : %v", i, len(es), len(tr), tr)
+		}
+		for j, e := range es {
+			bd := tr[j]
+			if bd.Text != e.text || bd.Rule != e.rule || bd.Split != e.split {
+				t.Errorf("Sentence %d : expected : %v, observed : %s", i, e, bd.String())
+			}
+			if bd.Token < len(toks) && toks[bd.Token].Begin() != bd.Begin {
+				t.Errorf("Decision offset mismatch : %s", bd.String())
+			}
+		}
+	}
+
+	si = NewSentenceIterator(toks)
+	for err := si.MoveNext(); err == nil; err = si.MoveNext() {
+		if si.Item().Trace() != nil {
+			t.Errorf("Expected no trace outside trace mode")
+		}
+	}
+}
+
+//
+
+func TestSentenceTrace002(t *testing.T) {
+	in := "It was high.[4] It was done. Fig. A. B. 12 shows it."
+	var toks []*TextToken
+	ti := NewTextTokenIterator(in)
+	for err := ti.MoveNext(); err == nil; err = ti.MoveNext() {
+		toks = append(toks, ti.Item())
+	}
+
+	si := NewSentenceIterator(toks)
+	si.SetOptions(SentenceOptions{Trace: true})
+	for _, p := range []string{"[4]", "Fig. A. B. 12"} {
+		b := strings.Index(in, p)
+		if err := si.Protect(b, b+len(p)-1); err != nil {
+			t.Fatalf("Failed to protect a span : %s", err.Error())
+		}
+	}
+	var sents []*Sentence
+	for err := si.MoveNext(); err == nil; err = si.MoveNext() {
+		sents = append(sents, si.Item())
+	}
+	if len(sents) != 3 {
+		t.Fatalf("Expected sentence count : 3, observed : %d", len(sents))
+	}
+
+	exp := []struct {
+		sent  int
+		text  string
+		rule  BoundaryRule
+		split bool
+	}{
+		{0, "[", RuleProtectedAttach, false},
+		{0, "It", RuleUppercaseFollow, true},
+		{1, "Fig", RuleProtectedSplit, true},
+	}
+	for _, e := range exp {
+		found := false
+		for _, bd := range sents[e.sent].Trace() {
+			if bd.Text == e.text && bd.Rule == e.rule && bd.Split == e.split {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Sentence %d : expected decision : %v, observed : %v", e.sent, e, sents[e.sent].Trace())
+		}
+	}
+	for _, s := range sents {
+		for _, bd := range s.Trace() {
+			if bd.Rule == RuleUppercaseFollow && bd.Text == "Fig" {
+				t.Errorf("Expected the protected split not to be recorded as %s", BrDescriptions[bd.Rule])
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Snapshot represents an immutable view of a document, as of the time
+// it was frozen.
+//
+// Snapshots are safe for concurrent use by multiple goroutines, and
+// are unaffected by further processing of their documents.  Their
+// accessors answer fresh slices; words and annotations -- which can
+// be modified through their own APIs -- are answered as copies.
+type Snapshot struct {
+	id     string
+	isTech bool
+	secs   []string
+	sep    string
+	input  map[string]string
+	tokens map[string][]*TextToken
+	sents  map[string][]*Sentence
+	words  map[string][]Word
+	annos  map[string][]Annotation
+}
+
+// Freeze answers an immutable snapshot of the current state of the
+// document.
+//
+// A document itself is not safe for concurrent use: its processing
+// methods modify its state without synchronisation.  Documents that
+// need to be shared between goroutines should be frozen once their
+// processing is complete, and the snapshots shared instead.
+func (d *Document) Freeze() *Snapshot {
+	s := &Snapshot{}
+	s.id = d.id
+	s.isTech = d.isTech
+	s.secs = d.Sections()
+	s.sep = d.sep
+	s.input = make(map[string]string, len(d.input))
+	s.tokens = make(map[string][]*TextToken, len(d.tokens))
+	s.sents = make(map[string][]*Sentence, len(d.sents))
+	s.words = make(map[string][]Word, len(d.words))
+	s.annos = make(map[string][]Annotation, len(d.annos))
+
+	// Text tokens and sentences are never modified after their
+	// creation; it suffices to copy the slices holding them.
+	for sec, inp := range d.input {
+		s.input[sec] = inp
+	}
+	for sec, toks := range d.tokens {
+		s.tokens[sec] = append([]*TextToken(nil), toks...)
+	}
+	for sec, sents := range d.sents {
+		s.sents[sec] = append([]*Sentence(nil), sents...)
+	}
+	for sec, words := range d.words {
+		ws := make([]Word, len(words))
+		for i, w := range words {
+			ws[i] = *w
+		}
+		s.words[sec] = ws
+	}
+	for sec, annos := range d.annos {
+		as := make([]Annotation, len(annos))
+		for i, a := range annos {
+			as[i] = *a
+		}
+		s.annos[sec] = as
+	}
+
+	return s
+}
+
+func (s *Snapshot) ID() string {
+	return s.id
+}
+
+func (s *Snapshot) IsTechnical() bool {
+	return s.isTech
+}
+
+// Sections answers the names of the sections of the document, in
+// document order.
+func (s *Snapshot) Sections() []string {
+	return append([]string(nil), s.secs...)
+}
+
+// Input answers the input text of the given section, if one exists.
+func (s *Snapshot) Input(sec string) (string, error) {
+	if v, ok := s.input[sec]; ok {
+		return v, nil
+	}
+
+	return "", fmt.Errorf("No input text for section : %s", sec)
+}
+
+// FullText answers the texts of all the sections concatenated in
+// document order, separated by the section separator of the document.
+func (s *Snapshot) FullText() string {
+	texts := make([]string, len(s.secs))
+	for i, sec := range s.secs {
+		texts[i] = s.input[sec]
+	}
+	return strings.Join(texts, s.sep)
+}
+
+// SectionTokens answers the tokens of the given section.
+func (s *Snapshot) SectionTokens(sec string) []*TextToken {
+	if v, ok := s.tokens[sec]; ok {
+		return append([]*TextToken(nil), v...)
+	}
+
+	return nil
+}
+
+// SectionSentences answers the sentences of the given section.
+func (s *Snapshot) SectionSentences(sec string) []*Sentence {
+	if v, ok := s.sents[sec]; ok {
+		return append([]*Sentence(nil), v...)
+	}
+
+	return nil
+}
+
+// SectionWords answers copies of the words of the given section.
+func (s *Snapshot) SectionWords(sec string) []*Word {
+	v, ok := s.words[sec]
+	if !ok {
+		return nil
+	}
+
+	res := make([]*Word, len(v))
+	for i := range v {
+		w := v[i]
+		res[i] = &w
+	}
+	return res
+}
+
+// SectionAnnotations answers copies of the annotations of the given
+// section.
+func (s *Snapshot) SectionAnnotations(sec string) []*Annotation {
+	v, ok := s.annos[sec]
+	if !ok {
+		return nil
+	}
+
+	res := make([]*Annotation, len(v))
+	for i := range v {
+		a := v[i]
+		res[i] = &a
+	}
+	return res
+}
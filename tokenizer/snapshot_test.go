@@ -0,0 +1,94 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFreeze001(t *testing.T) {
+	doc, _ := NewDocument("Freeze001")
+	doc.SetInput("T", "Benzene (CAS 71-43-2)")
+	doc.SetInput("A", "Benzene was distilled. It boiled at 80 C.")
+	doc.Tokenize()
+	doc.AssembleSentences()
+	doc.Annotate(&Annotation{"Freeze001", "A", 0, 6, "Benzene", "NN"}, "POS")
+
+	snap := doc.Freeze()
+	ntoks := len(snap.SectionTokens("A"))
+
+	doc.Annotate(&Annotation{"Freeze001", "A", 0, 6, "Benzene", "CHEMICAL"}, "CLS")
+	doc.SetInput("A", "Changed text.")
+	doc.Tokenize()
+	doc.RecognizeIdentifiers("T")
+
+	if c := len(snap.SectionTokens("A")); c != ntoks {
+		t.Errorf("Expected token count : %d, observed : %d", ntoks, c)
+	}
+	if c := len(snap.SectionSentences("A")); c != 2 {
+		t.Errorf("Expected sentence count : 2, observed : %d", c)
+	}
+	if ws := snap.SectionWords("A"); len(ws) != 1 || ws[0].POS() != "NN" || ws[0].Class() != "" {
+		t.Errorf("Expected an unclassified word in the snapshot")
+	}
+	if ws := snap.SectionWords("T"); ws != nil {
+		t.Errorf("Expected no words in the title of the snapshot, observed : %d", len(ws))
+	}
+	if inp, _ := snap.Input("A"); inp != "Benzene was distilled. It boiled at 80 C." {
+		t.Errorf("Unexpected input in the snapshot : %s", inp)
+	}
+
+	as := snap.SectionAnnotations("A")
+	as[0].Begin = 5
+	if snap.SectionAnnotations("A")[0].Begin != 0 {
+		t.Errorf("Expected annotations of the snapshot to be unaffected by changes to copies")
+	}
+	toks := snap.SectionTokens("A")
+	toks[0] = nil
+	if snap.SectionTokens("A")[0] == nil {
+		t.Errorf("Expected tokens of the snapshot to be unaffected by changes to copies")
+	}
+}
+
+//
+
+func TestFreezeConcurrent001(t *testing.T) {
+	doc, _ := NewDocument("Freeze002")
+	doc.SetInput("T", "A title")
+	doc.SetInput("A", "First sentence here. Second sentence (CAS 64-17-5) there.")
+	doc.Tokenize()
+	doc.AssembleSentences()
+	snap := doc.Freeze()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for _, sec := range snap.Sections() {
+					for _, tok := range snap.SectionTokens(sec) {
+						_ = tok.Text()
+					}
+					for _, w := range snap.SectionWords(sec) {
+						_ = w.Class()
+					}
+					_ = snap.SectionSentences(sec)
+				}
+				_ = snap.FullText()
+			}
+		}()
+	}
+
+	for j := 0; j < 20; j++ {
+		doc.Tokenize()
+		doc.AssembleSentences()
+		doc.RecognizeIdentifiers("A")
+		doc.Annotate(&Annotation{"Freeze002", "A", 0, 4, "First", "JJ"}, "POS")
+	}
+	wg.Wait()
+}
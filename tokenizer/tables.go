@@ -5,6 +5,10 @@
 
 package tokenizer
 
+import (
+	"strings"
+)
+
 // NonTermAbbrevs lists the common abbreviations that could end with a
 // full stop, but without ending the sentence.  The abbrevs are in
 // lowercase.
@@ -35,3 +39,60 @@ var MayBeTermGroupAbbrevs = map[string][]string{
 	"e": {"i"},
 	"g": {"e"},
 }
+
+// AbbrevSet groups the abbreviation tables consulted during sentence
+// assembly.  Its tables have the same semantics as `NonTermAbbrevs`,
+// `MayBeTermAbbrevs` and `MayBeTermGroupAbbrevs`, respectively.
+type AbbrevSet struct {
+	NonTerm   map[string]struct{}
+	MayBeTerm map[string]struct{}
+	Group     map[string][]string
+}
+
+// DefaultAbbrevs answers an abbreviation set of the package-level
+// tables.  Changes to those tables are visible through it.
+func DefaultAbbrevs() *AbbrevSet {
+	return &AbbrevSet{NonTermAbbrevs, MayBeTermAbbrevs, MayBeTermGroupAbbrevs}
+}
+
+// chemNonTermAbbrevs lists abbreviations common in chemical
+// literature, that do not end sentences.
+var chemNonTermAbbrevs = []string{
+	"approx", "ca", "cf", "al", "eq", "equiv", "ref", "refs", "no", "nos",
+	"vol", "vols", "ed", "eds", "temp", "conc", "soln", "ppt", "anal",
+	"calcd", "mp", "bp", "lit", "pat", "appl",
+}
+
+// ChemistryAbbrevs answers an abbreviation set that extends the
+// default one with abbreviations common in chemical literature:
+// `ca.`, `cf.`, `et al.`, `equiv.`, `calcd.`, etc.
+func ChemistryAbbrevs() *AbbrevSet {
+	return DefaultAbbrevs().With(chemNonTermAbbrevs...)
+}
+
+// With answers a copy of this abbreviation set, extended with the
+// given non-terminating abbreviations.  They are converted to
+// lowercase, and any trailing full stop is dropped.
+func (a *AbbrevSet) With(nonTerm ...string) *AbbrevSet {
+	res := &AbbrevSet{
+		NonTerm:   make(map[string]struct{}, len(a.NonTerm)+len(nonTerm)),
+		MayBeTerm: make(map[string]struct{}, len(a.MayBeTerm)),
+		Group:     make(map[string][]string, len(a.Group)),
+	}
+	for k := range a.NonTerm {
+		res.NonTerm[k] = struct{}{}
+	}
+	for k := range a.MayBeTerm {
+		res.MayBeTerm[k] = struct{}{}
+	}
+	for k, v := range a.Group {
+		res.Group[k] = v
+	}
+	for _, s := range nonTerm {
+		s = strings.ToLower(strings.TrimSuffix(s, "."))
+		if s != "" {
+			res.NonTerm[s] = struct{}{}
+		}
+	}
+	return res
+}
@@ -45,6 +45,7 @@ const (
 	TokMayBeWord
 	TokWord
 	TokSentence
+	TokNotation // Chemical line notation: SMILES, InChI
 )
 
 // TtDescriptions helps in printing token types.
@@ -52,7 +53,7 @@ var TtDescriptions = map[TokenType]string{
 	TokOther:        "TokOther",
 	TokSpace:        "TokSpace",
 	TokLetter:       "TokLetter",
-	TokNumber:       "TokLetter",
+	TokNumber:       "TokNumber",
 	TokMayBeTerm:    "TokMayBeTerm",
 	TokTerm:         "TokTerm",
 	TokPause:        "TokPause",
@@ -71,6 +72,7 @@ var TtDescriptions = map[TokenType]string{
 	TokMayBeWord:    "TokMayBeWord",
 	TokWord:         "TokWord",
 	TokSentence:     "TokSentence",
+	TokNotation:     "TokNotation",
 }
 
 // RuneType answers the token type of the given rune.
@@ -88,7 +90,14 @@ func RuneType(r rune) TokenType {
 	case r == '!', r == '?',
 		r == '\u3002', // Ideographic full stop
 		r == '\uff01', // Full width exclamation mark
-		r == '\uff1f': // Full width question mark
+		r == '\uff1f', // Full width question mark
+		r == '\u0964', // Devanagari danda
+		r == '\u0965', // Devanagari double danda
+		r == '\u061f', // Arabic question mark
+		r == '\u06d4', // Arabic full stop
+		r == '\u0589', // Armenian full stop
+		r == '\u1362', // Ethiopic full stop
+		r == '\u1367': // Ethiopic question mark
 		return TokTerm
 
 	case r == '.',
@@ -5,8 +5,14 @@
 
 package tokenizer
 
+import (
+	"fmt"
+	"sort"
+)
+
 // Word represents a token whose type is one of `TokMayBeWord` or
-// `TokWord`, and qualifies it.
+// `TokWord`, and qualifies it.  Punctuation and symbols made words by
+// `EnsureWords` or `AssembleWords` retain their own token types.
 //
 // It holds information regarding the so-called IOB (Inside, Outside,
 // Beginning) status of the token, its lemma form (in case of a word),
@@ -17,6 +23,7 @@ type Word struct {
 	pos   string    // Part of Speech
 	lemma string    // Lemma form
 	class string    // Assigned after learning
+	conf  float64   // Confidence in the learned IOB status and class
 }
 
 // newWord creates and initialises a word with its properties set to
@@ -63,3 +70,111 @@ func (w *Word) Lemma() string {
 func (w *Word) Class() string {
 	return w.class
 }
+
+// Confidence answers the probability, between 0 and 1, of the learned
+// IOB status and class of this word.  It is 0 for words not qualified
+// by learning.
+func (w *Word) Confidence() float64 {
+	return w.conf
+}
+
+// SetIOB changes the IOB status of this word.
+func (w *Word) SetIOB(iob byte) {
+	w.iob = iob
+}
+
+// SetPOS changes the part of speech of this word.
+func (w *Word) SetPOS(pos string) {
+	w.pos = pos
+}
+
+// SetLemma changes the lemma form of this word.
+func (w *Word) SetLemma(lemma string) {
+	w.lemma = lemma
+}
+
+// SetClass changes the class of this word.
+func (w *Word) SetClass(class string) {
+	w.class = class
+}
+
+// SetConfidence changes the confidence in the learned IOB status and
+// class of this word.
+func (w *Word) SetConfidence(conf float64) {
+	w.conf = conf
+}
+
+// EnsureWords makes every non-space token of the given section part
+// of a word, creating single-token words for those not already part
+// of one.  Punctuation and symbols become words of their own token
+// types.  It answers all the words of the section, in text order.
+//
+// This prepares sections for processors that qualify every word, such
+// as part of speech taggers.  See `AssembleWords` for words that span
+// several tokens.
+func (d *Document) EnsureWords(sec string) ([]*Word, error) {
+	toks, ok := d.tokens[sec]
+	if !ok {
+		return nil, fmt.Errorf("Unknown section : %s", sec)
+	}
+
+	words := d.words[sec]
+	sort.SliceStable(words, func(i, j int) bool { return words[i].Begin() < words[j].Begin() })
+
+	res := make([]*Word, 0, len(toks))
+	k := 0
+	for _, t := range toks {
+		for k < len(words) && words[k].End() < t.begin {
+			res = append(res, words[k])
+			k++
+		}
+		if t.ttype == TokSpace {
+			continue
+		}
+		if k < len(words) && words[k].Begin() <= t.begin {
+			continue // Part of an existing word
+		}
+
+		w := newWord(t.text, t.begin, t.end)
+		if t.ttype != TokMayBeWord {
+			w.token.ttype = t.ttype
+		}
+		res = append(res, w)
+	}
+	res = append(res, words[k:]...)
+
+	d.words[sec] = res
+	return res, nil
+}
+
+// SentenceWords answers the words of the given section, grouped by
+// sentence.  The whole section is a single group, should its sentences
+// not have been assembled.  Words are created for the tokens that are
+// not already part of one; see `EnsureWords`.
+func (d *Document) SentenceWords(sec string) ([][]*Word, error) {
+	ws, err := d.EnsureWords(sec)
+	if err != nil {
+		return nil, err
+	}
+
+	sents := d.SectionSentences(sec)
+	if len(sents) == 0 {
+		return [][]*Word{ws}, nil
+	}
+
+	res := make([][]*Word, 0, len(sents))
+	k := 0
+	for _, s := range sents {
+		for k < len(ws) && ws[k].Begin() < s.Begin() {
+			k++
+		}
+		b := k
+		for k < len(ws) && ws[k].Begin() <= s.End() {
+			k++
+		}
+		if k > b {
+			res = append(res, ws[b:k])
+		}
+	}
+	return res, nil
+}
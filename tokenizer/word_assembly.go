@@ -0,0 +1,252 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// contractionEndings lists the endings of English contractions and
+// possessives, that follow their apostrophes: `wasn't`, `it's`,
+// `we'll`, etc.  The endings are in lowercase.
+var contractionEndings = map[string]struct{}{
+	"s":  {},
+	"t":  {},
+	"d":  {},
+	"m":  {},
+	"re": {},
+	"ve": {},
+	"ll": {},
+}
+
+// wordMarks lists the marks that belong to the words they immediately
+// follow.
+var wordMarks = map[string]struct{}{
+	"\u00ae": {}, // ® Registered sign
+	"\u2122": {}, // ™ Trade mark sign
+	"\u2120": {}, // ℠ Service mark sign
+}
+
+// AssembleWords builds the words of the sections of the document from
+// their text tokens, so that every section has a complete word layer.
+//
+// Runs of tokens not separated by space are merged into single words
+// when they form hyphenated compounds (`4-methoxy-benzaldehyde`,
+// `50W-X8`), numbers (`31.1`, `1,000`), locants (`N,N-dimethyl`),
+// abbreviations (`e.g.`, `Dr.`), contractions (`wasn't`) or names
+// with registered marks (`Teflon®`).  Every other non-space token
+// becomes a word on its own; punctuation and symbols retain their
+// token types.  Words with letters are confirmed as `TokWord`s.
+//
+// Existing words, such as those of annotations or identifiers, are
+// retained, and their tokens are not assembled again.  Assembling
+// again after an edit fills the gaps left by invalidated words.
+func (d *Document) AssembleWords() {
+	for _, sec := range d.secs {
+		if _, ok := d.tokens[sec]; ok {
+			d.assembleWords(sec)
+		}
+	}
+}
+
+// assembleWords builds the words of the given section.
+func (d *Document) assembleWords(sec string) {
+	toks := d.tokens[sec]
+	inp := d.input[sec]
+
+	words := append([]*Word(nil), d.words[sec]...)
+	wa := &wordAssembler{toks: toks, covered: make([]bool, len(toks)), abbrevs: d.abbrevs}
+	if wa.abbrevs == nil {
+		wa.abbrevs = DefaultAbbrevs()
+	}
+	for _, w := range words {
+		i := sort.Search(len(toks), func(i int) bool { return toks[i].begin >= w.Begin() })
+		for ; i < len(toks) && toks[i].end <= w.End(); i++ {
+			wa.covered[i] = true
+		}
+	}
+
+	for i := 0; i < len(toks); {
+		t := toks[i]
+		if t.ttype == TokSpace || wa.covered[i] {
+			i++
+			continue
+		}
+
+		j := wa.end(i)
+		b, e := t.begin, toks[j-1].end
+		w := newWord(inp[b:e+1], b, e)
+		switch {
+		case j == i+1 && !isWordToken(t):
+			w.token.ttype = t.ttype
+		case hasLetter(w.Text()):
+			w.token.ttype = TokWord
+		}
+		words = append(words, w)
+		i = j
+	}
+
+	sort.SliceStable(words, func(i, j int) bool { return words[i].Begin() < words[j].Begin() })
+	d.words[sec] = words
+}
+
+// wordAssembler finds the extents of the words of a section.
+type wordAssembler struct {
+	toks    []*TextToken
+	covered []bool // Tokens of existing words
+	abbrevs *AbbrevSet
+}
+
+// free answers the token at the given index, if it exists and is not
+// part of an existing word.
+func (wa *wordAssembler) free(k int) (*TextToken, bool) {
+	if k < 0 || k >= len(wa.toks) || wa.covered[k] {
+		return nil, false
+	}
+	return wa.toks[k], true
+}
+
+// word answers if the token at the given index is a free word token.
+func (wa *wordAssembler) word(k int) bool {
+	t, ok := wa.free(k)
+	return ok && isWordToken(t)
+}
+
+// text answers the text of the token at the given index, if it is
+// free, and the empty string otherwise.
+func (wa *wordAssembler) text(k int) string {
+	if t, ok := wa.free(k); ok {
+		return t.text
+	}
+	return ""
+}
+
+// end answers the index of the token following the word that begins
+// with the token at the given index.
+func (wa *wordAssembler) end(i int) int {
+	if !isWordToken(wa.toks[i]) {
+		return i + 1
+	}
+	if j := wa.abbrevEnd(i); j > i {
+		return j
+	}
+
+	j := i + 1
+	for {
+		switch {
+		case wa.word(j): // `50` `W`
+			j++
+		case (wa.text(j) == "." || wa.text(j) == ",") && isASCIIDigits(wa.text(j-1)) &&
+			wa.word(j+1) && isASCIIDigits(wa.text(j+1)):
+			j += 2
+		case wa.hyphen(j) && wa.word(j+1):
+			j += 2
+		case wa.locantsEnd(j) > j:
+			j = wa.locantsEnd(j)
+		case wa.contraction(j):
+			j += 2
+		case isWordMark(wa.text(j)):
+			return j + 1
+		default:
+			return j
+		}
+	}
+}
+
+// hyphen answers if the token at the given index is a free hyphen.
+func (wa *wordAssembler) hyphen(k int) bool {
+	t, ok := wa.free(k)
+	return ok && isHyphen(t)
+}
+
+// locantsEnd answers the index of the hyphen that ends the list of
+// locants continued by the comma at the given index, as in `N,N-` or
+// `2,3,5-`.  It answers the given index when there is no such list.
+func (wa *wordAssembler) locantsEnd(j int) int {
+	if !isLocant(wa.text(j - 1)) {
+		return j
+	}
+	k := j
+	for wa.text(k) == "," && wa.word(k+1) && isLocant(wa.text(k+1)) {
+		k += 2
+	}
+	if k > j && wa.hyphen(k) && wa.word(k+1) {
+		return k
+	}
+	return j
+}
+
+// contraction answers if the token at the given index is an
+// apostrophe that, with the following token, ends a contraction.
+func (wa *wordAssembler) contraction(j int) bool {
+	switch wa.text(j) {
+	case "'", "\u2019": // ’ Right single quotation mark
+	default:
+		return false
+	}
+	if _, ok := contractionEndings[strings.ToLower(wa.text(j+1))]; !ok {
+		return false
+	}
+	return !wa.word(j + 2)
+}
+
+// abbrevEnd answers the index of the token following the abbreviation
+// that begins with the token at the given index, or the given index if
+// none does.  Abbreviations are either single letters alternating with
+// full stops (`e.g.`, `U.S.`), or words of the abbreviation tables
+// followed by full stops (`Dr.`, `etc.`).
+func (wa *wordAssembler) abbrevEnd(i int) int {
+	j := i
+	for wa.word(j) && utf8.RuneCountInString(wa.text(j)) == 1 && hasLetter(wa.text(j)) && wa.text(j+1) == "." {
+		j += 2
+	}
+	if j-i >= 4 {
+		return j
+	}
+
+	if wa.text(i+1) != "." {
+		return i
+	}
+	lw := strings.ToLower(wa.text(i))
+	if _, ok := wa.abbrevs.NonTerm[lw]; ok {
+		return i + 2
+	}
+	if _, ok := wa.abbrevs.MayBeTerm[lw]; ok {
+		return i + 2
+	}
+	return i
+}
+
+// isWordToken answers if the given token may be, or is, a word.
+func isWordToken(t *TextToken) bool {
+	return t.ttype == TokMayBeWord || t.ttype == TokWord
+}
+
+// isWordMark answers if the given text is a mark that belongs to the
+// preceding word.
+func isWordMark(s string) bool {
+	_, ok := wordMarks[s]
+	return ok
+}
+
+// isLocant answers if the given text can be a locant in a chemical
+// name: a number, or one or two letters (`N`, `O`, `3a`).
+func isLocant(s string) bool {
+	return isASCIIDigits(s) || (s != "" && utf8.RuneCountInString(s) <= 2)
+}
+
+// hasLetter answers if the given text has at least one letter.
+func hasLetter(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
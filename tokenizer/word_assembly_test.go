@@ -0,0 +1,142 @@
+// Copyright (c) 2015 RxnWeaver
+//
+// Part of the RxnWeaver suite of projects.  See README.md and LICENSE
+// for more details.
+
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+)
+
+// assembledWords answers the texts of the words assembled from the
+// given input, separated by `|`.
+func assembledWords(in string, a *AbbrevSet) string {
+	d, _ := NewDocument("AssembledWords")
+	if a != nil {
+		d.SetAbbreviations(a)
+	}
+	d.SetInput("P", in)
+	d.Tokenize()
+	d.AssembleWords()
+
+	var res []string
+	for _, w := range d.SectionWords("P") {
+		res = append(res, w.Text())
+	}
+	return strings.Join(res, "|")
+}
+
+//
+
+func TestAssembleWords001(t *testing.T) {
+	cases := []struct {
+		in  string
+		exp string
+	}{
+		{"4-methoxy-benzaldehyde (31.1 g, 0.5 mmol)", "4-methoxy-benzaldehyde|(|31.1|g|,|0.5|mmol|)"},
+		{"Dowex 50W-X8 and 1,000.5 mL", "Dowex|50W-X8|and|1,000.5|mL"},
+		{"N,N-dimethylformamide and 2,3-dichlorophenol", "N,N-dimethylformamide|and|2,3-dichlorophenol"},
+		{"at -78 °C", "at|-|78|°|C"},
+		{"e.g. in the U.S. and i.e. here", "e.g.|in|the|U.S.|and|i.e.|here"},
+		{"Dr. Smith's flask wasn't dry.", "Dr.|Smith's|flask|wasn't|dry|."},
+		{"It’s Teflon® and Dowex™.", "It’s|Teflon®|and|Dowex™|."},
+		{"the chemists' self-assembly", "the|chemists|'|self-assembly"},
+		{"2- and 3-methyl", "2|-|and|3-methyl"},
+		{"vitamin C. Then", "vitamin|C|.|Then"},
+	}
+	for _, c := range cases {
+		if obs := assembledWords(c.in, nil); obs != c.exp {
+			t.Errorf("Expected words : %s, observed : %s", c.exp, obs)
+		}
+	}
+
+	exp := "ca.|5|g|,|cf.|Ref.|2"
+	if obs := assembledWords("ca. 5 g, cf. Ref. 2", ChemistryAbbrevs()); obs != exp {
+		t.Errorf("Expected words : %s, observed : %s", exp, obs)
+	}
+}
+
+//
+
+func TestAssembleWords002(t *testing.T) {
+	in := "The 31.1 g of NaCl was added, e.g. slowly."
+	d, _ := NewDocument("AssembleWords002")
+	d.SetInput("P", in)
+	d.Tokenize()
+
+	b := strings.Index(in, "g of")
+	a := &Annotation{DocumentID: "AssembleWords002", Section: "P",
+		Begin: b, End: b + len("g of NaCl") - 1, Entity: "g of NaCl", Property: "QTY"}
+	if err := d.Annotate(a, "CLS"); err != nil {
+		t.Fatalf("Unable to annotate : %s", err.Error())
+	}
+	d.AssembleWords()
+
+	exp := []struct {
+		text  string
+		ttype TokenType
+	}{
+		{"The", TokWord},
+		{"31.1", TokMayBeWord},
+		{"g of NaCl", TokMayBeWord},
+		{"was", TokWord},
+		{"added", TokWord},
+		{",", TokPause},
+		{"e.g.", TokWord},
+		{"slowly", TokWord},
+		{".", TokMayBeTerm},
+	}
+	ws := d.SectionWords("P")
+	if len(ws) != len(exp) {
+		t.Fatalf("Expected word count : %d, observed : %d", len(exp), len(ws))
+	}
+	for i, w := range ws {
+		if w.Text() != exp[i].text || w.Type() != exp[i].ttype {
+			t.Errorf("Expected word : %s %s, observed : %s %s", exp[i].text, TtDescriptions[exp[i].ttype],
+				w.Text(), TtDescriptions[w.Type()])
+		}
+	}
+	if ws[2].Class() != "QTY" {
+		t.Errorf("Expected the annotated word to retain its class, observed : %s", ws[2].Class())
+	}
+
+	// Assembly is idempotent, and complete for `EnsureWords`.
+	d.AssembleWords()
+	if c, _ := d.SectionWordCount("P"); c != len(exp) {
+		t.Errorf("Expected word count : %d, observed : %d", len(exp), c)
+	}
+	if ews, _ := d.EnsureWords("P"); len(ews) != len(exp) {
+		t.Errorf("Expected word count : %d, observed : %d", len(exp), len(ews))
+	}
+}
+
+//
+
+func TestAssembleWords003(t *testing.T) {
+	in := "The solvent (CAS 7732-18-5) was re-added."
+	pl, err := NewPipelineFromNames("words", "identifiers", "tokenize")
+	if err != nil {
+		t.Fatalf("Failed to create pipeline : %s", err.Error())
+	}
+	exp := "tokenize identifiers words"
+	if obs := strings.Join(pl.Stages(), " "); obs != exp {
+		t.Fatalf("Expected stages : %s, observed : %s", exp, obs)
+	}
+
+	d, _ := NewDocument("AssembleWords003")
+	d.SetInput("P", in)
+	if _, err := pl.Run(d); err != nil {
+		t.Fatalf("Failed to run pipeline : %s", err.Error())
+	}
+
+	var obs []string
+	for _, w := range d.SectionWords("P") {
+		obs = append(obs, w.Text())
+	}
+	expw := "The|solvent|(|CAS|7732-18-5|)|was|re-added|."
+	if strings.Join(obs, "|") != expw {
+		t.Errorf("Expected words : %s, observed : %s", expw, strings.Join(obs, "|"))
+	}
+}
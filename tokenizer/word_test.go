@@ -63,3 +63,71 @@ func TestDoubleWord001(t *testing.T) {
 		t.Fatalf("Expect word count : 1, observed : %d", c)
 	}
 }
+
+//
+
+func TestEnsureWords001(t *testing.T) {
+	doc, _ := NewDocument("EnsureWords001")
+	doc.SetInput("Para1", "Raamu is a good boy.")
+	doc.Tokenize()
+
+	a, _ := NewAnnotation("EnsureWords001\tPara1\t11\t18\tgood boy\tQUALIFIER")
+	doc.Annotate(a, "CLS")
+	ws, err := doc.EnsureWords("Para1")
+	if err != nil {
+		t.Fatalf("Failed to ensure words : %s", err.Error())
+	}
+
+	exp := []string{"Raamu", "is", "a", "good boy", "."}
+	if len(ws) != len(exp) {
+		t.Fatalf("Expected word count : %d, observed : %d", len(exp), len(ws))
+	}
+	for i, w := range ws {
+		if w.Text() != exp[i] {
+			t.Errorf("Expected word : %s, observed : %s", exp[i], w.Text())
+		}
+	}
+	if ws[3].Class() != "QUALIFIER" || ws[4].Type() != TokMayBeTerm {
+		t.Errorf("Expected the annotated word and a full stop, observed : %s, %s", ws[3].Class(), TtDescriptions[ws[4].Type()])
+	}
+
+	ws[0].SetPOS("PROPN")
+	ws2, _ := doc.EnsureWords("Para1")
+	if len(ws2) != len(ws) || ws2[0].POS() != "PROPN" {
+		t.Errorf("Expected the same words again")
+	}
+	if _, err := doc.EnsureWords("Para2"); err == nil {
+		t.Errorf("Expected an error for an unknown section")
+	}
+}
+
+//
+
+func TestSentenceWords001(t *testing.T) {
+	doc, _ := NewDocument("SentenceWords001")
+	doc.SetInput("Para1", "Raamu is a good boy. He plays.")
+	doc.Tokenize()
+
+	groups, err := doc.SentenceWords("Para1")
+	if err != nil {
+		t.Fatalf("Failed to group words : %s", err.Error())
+	}
+	if len(groups) != 1 || len(groups[0]) != 9 {
+		t.Fatalf("Expected a single group of 9 words, observed : %d", len(groups))
+	}
+
+	doc.AssembleSentences()
+	groups, _ = doc.SentenceWords("Para1")
+	exp := []int{6, 3}
+	if len(groups) != len(exp) {
+		t.Fatalf("Expected group count : %d, observed : %d", len(exp), len(groups))
+	}
+	for i, g := range groups {
+		if len(g) != exp[i] {
+			t.Errorf("Expected word count of group %d : %d, observed : %d", i, exp[i], len(g))
+		}
+	}
+	if _, err := doc.SentenceWords("Para2"); err == nil {
+		t.Errorf("Expected an error for an unknown section")
+	}
+}